@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/disasm"
+	"spike-interpreter-go/spike/vm"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// bytecodeExtension is the extension of bytecode files.
+const bytecodeExtension = ".spkc"
+
+func compileCommand(env environment, args []string) int {
+	flags := flag.NewFlagSet("compile", flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	output := flags.String("o", "", "bytecode file to write, the source file with the extension "+bytecodeExtension+" by default")
+	level := optimizationFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 || flags.Arg(0) == stdinName && *output == "" {
+		usage(env.stderr)
+		return exitUsage
+	}
+
+	name := flags.Arg(0)
+	if *output == "" {
+		*output = strings.TrimSuffix(name, filepath.Ext(name)) + bytecodeExtension
+	}
+
+	source, err := readSource(env, name)
+	if err != nil {
+		reportError(env.stderr, err)
+		return exitFailure
+	}
+
+	bytecode, err := compileSource(name, source, *level)
+	if err != nil {
+		reportError(env.stderr, err)
+		return exitInvalid
+	}
+
+	data, err := compiler.Marshal(bytecode)
+	if err == nil {
+		err = ioutil.WriteFile(*output, data, 0644)
+	}
+	if err != nil {
+		reportError(env.stderr, err)
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func disasmCommand(env environment, args []string) int {
+	flags := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	level := optimizationFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	name := stdinName
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		name = flags.Arg(0)
+	default:
+		usage(env.stderr)
+		return exitUsage
+	}
+
+	source, err := readSource(env, name)
+	if err != nil {
+		reportError(env.stderr, err)
+		return exitFailure
+	}
+
+	// Bytecode files are not verified, so that malformed ones can be looked at.
+	var bytecode *compiler.Bytecode
+	var sources map[string][]byte
+	if compiler.IsBytecode(source) {
+		bytecode, err = compiler.Unmarshal(source)
+	} else {
+		bytecode, err = compileSource(name, source, *level)
+		sources = map[string][]byte{sourceName(name): source}
+	}
+	if err != nil {
+		reportError(env.stderr, err)
+		return exitInvalid
+	}
+	fmt.Fprint(env.stdout, disasm.Bytecode(bytecode, sources))
+
+	return exitOK
+}
+
+// compileSource compiles source optimized at level, or loads and verifies it
+// when it is a bytecode file, which was optimized when it was compiled.
+func compileSource(name string, source []byte, level compiler.OptimizationLevel) (*compiler.Bytecode, error) {
+	if compiler.IsBytecode(source) {
+		bytecode, err := compiler.Unmarshal(source)
+		if err == nil {
+			err = vm.Verify(bytecode)
+		}
+		return bytecode, err
+	}
+
+	program, err := parseSource(name, source)
+	if err != nil {
+		return nil, err
+	}
+
+	comp := newCompiler()
+	if err := comp.Compile(program); err != nil {
+		return nil, err
+	}
+
+	return compiler.Optimize(comp.Bytecode(), level), nil
+}
+
+// optimizationLevel is the value of the -O flag.
+type optimizationLevel compiler.OptimizationLevel
+
+func optimizationFlag(flags *flag.FlagSet) *compiler.OptimizationLevel {
+	level := compiler.O0
+	flags.Var(
+		(*optimizationLevel)(&level),
+		"O",
+		fmt.Sprintf("optimization level of compiled programs, 0 to %d", compiler.MaxOptimizationLevel),
+	)
+
+	return &level
+}
+
+func (level *optimizationLevel) String() string {
+	return strconv.Itoa(int(*level))
+}
+
+func (level *optimizationLevel) Set(value string) error {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < int(compiler.O0) || number > int(compiler.MaxOptimizationLevel) {
+		return errors.Errorf("optimization level must be 0 to %d", compiler.MaxOptimizationLevel)
+	}
+
+	*level = optimizationLevel(number)
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import "spike-interpreter-go/spike/compiler"
+
+func checkCommand(env environment, args []string) int {
+	if len(args) == 0 {
+		args = []string{stdinName}
+	}
+
+	status := exitOK
+	for _, name := range args {
+		source, err := readSource(env, name)
+		if err != nil {
+			reportError(env.stderr, err)
+			status = exitFailure
+			continue
+		}
+
+		if _, err := compileSource(name, source, compiler.O0); err != nil {
+			reportError(env.stderr, err)
+			status = exitInvalid
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"spike-interpreter-go/spike/format"
+)
+
+func fmtCommand(env environment, args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	write := flags.Bool("w", false, "write the result to the files instead of the standard output")
+	list := flags.Bool("l", false, "list the files whose formatting differs")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	names := flags.Args()
+	if len(names) == 0 {
+		if *write {
+			fmt.Fprintln(env.stderr, "spike fmt: -w needs files to write to")
+			return exitUsage
+		}
+		names = []string{stdinName}
+	}
+
+	status := exitOK
+	for _, name := range names {
+		source, err := readSource(env, name)
+		if err != nil {
+			reportError(env.stderr, err)
+			status = exitFailure
+			continue
+		}
+
+		formatted, err := format.Source(name, source)
+		if err != nil {
+			reportError(env.stderr, err)
+			status = exitInvalid
+			continue
+		}
+
+		changed := !bytes.Equal(source, formatted)
+		if *list && changed {
+			fmt.Fprintln(env.stdout, name)
+		}
+
+		switch {
+		case *write && changed:
+			info, err := os.Stat(name)
+			if err == nil {
+				err = ioutil.WriteFile(name, formatted, info.Mode())
+			}
+			if err != nil {
+				reportError(env.stderr, err)
+				status = exitFailure
+			}
+		case !*write && !*list:
+			_, _ = env.stdout.Write(formatted)
+		}
+	}
+
+	return status
+}
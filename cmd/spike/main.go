@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"spike-interpreter-go/spike/parser"
+	"spike-interpreter-go/spike/vm"
+	"text/tabwriter"
+)
+
+// Exit codes of the spike command.
+const (
+	exitOK = 0
+	// exitFailure is returned for runtime errors and failing tests.
+	exitFailure = 1
+	exitUsage   = 2
+	// exitInvalid is returned for programs that do not parse or compile.
+	exitInvalid = 3
+)
+
+// stdinName is the file name standing for the standard input.
+const stdinName = "-"
+
+type environment struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	usage       string
+	description string
+	run         func(env environment, args []string) int
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"run": {
+			usage:       "run [--engine=vm|eval] [-O level] [file|-] [args...]",
+			description: "run a program, passing args to it",
+			run:         runCommand,
+		},
+		"compile": {
+			usage:       "compile [-O level] [-o output] file",
+			description: "compile a program to a bytecode file",
+			run:         compileCommand,
+		},
+		"disasm": {
+			usage:       "disasm [-O level] [file|-]",
+			description: "print the bytecode of a program",
+			run:         disasmCommand,
+		},
+		"fmt": {
+			usage:       "fmt [-l] [-w] [files...]",
+			description: "format programs",
+			run:         fmtCommand,
+		},
+		"check": {
+			usage:       "check [files...]",
+			description: "report parse and compile errors without running",
+			run:         checkCommand,
+		},
+		"test": {
+			usage:       "test [--engine=vm|eval|all] [files or directories...]",
+			description: "run programs annotated with their expected results",
+			run:         testCommand,
+		},
+		"repl": {
+			usage:       "repl",
+			description: "start an interactive session",
+			run:         replCommand,
+		},
+	}
+}
+
+func main() {
+	os.Exit(run(environment{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}, os.Args[1:]))
+}
+
+func run(env environment, args []string) int {
+	if len(args) == 0 {
+		usage(env.stderr)
+		return exitUsage
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(env.stdout)
+		return exitOK
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(env.stderr, "spike: unknown command %q\n", args[0])
+		usage(env.stderr)
+		return exitUsage
+	}
+
+	return command.run(env, args[1:])
+}
+
+func usage(out io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(out, "Usage: spike <command> [arguments]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	table := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(table, "  %s\t%s\n", commands[name].usage, commands[name].description)
+	}
+	_ = table.Flush()
+}
+
+// readSource reads the named file, or the standard input for "-".
+func readSource(env environment, name string) ([]byte, error) {
+	if name == stdinName {
+		return ioutil.ReadAll(env.stdin)
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ioutil.ReadAll(file)
+}
+
+// reportError prints err the most helpful way: parse errors with their
+// hints and runtime errors of the VM with their stack trace.
+func reportError(out io.Writer, err error) {
+	switch err := err.(type) {
+	case parser.Diagnostics:
+		for _, diagnostic := range err {
+			reportError(out, diagnostic)
+		}
+	case *parser.Diagnostic:
+		fmt.Fprintf(out, "%s: %s: %s\n", err.Span.Start, err.Severity, err.Message)
+		if err.Hint != "" {
+			fmt.Fprintf(out, "\thint: %s\n", err.Hint)
+		}
+	case *vm.RuntimeError:
+		fmt.Fprintln(out, err.StackTrace())
+	default:
+		fmt.Fprintln(out, err)
+	}
+}
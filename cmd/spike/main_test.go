@@ -0,0 +1,245 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_run(t *testing.T) {
+	directory, err := ioutil.TempDir("", "spike")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	script := filepath.Join(directory, "greet.spk")
+	err = ioutil.WriteFile(script, []byte(`let greet = fn(name) { "Hello ${name}" }; greet(args[0])`), 0644)
+	assert.NoError(t, err)
+
+	cases := filepath.Join(directory, "cases.spike")
+	err = ioutil.WriteFile(cases, []byte("1 + 1\n// expect: 2\n\n1 / 0\n// error: division by zero\n"), 0644)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedStdout string
+		expectedStderr string
+		expectedStatus int
+	}{
+		{
+			name:           "run a file with arguments",
+			args:           []string{"run", script, "world", "--ignored"},
+			expectedStdout: "\"Hello world\"\n",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "run with the evaluator",
+			args:           []string{"run", "--engine=eval", script, "eval"},
+			expectedStdout: "\"Hello eval\"\n",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "run the standard input",
+			args:           []string{"run"},
+			stdin:          "[len(args), 2 * 3]",
+			expectedStdout: "[0, 6]\n",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "null results are not printed",
+			args:           []string{"run", "-"},
+			stdin:          "if (false) { 1 }",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "a trailing let is not printed",
+			args:           []string{"run"},
+			stdin:          "let x = 5;",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "a trailing let is not printed by the evaluator",
+			args:           []string{"run", "--engine=eval"},
+			stdin:          "let x = 5;",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "parse error",
+			args:           []string{"run"},
+			stdin:          "let x = ",
+			expectedStderr: "<stdin>:1:9: error: unexpected end of input\n",
+			expectedStatus: exitInvalid,
+		},
+		{
+			name:           "compile error",
+			args:           []string{"run"},
+			stdin:          "missing",
+			expectedStderr: "<stdin>:1:1: undefined identifier: missing\n",
+			expectedStatus: exitInvalid,
+		},
+		{
+			name:           "runtime error with stack trace",
+			args:           []string{"run"},
+			stdin:          "let f = fn() { 1 / 0 }\nf()",
+			expectedStderr: "<stdin>:1:16: division by zero\n\tat f (<stdin>:1:16)\n\tat <main> (<stdin>:2:1)\n",
+			expectedStatus: exitFailure,
+		},
+		{
+			name:           "runtime error of the evaluator",
+			args:           []string{"run", "--engine", "eval"},
+			stdin:          "1 / 0",
+			expectedStderr: "division by zero\n",
+			expectedStatus: exitFailure,
+		},
+		{
+			name:           "unknown engine",
+			args:           []string{"run", "--engine=jit"},
+			expectedStderr: "spike run: unknown engine \"jit\"\n",
+			expectedStatus: exitUsage,
+		},
+		{
+			name:           "check",
+			args:           []string{"check", script},
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "check reports hints",
+			args:           []string{"check"},
+			stdin:          "x = 1",
+			expectedStderr: "<stdin>:1:1: error: variables are immutable, x can not be reassigned\n\thint: bind a new name with let, or shadow x with let in a nested function\n",
+			expectedStatus: exitInvalid,
+		},
+		{
+			name:           "fmt",
+			args:           []string{"fmt"},
+			stdin:          "let  x=[1,2]",
+			expectedStdout: "let x = [1, 2]\n",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "fmt lists unformatted files",
+			args:           []string{"fmt", "-l", script},
+			expectedStdout: script + "\n",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "test",
+			args:           []string{"test", directory},
+			expectedStdout: "ok: 2 cases\n",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "failing test",
+			args:           []string{"test", "--engine=vm", "-"},
+			stdin:          "1 + 1\n// expect: 3\n",
+			expectedStdout: "-:1:\n    1 + 1\n  expected: 3\n  vm:       2\n\nFAIL: 1 of 1 cases\n",
+			expectedStatus: exitFailure,
+		},
+		{
+			name:           "disasm",
+			args:           []string{"disasm"},
+			stdin:          "1 + 2",
+			expectedStdout: "main:\n    ; <stdin>:1: 1 + 2\n    0000 OpConstant 0           ; 1\n    0003 OpConstant 1           ; 2\n    0006 OpAdd\n    0007 OpPop\n\nconstants:\n    0: 1\n    1: 2\n",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "repl",
+			args:           []string{"repl"},
+			stdin:          "1 + 2\n",
+			expectedStdout: ">> 3\n>> ",
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "unknown command",
+			args:           []string{"launch"},
+			expectedStderr: "spike: unknown command \"launch\"\n",
+			expectedStatus: exitUsage,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			stderr := &strings.Builder{}
+			env := environment{stdin: strings.NewReader(testCase.stdin), stdout: stdout, stderr: stderr}
+
+			status := run(env, testCase.args)
+
+			assert.Equal(t, testCase.expectedStatus, status)
+			assert.Equal(t, testCase.expectedStdout, stdout.String())
+			if testCase.expectedStatus == exitUsage {
+				assert.True(t, strings.HasPrefix(stderr.String(), testCase.expectedStderr), stderr.String())
+			} else {
+				assert.Equal(t, testCase.expectedStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func Test_run_fmtWrite(t *testing.T) {
+	file, err := ioutil.TempFile("", "spike*.spk")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("if(true){1}")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	status := run(environment{stdin: strings.NewReader(""), stdout: &strings.Builder{}, stderr: &strings.Builder{}}, []string{"fmt", "-w", file.Name()})
+
+	assert.Equal(t, exitOK, status)
+	formatted, err := ioutil.ReadFile(file.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, "if (true) { 1 }\n", string(formatted))
+}
+
+func Test_run_compile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "spike")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	script := filepath.Join(directory, "greet.spk")
+	err = ioutil.WriteFile(script, []byte("let greet = fn(name) { \"Hello ${name}\" }\ngreet(args[0])"), 0644)
+	assert.NoError(t, err)
+
+	run := func(args ...string) (int, string, string) {
+		stdout := &strings.Builder{}
+		stderr := &strings.Builder{}
+		status := run(environment{stdin: strings.NewReader(""), stdout: stdout, stderr: stderr}, args)
+		return status, stdout.String(), stderr.String()
+	}
+
+	status, _, stderr := run("compile", script)
+	assert.Equal(t, exitOK, status, stderr)
+
+	bytecode := filepath.Join(directory, "greet.spkc")
+	status, stdout, stderr := run("run", bytecode, "bytecode")
+	assert.Equal(t, exitOK, status, stderr)
+	assert.Equal(t, "\"Hello bytecode\"\n", stdout)
+
+	status, stdout, _ = run("disasm", bytecode)
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, "function greet/1 (constant 1):\n    ; "+script+":1\n")
+
+	status, _, stderr = run("run", "--engine=eval", bytecode)
+	assert.Equal(t, exitUsage, status)
+	assert.Equal(t, "spike run: bytecode files only run with the vm engine\n", stderr)
+
+	output := filepath.Join(directory, "failing.spkc")
+	status, _, _ = run("compile", "-o", output, script)
+	assert.Equal(t, exitOK, status)
+
+	data, err := ioutil.ReadFile(output)
+	assert.NoError(t, err)
+	data[4]++
+	assert.NoError(t, ioutil.WriteFile(output, data, 0644))
+
+	status, _, stderr = run("run", output)
+	assert.Equal(t, exitInvalid, status)
+	assert.Equal(t, "bytecode version 257 is not supported, expected version 1: recompile the program\n", stderr)
+}
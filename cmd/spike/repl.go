@@ -0,0 +1,16 @@
+package main
+
+import (
+	"spike-interpreter-go/ispike/repl"
+)
+
+func replCommand(env environment, args []string) int {
+	if len(args) > 0 {
+		usage(env.stderr)
+		return exitUsage
+	}
+
+	repl.Start(env.stdin, env.stdout)
+
+	return exitOK
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/eval"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"spike-interpreter-go/spike/parser/ast"
+	"spike-interpreter-go/spike/vm"
+)
+
+// argumentsName is the global holding the arguments passed to a script.
+const argumentsName = "args"
+
+// argumentsIndex is the index of the arguments global. It is the first global
+// newCompiler defines, so it is the same in bytecode files.
+const argumentsIndex = 0
+
+// engine prepares a program to run with the given script arguments, at the
+// given optimization level when it compiles programs. Errors of prepare mean
+// the program is invalid, errors of the returned function that it failed
+// while running.
+type engine func(
+	program *ast.Program,
+	arguments object.Object,
+	level compiler.OptimizationLevel,
+) (func() (object.Object, error), error)
+
+var engines = map[string]engine{
+	"vm":   prepareVM,
+	"eval": prepareEval,
+}
+
+// newCompiler returns a compiler for scripts, which have the arguments
+// global defined.
+func newCompiler() *compiler.Compiler {
+	symbolTable := compiler.NewGlobalSymbolTable()
+	symbolTable.Define(argumentsName)
+
+	return compiler.NewWithState(symbolTable, []object.Object{})
+}
+
+func prepareVM(
+	program *ast.Program,
+	arguments object.Object,
+	level compiler.OptimizationLevel,
+) (func() (object.Object, error), error) {
+	comp := newCompiler()
+	if err := comp.Compile(program); err != nil {
+		return nil, err
+	}
+
+	return prepareBytecode(compiler.Optimize(comp.Bytecode(), level), arguments), nil
+}
+
+// prepareBytecode returns the value of the last statement when it is an
+// expression, like eval does.
+func prepareBytecode(bytecode *compiler.Bytecode, arguments object.Object) func() (object.Object, error) {
+	globals := make([]object.Object, vm.GlobalsSize)
+	globals[argumentsIndex] = arguments
+
+	return func() (object.Object, error) {
+		machine := vm.NewWithGlobalStore(bytecode, globals)
+		if err := machine.Run(); err != nil {
+			return nil, err
+		}
+
+		if !endsWithExpression(bytecode.Instructions) {
+			return nil, nil
+		}
+		return machine.LastPoppedStackElement(), nil
+	}
+}
+
+// endsWithExpression tells whether instructions end with the OpPop of an
+// expression statement. Other statements leave the value popped last
+// unrelated to them, e.g. let stores the value of its expression.
+func endsWithExpression(instructions code.Instructions) bool {
+	last := -1
+	for offset := 0; offset < len(instructions); {
+		_, _, length, _ := instructions.ReadInstruction(offset)
+		last = offset
+		offset += length
+	}
+
+	return last >= 0 && code.Opcode(instructions[last]) == code.OpPop
+}
+
+func prepareEval(
+	program *ast.Program,
+	arguments object.Object,
+	_ compiler.OptimizationLevel,
+) (func() (object.Object, error), error) {
+	environment := object.NewEnvironment()
+	environment.Set(argumentsName, arguments)
+
+	return func() (object.Object, error) {
+		return eval.Eval(program, environment)
+	}, nil
+}
+
+// sourceName is the file name positions in source read from name refer to.
+func sourceName(name string) string {
+	if name == stdinName {
+		return "<stdin>"
+	}
+
+	return name
+}
+
+func parseSource(name string, source []byte) (*ast.Program, error) {
+	return parser.New(lexer.NewWithFileName(bytes.NewReader(source), sourceName(name))).ParseProgram()
+}
+
+func runCommand(env environment, args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	engineName := flags.String("engine", "vm", "engine running the program, vm or eval")
+	level := optimizationFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	run, ok := engines[*engineName]
+	if !ok {
+		fmt.Fprintf(env.stderr, "spike run: unknown engine %q\n", *engineName)
+		return exitUsage
+	}
+
+	name := stdinName
+	if flags.NArg() > 0 {
+		name = flags.Arg(0)
+	}
+
+	source, err := readSource(env, name)
+	if err != nil {
+		reportError(env.stderr, err)
+		return exitFailure
+	}
+
+	arguments := &object.Array{Elements: []object.Object{}}
+	if flags.NArg() > 1 {
+		for _, argument := range flags.Args()[1:] {
+			arguments.Elements = append(arguments.Elements, &object.String{Value: argument})
+		}
+	}
+
+	var execute func() (object.Object, error)
+	if compiler.IsBytecode(source) {
+		if *engineName != "vm" {
+			fmt.Fprintln(env.stderr, "spike run: bytecode files only run with the vm engine")
+			return exitUsage
+		}
+
+		var bytecode *compiler.Bytecode
+		bytecode, err = compileSource(name, source, *level)
+		if err == nil {
+			execute = prepareBytecode(bytecode, arguments)
+		}
+	} else {
+		var program *ast.Program
+		program, err = parseSource(name, source)
+		if err == nil {
+			execute, err = run(program, arguments, *level)
+		}
+	}
+	if err != nil {
+		reportError(env.stderr, err)
+		return exitInvalid
+	}
+
+	result, err := execute()
+	if err != nil {
+		reportError(env.stderr, err)
+		return exitFailure
+	}
+
+	if result != nil && result != &object.NullObject {
+		fmt.Fprintln(env.stdout, result.Inspect())
+	}
+
+	return exitOK
+}
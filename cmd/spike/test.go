@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"spike-interpreter-go/spike/conformance"
+)
+
+func testCommand(env environment, args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	engineName := flags.String("engine", "all", "engine running the programs, vm, eval or all")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	var testEngines []conformance.Engine
+	switch *engineName {
+	case "vm":
+		testEngines = []conformance.Engine{conformance.VM}
+	case "eval":
+		testEngines = []conformance.Engine{conformance.Eval}
+	case "all":
+		testEngines = []conformance.Engine{conformance.Eval, conformance.VM}
+	default:
+		fmt.Fprintf(env.stderr, "spike test: unknown engine %q\n", *engineName)
+		return exitUsage
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var cases []conformance.Case
+	for _, path := range paths {
+		pathCases, err := loadCases(env, path)
+		if err != nil {
+			reportError(env.stderr, err)
+			return exitInvalid
+		}
+		cases = append(cases, pathCases...)
+	}
+
+	failures := conformance.RunSuite(cases, testEngines...)
+	for _, failure := range failures {
+		fmt.Fprintln(env.stdout, failure.String())
+	}
+
+	if len(failures) > 0 {
+		fmt.Fprintf(env.stdout, "FAIL: %d of %d cases\n", len(failures), len(cases))
+		return exitFailure
+	}
+
+	fmt.Fprintf(env.stdout, "ok: %d cases\n", len(cases))
+	return exitOK
+}
+
+// loadCases reads the cases of a file, or of the .spike files in a directory.
+func loadCases(env environment, path string) ([]conformance.Case, error) {
+	if path != stdinName {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return conformance.LoadSuite(path)
+		}
+	}
+
+	source, err := readSource(env, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return conformance.ReadSuite(path, bytes.NewReader(source))
+}
@@ -4,12 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"spike-interpreter-go/spike/compiler"
-	"spike-interpreter-go/spike/lexer"
-	"spike-interpreter-go/spike/object"
-	"spike-interpreter-go/spike/parser"
-	"spike-interpreter-go/spike/vm"
-	"strings"
+	"spike-interpreter-go/spike"
 )
 
 const prompt = ">> "
@@ -17,9 +12,7 @@ const prompt = ">> "
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 
-	constants := []object.Object{}
-	globals := make([]object.Object, vm.GlobalsSize)
-	symbolTable := compiler.NewSymbolTable()
+	interpreter := spike.New()
 
 	for {
 		_, err := fmt.Fprint(out, prompt)
@@ -33,30 +26,13 @@ func Start(in io.Reader, out io.Writer) {
 			return
 		}
 
-		l := lexer.New(strings.NewReader(scanner.Text()))
-		p := parser.New(l)
-		program, err := p.ParseProgram()
-
-		if err != nil {
-			fmt.Print(err)
-			return
-		}
-
-		c := compiler.NewWithState(symbolTable, constants)
-		err = c.Compile(program)
-		if err != nil {
-			fmt.Print(err)
-			return
-		}
-
-		v := vm.NewWithGlobalStore(c.Bytecode(), globals)
-		err = v.Run()
+		result, err := interpreter.Eval(scanner.Text())
 		if err != nil {
 			fmt.Print(err)
 			return
 		}
 
-		_, err = fmt.Fprint(out, v.LastPoppedStackElement().Inspect())
+		_, err = fmt.Fprint(out, result.Inspect())
 		if err != nil {
 			fmt.Print(err)
 			return
@@ -16,3 +16,23 @@ func TestStart(t *testing.T) {
 
 	assert.Equal(t, expectedOutput, output.String())
 }
+
+func TestStart_builtins(t *testing.T) {
+	input := strings.NewReader("len(\"spike\")\n")
+	expectedOutput := ">> 5\n>> "
+	output := &strings.Builder{}
+
+	Start(input, output)
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestStart_functions(t *testing.T) {
+	input := strings.NewReader("let greet = fn() { \"Hello\" + \"!\" }\n10\ngreet()\n")
+	expectedOutput := ">> fn/0\n>> 10\n>> \"Hello!\"\n>> "
+	output := &strings.Builder{}
+
+	Start(input, output)
+
+	assert.Equal(t, expectedOutput, output.String())
+}
@@ -41,6 +41,21 @@ const (
 	OpGetBuiltin
 	OpClosure
 	OpGetFreeVar
+	OpTuple
+	OpMatchEqual
+	OpMatchTuple
+	OpMatchArray
+	OpMatchHash
+	OpArrayRest
+	OpNoMatch
+	OpReceiveStart
+	OpReceive
+	OpReceiveNext
+	OpReceiveAccept
+	OpYield
+	OpGreaterOrEqual
+	OpLessThan
+	OpLessOrEqual
 )
 
 type Definition struct {
@@ -165,6 +180,87 @@ var definitions = map[Opcode]*Definition{
 		Name:          "OpGetFreeVar",
 		OperandWidths: []int{1 * Byte},
 	},
+	OpTuple: {
+		Name:          "OpTuple",
+		OperandWidths: []int{2 * Byte},
+	},
+	OpMatchEqual: {
+		Name:          "OpMatchEqual",
+		OperandWidths: []int{},
+	},
+	OpMatchTuple: {
+		Name:          "OpMatchTuple",
+		OperandWidths: []int{2 * Byte},
+	},
+	OpMatchArray: {
+		Name:          "OpMatchArray",
+		OperandWidths: []int{2 * Byte, 1 * Byte},
+	},
+	OpMatchHash: {
+		Name:          "OpMatchHash",
+		OperandWidths: []int{2 * Byte},
+	},
+	OpArrayRest: {
+		Name:          "OpArrayRest",
+		OperandWidths: []int{2 * Byte},
+	},
+	OpNoMatch: {
+		Name:          "OpNoMatch",
+		OperandWidths: []int{},
+	},
+	OpReceiveStart: {
+		Name:          "OpReceiveStart",
+		OperandWidths: []int{},
+	},
+	OpReceive: {
+		Name:          "OpReceive",
+		OperandWidths: []int{2 * Byte},
+	},
+	OpReceiveNext: {
+		Name:          "OpReceiveNext",
+		OperandWidths: []int{},
+	},
+	OpReceiveAccept: {
+		Name:          "OpReceiveAccept",
+		OperandWidths: []int{},
+	},
+	OpYield: {
+		Name:          "OpYield",
+		OperandWidths: []int{},
+	},
+	OpGreaterOrEqual: {
+		Name:          "OpGreaterOrEqual",
+		OperandWidths: []int{},
+	},
+	OpLessThan: {
+		Name:          "OpLessThan",
+		OperandWidths: []int{},
+	},
+	OpLessOrEqual: {
+		Name:          "OpLessOrEqual",
+		OperandWidths: []int{},
+	},
+}
+
+// Width is the number of bytes of the operands of the instruction.
+func (definition *Definition) Width() int {
+	width := 0
+	for _, operandWidth := range definition.OperandWidths {
+		width += operandWidth
+	}
+
+	return width
+}
+
+// IsJump tells whether the first operand of opcode is the offset of the
+// instruction it may jump to.
+func IsJump(opcode Opcode) bool {
+	switch opcode {
+	case OpJump, OpJumpNotTrue, OpReceive:
+		return true
+	}
+
+	return false
 }
 
 type Instructions []byte
@@ -174,27 +270,45 @@ func (instructions Instructions) String() string {
 
 	i := 0
 	for i < len(instructions) {
-		definition, err := Lookup(Opcode(instructions[i]))
+		definition, operands, length, err := instructions.ReadInstruction(i)
 		if err != nil {
-			_, err = fmt.Fprintf(&result, "ERROR: %s\n", err)
-			if err != nil {
-				panic(err)
-			}
-			continue
+			_, err = fmt.Fprintf(&result, "%04d ERROR: %s\n", i, err)
+		} else {
+			_, err = fmt.Fprintf(&result, "%04d %s\n", i, formatInstruction(definition, operands))
 		}
-
-		operands, operandBytes := ReadOperands(definition, instructions[i+1:])
-		_, err = fmt.Fprintf(&result, "%04d %s\n", i, formatInstruction(definition, operands))
 		if err != nil {
 			panic(err)
 		}
 
-		i += 1 + operandBytes
+		i += length
 	}
 
 	return result.String()
 }
 
+// ReadInstruction decodes the instruction at offset and returns its length
+// together with its definition and operands. Undefined opcodes are one byte
+// long and instructions cut short by the end of instructions extend to it.
+func (instructions Instructions) ReadInstruction(offset int) (*Definition, []int, int, error) {
+	definition, err := Lookup(Opcode(instructions[offset]))
+	if err != nil {
+		return nil, nil, 1, err
+	}
+
+	if length := 1 + definition.Width(); offset+length > len(instructions) {
+		return definition, nil, len(instructions) - offset, errors.Errorf(
+			"%s truncated, expected %d operand bytes, got %d",
+			definition.Name,
+			length-1,
+			len(instructions)-offset-1,
+		)
+	}
+
+	operands, operandBytes := ReadOperands(definition, instructions[offset+1:])
+
+	return definition, operands, 1 + operandBytes, nil
+}
+
 func formatInstruction(definition *Definition, operands []int) string {
 	operandCount := len(definition.OperandWidths)
 
@@ -232,12 +346,7 @@ func Make(opcode Opcode, operands ...int) ([]byte, error) {
 		return nil, err
 	}
 
-	instructionLength := 1
-	for _, operandWidth := range definition.OperandWidths {
-		instructionLength += operandWidth
-	}
-
-	instruction := make([]byte, instructionLength)
+	instruction := make([]byte, 1+definition.Width())
 	instruction[0] = byte(opcode)
 
 	offset := 1
@@ -98,6 +98,19 @@ func Test_Instructions_String(t *testing.T) {
 	assert.Equal(t, expectedOutput, instructions.String())
 }
 
+func Test_Instructions_String_malformed(t *testing.T) {
+	instructions := append(NewBuilder().Make(OpTrue).Build(), 255)
+	instructions = append(instructions, NewBuilder().Make(OpPop).Make(OpConstant, 1).Build()[:3]...)
+
+	expectedOutput := `0000 OpTrue
+0001 ERROR: opcode 255 undefined
+0002 OpPop
+0003 ERROR: OpConstant truncated, expected 2 operand bytes, got 1
+`
+
+	assert.Equal(t, expectedOutput, instructions.String())
+}
+
 func Test_ReadOperands(t *testing.T) {
 	opcode := OpConstant
 	expectedOperands := []int{65535}
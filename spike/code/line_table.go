@@ -0,0 +1,41 @@
+package code
+
+import "spike-interpreter-go/spike/lexer"
+
+type LineTableEntry struct {
+	Offset int
+	Span   lexer.Span
+}
+
+// LineTable maps instruction offsets to the source spans they were compiled
+// from. Entries are sorted by offset and an entry covers every instruction up
+// to the next one.
+type LineTable []LineTableEntry
+
+func (table LineTable) Add(offset int, span lexer.Span) LineTable {
+	if len(table) > 0 && table[len(table)-1].Span == span {
+		return table
+	}
+
+	return append(table, LineTableEntry{Offset: offset, Span: span})
+}
+
+func (table LineTable) Truncate(offset int) LineTable {
+	for i, entry := range table {
+		if entry.Offset >= offset {
+			return table[:i]
+		}
+	}
+
+	return table
+}
+
+func (table LineTable) Lookup(offset int) (lexer.Span, bool) {
+	for i := len(table) - 1; i >= 0; i-- {
+		if table[i].Offset <= offset {
+			return table[i].Span, true
+		}
+	}
+
+	return lexer.Span{}, false
+}
@@ -0,0 +1,41 @@
+package code
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LineTable(t *testing.T) {
+	first := lexer.Span{Start: lexer.Position{Line: 1, Column: 1}}
+	second := lexer.Span{Start: lexer.Position{Line: 2, Column: 3}}
+
+	table := LineTable{}.
+		Add(0, first).
+		Add(3, first).
+		Add(4, second).
+		Add(7, first)
+
+	assert.Len(t, table, 3)
+
+	span, ok := table.Lookup(2)
+	assert.True(t, ok)
+	assert.Equal(t, first, span)
+
+	span, ok = table.Lookup(5)
+	assert.True(t, ok)
+	assert.Equal(t, second, span)
+
+	span, ok = table.Lookup(100)
+	assert.True(t, ok)
+	assert.Equal(t, first, span)
+
+	table = table.Truncate(4)
+	span, ok = table.Lookup(5)
+	assert.True(t, ok)
+	assert.Equal(t, first, span)
+
+	_, ok = LineTable{}.Lookup(0)
+	assert.False(t, ok)
+}
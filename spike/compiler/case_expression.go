@@ -0,0 +1,221 @@
+package compiler
+
+import (
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser/ast"
+)
+
+// caseSubjectName names the hidden variable holding the value matched by a
+// case expression. It is not a valid identifier, so it cannot clash with user
+// variables; nested case expressions keep their own Symbol.
+const caseSubjectName = "$case"
+
+// compileCaseExpression compiles each clause to a sequence of tests, each
+// followed by OpJumpNotTrue to the next clause. Bindings are stored as soon as
+// they are reached, in a block of the clause, so they shadow enclosing names
+// and are neither visible to later clauses nor after the case expression.
+// When no clause matches, OpNoMatch raises a runtime error.
+func (compiler *Compiler) compileCaseExpression(node *ast.CaseExpression) error {
+	err := compiler.Compile(node.Subject)
+	if err != nil {
+		return err
+	}
+
+	subject := compiler.symbolTable.Define(caseSubjectName)
+	compiler.storeSymbol(subject)
+	loadSubject := func() error {
+		compiler.loadSymbol(subject)
+		return nil
+	}
+
+	endJumps := make([]int, 0, len(node.Clauses))
+	for _, clause := range node.Clauses {
+		failJumps := make([]int, 0)
+		compiler.enterBlock()
+
+		err := compiler.compilePattern(clause.Pattern, loadSubject, &failJumps)
+		if err != nil {
+			return err
+		}
+
+		if clause.Guard != nil {
+			err := compiler.Compile(clause.Guard)
+			if err != nil {
+				return err
+			}
+			failJumps = append(failJumps, compiler.emit(code.OpJumpNotTrue, -1))
+		}
+
+		err = compiler.compileClauseBody(clause.Body)
+		if err != nil {
+			return err
+		}
+		endJumps = append(endJumps, compiler.emit(code.OpJump, -1))
+		compiler.leaveBlock()
+
+		nextClauseIndex := len(compiler.scopes[compiler.scopeIndex].instructions)
+		for _, failJump := range failJumps {
+			compiler.changeOperand(failJump, nextClauseIndex)
+		}
+	}
+
+	compiler.loadSymbol(subject)
+	compiler.emit(code.OpNoMatch)
+
+	endIndex := len(compiler.scopes[compiler.scopeIndex].instructions)
+	for _, endJump := range endJumps {
+		compiler.changeOperand(endJump, endIndex)
+	}
+
+	return nil
+}
+
+// compileClauseBody compiles body leaving its value on the stack, null when
+// it doesn't end with an expression.
+func (compiler *Compiler) compileClauseBody(body *ast.BlockStatement) error {
+	bodyStart := len(compiler.scopes[compiler.scopeIndex].instructions)
+	err := compiler.Compile(body)
+	if err != nil {
+		return err
+	}
+
+	if len(compiler.scopes[compiler.scopeIndex].instructions) > bodyStart && compiler.lastInstructionIs(code.OpPop) {
+		compiler.removeLastInstruction()
+	} else {
+		compiler.emit(code.OpNull)
+	}
+
+	return nil
+}
+
+// compilePattern emits tests of the value pushed by load against pattern.
+// Every failed test jumps to an address collected in failJumps.
+func (compiler *Compiler) compilePattern(pattern ast.Pattern, load func() error, failJumps *[]int) error {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return nil
+
+	case *ast.BindingPattern:
+		err := load()
+		if err != nil {
+			return err
+		}
+		compiler.storeSymbol(compiler.symbolTable.Define(pattern.Name.Value))
+
+	case *ast.LiteralPattern:
+		err := load()
+		if err != nil {
+			return err
+		}
+
+		err = compiler.Compile(pattern.Value)
+		if err != nil {
+			return err
+		}
+		compiler.emit(code.OpMatchEqual)
+		*failJumps = append(*failJumps, compiler.emit(code.OpJumpNotTrue, -1))
+
+	case *ast.TuplePattern:
+		err := load()
+		if err != nil {
+			return err
+		}
+		compiler.emit(code.OpMatchTuple, len(pattern.Elements))
+		*failJumps = append(*failJumps, compiler.emit(code.OpJumpNotTrue, -1))
+
+		return compiler.compileElementPatterns(pattern.Elements, load, failJumps)
+
+	case *ast.ArrayPattern:
+		err := load()
+		if err != nil {
+			return err
+		}
+
+		hasRest := 0
+		if pattern.Rest != nil {
+			hasRest = 1
+		}
+		compiler.emit(code.OpMatchArray, len(pattern.Elements), hasRest)
+		*failJumps = append(*failJumps, compiler.emit(code.OpJumpNotTrue, -1))
+
+		err = compiler.compileElementPatterns(pattern.Elements, load, failJumps)
+		if err != nil || pattern.Rest == nil {
+			return err
+		}
+
+		loadRest := func() error {
+			err := load()
+			if err != nil {
+				return err
+			}
+			compiler.emit(code.OpArrayRest, len(pattern.Elements))
+			return nil
+		}
+		return compiler.compilePattern(pattern.Rest, loadRest, failJumps)
+
+	case *ast.HashPattern:
+		err := load()
+		if err != nil {
+			return err
+		}
+
+		for _, key := range pattern.Keys {
+			err := compiler.Compile(key)
+			if err != nil {
+				return err
+			}
+		}
+		compiler.emit(code.OpMatchHash, len(pattern.Keys))
+		*failJumps = append(*failJumps, compiler.emit(code.OpJumpNotTrue, -1))
+
+		for i, key := range pattern.Keys {
+			key := key
+			loadValue := func() error {
+				err := load()
+				if err != nil {
+					return err
+				}
+				err = compiler.Compile(key)
+				if err != nil {
+					return err
+				}
+				compiler.emit(code.OpIndex)
+				return nil
+			}
+
+			err := compiler.compilePattern(pattern.Values[i], loadValue, failJumps)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (compiler *Compiler) compileElementPatterns(elements []ast.Pattern, load func() error, failJumps *[]int) error {
+	for i, element := range elements {
+		if _, ok := element.(*ast.WildcardPattern); ok {
+			continue
+		}
+
+		index := compiler.addConstant(&object.Integer{Value: int64(i)})
+		loadElement := func() error {
+			err := load()
+			if err != nil {
+				return err
+			}
+			compiler.emit(code.OpConstant, index)
+			compiler.emit(code.OpIndex)
+			return nil
+		}
+
+		err := compiler.compilePattern(element, loadElement, failJumps)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
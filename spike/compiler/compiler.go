@@ -2,8 +2,10 @@ package compiler
 
 import (
 	"fmt"
+	"math/big"
 	"sort"
 	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/lexer"
 	"spike-interpreter-go/spike/object"
 	"spike-interpreter-go/spike/parser/ast"
 
@@ -17,16 +19,20 @@ type EmittedInstruction struct {
 
 type CompilationScope struct {
 	instructions        code.Instructions
+	lineTable           code.LineTable
 	lastInstruction     EmittedInstruction
 	previousInstruction EmittedInstruction
 }
 
 type Compiler struct {
 	constants   []object.Object
+	atoms       map[*object.Atom]int
 	symbolTable *SymbolTable
 
 	scopes     []CompilationScope
 	scopeIndex int
+
+	currentSpan lexer.Span
 }
 
 func New() *Compiler {
@@ -36,14 +42,10 @@ func New() *Compiler {
 		previousInstruction: EmittedInstruction{},
 	}
 
-	symbolTable := NewSymbolTable()
-	for i, builtin := range object.Builtins {
-		symbolTable.DefineBuiltin(i, builtin.Name)
-	}
-
 	return &Compiler{
 		constants:   []object.Object{},
-		symbolTable: symbolTable,
+		atoms:       map[*object.Atom]int{},
+		symbolTable: NewGlobalSymbolTable(),
 		scopes:      []CompilationScope{mainScope},
 		scopeIndex:  0,
 	}
@@ -54,10 +56,20 @@ func NewWithState(symbolTable *SymbolTable, constants []object.Object) *Compiler
 	compiler.symbolTable = symbolTable
 	compiler.constants = constants
 
+	for i, constant := range constants {
+		if atom, ok := constant.(*object.Atom); ok {
+			compiler.atoms[atom] = i
+		}
+	}
+
 	return compiler
 }
 
 func (compiler *Compiler) Compile(node ast.Node) error {
+	previousSpan := compiler.currentSpan
+	compiler.currentSpan = node.Span()
+	defer func() { compiler.currentSpan = previousSpan }()
+
 	switch node := node.(type) {
 	case *ast.Program:
 		for _, statement := range node.Statements {
@@ -83,20 +95,8 @@ func (compiler *Compiler) Compile(node ast.Node) error {
 		}
 
 	case *ast.InfixExpression:
-		if node.Operator == "<" {
-			err := compiler.Compile(node.Right)
-			if err != nil {
-				return err
-			}
-
-			err = compiler.Compile(node.Left)
-			if err != nil {
-				return err
-			}
-
-			compiler.emit(code.OpGreaterThan)
-
-			return nil
+		if node.Operator == "&&" || node.Operator == "||" {
+			return compiler.compileLogicalExpression(node)
 		}
 
 		err := compiler.Compile(node.Left)
@@ -124,6 +124,12 @@ func (compiler *Compiler) Compile(node ast.Node) error {
 			compiler.emit(code.OpNotEqual)
 		case ">":
 			compiler.emit(code.OpGreaterThan)
+		case ">=":
+			compiler.emit(code.OpGreaterOrEqual)
+		case "<":
+			compiler.emit(code.OpLessThan)
+		case "<=":
+			compiler.emit(code.OpLessOrEqual)
 		default:
 			return fmt.Errorf("unknown operator: %s", node.Operator)
 		}
@@ -147,6 +153,14 @@ func (compiler *Compiler) Compile(node ast.Node) error {
 		integer := &object.Integer{Value: node.Value}
 		compiler.emit(code.OpConstant, compiler.addConstant(integer))
 
+	case *ast.BigInteger:
+		integer := object.NewInteger(new(big.Int).Set(node.Value))
+		compiler.emit(code.OpConstant, compiler.addConstant(integer))
+
+	case *ast.Float:
+		float := &object.Float{Value: node.Value}
+		compiler.emit(code.OpConstant, compiler.addConstant(float))
+
 	case *ast.String:
 		str := &object.String{Value: node.Value}
 		compiler.emit(code.OpConstant, compiler.addConstant(str))
@@ -203,22 +217,40 @@ func (compiler *Compiler) Compile(node ast.Node) error {
 		}
 
 	case *ast.LetStatement:
-		symbol := compiler.symbolTable.Define(node.Name.Value)
+		if compiler.symbolTable.Defines(node.Name.Value) {
+			return &Error{
+				Span:    node.Name.Token.Span,
+				Message: fmt.Sprintf("%s is already defined in this scope", node.Name.Value),
+			}
+		}
+
+		// Functions can refer to themselves, other values are compiled before
+		// the name is bound, as eval only binds it once they are evaluated.
+		if _, ok := node.Value.(*ast.FunctionExpression); ok {
+			symbol := compiler.symbolTable.Define(node.Name.Value)
+			err := compiler.Compile(node.Value)
+			if err != nil {
+				return err
+			}
+
+			compiler.storeSymbol(symbol)
+			break
+		}
+
 		err := compiler.Compile(node.Value)
 		if err != nil {
 			return err
 		}
 
-		if symbol.SymbolScope == GlobalScope {
-			compiler.emit(code.OpSetGlobal, symbol.Index)
-		} else {
-			compiler.emit(code.OpSetLocal, symbol.Index)
-		}
+		compiler.storeSymbol(compiler.symbolTable.Define(node.Name.Value))
 
 	case *ast.Identifier:
 		symbol, ok := compiler.symbolTable.Resolve(node.Value)
 		if !ok {
-			return errors.Errorf("unable to resolve identifier: %s", node.Value)
+			return &Error{
+				Span:    node.Token.Span,
+				Message: fmt.Sprintf("undefined identifier: %s", node.Value),
+			}
 		}
 
 		compiler.loadSymbol(symbol)
@@ -233,6 +265,25 @@ func (compiler *Compiler) Compile(node ast.Node) error {
 
 		compiler.emit(code.OpArray, len(node.Elements))
 
+	case *ast.CaseExpression:
+		return compiler.compileCaseExpression(node)
+
+	case *ast.ReceiveExpression:
+		return compiler.compileReceiveExpression(node)
+
+	case *ast.Atom:
+		compiler.emit(code.OpConstant, compiler.addAtom(object.NewAtom(node.Name)))
+
+	case *ast.Tuple:
+		for _, element := range node.Elements {
+			err := compiler.Compile(element)
+			if err != nil {
+				return err
+			}
+		}
+
+		compiler.emit(code.OpTuple, len(node.Elements))
+
 	case *ast.Hash:
 		keys := make([]ast.Expression, 0)
 		for key := range node.Pairs {
@@ -294,6 +345,7 @@ func (compiler *Compiler) Compile(node ast.Node) error {
 
 		freeSymbols := compiler.symbolTable.FreeSymbols
 		localCount := compiler.symbolTable.numDefinitions
+		lineTable := compiler.scopes[compiler.scopeIndex].lineTable
 		instructions := compiler.leaveScope()
 
 		for _, symbol := range freeSymbols {
@@ -301,13 +353,28 @@ func (compiler *Compiler) Compile(node ast.Node) error {
 		}
 
 		compiledFunction := &object.CompiledFunction{
+			Name:            node.Name,
 			Instructions:    instructions,
 			LocalsCount:     localCount,
 			ParametersCount: len(node.Parameters),
+			LineTable:       lineTable,
+			Generator:       node.Generator,
 		}
 		index := compiler.addConstant(compiledFunction)
 		compiler.emit(code.OpClosure, index, len(freeSymbols))
 
+	case *ast.YieldExpression:
+		if node.Value == nil {
+			compiler.emit(code.OpNull)
+		} else {
+			err := compiler.Compile(node.Value)
+			if err != nil {
+				return err
+			}
+		}
+
+		compiler.emit(code.OpYield)
+
 	case *ast.ReturnStatement:
 		err := compiler.Compile(node.Result)
 		if err != nil {
@@ -348,16 +415,37 @@ func (compiler *Compiler) loadSymbol(symbol Symbol) {
 	}
 }
 
+func (compiler *Compiler) storeSymbol(symbol Symbol) {
+	if symbol.SymbolScope == GlobalScope {
+		compiler.emit(code.OpSetGlobal, symbol.Index)
+	} else {
+		compiler.emit(code.OpSetLocal, symbol.Index)
+	}
+}
+
 func (compiler *Compiler) addConstant(obj object.Object) int {
 	compiler.constants = append(compiler.constants, obj)
 	return len(compiler.constants) - 1
 }
 
+// addAtom adds atom to the constant pool once and reuses its index for every
+// later occurrence.
+func (compiler *Compiler) addAtom(atom *object.Atom) int {
+	if index, ok := compiler.atoms[atom]; ok {
+		return index
+	}
+
+	index := compiler.addConstant(atom)
+	compiler.atoms[atom] = index
+	return index
+}
+
 func (compiler *Compiler) emit(opcode code.Opcode, operands ...int) int {
 	instruction, _ := code.Make(opcode, operands...)
 
 	newInstructionIndex := len(compiler.scopes[compiler.scopeIndex].instructions)
 	compiler.scopes[compiler.scopeIndex].instructions = append(compiler.scopes[compiler.scopeIndex].instructions, instruction...)
+	compiler.scopes[compiler.scopeIndex].lineTable = compiler.scopes[compiler.scopeIndex].lineTable.Add(newInstructionIndex, compiler.currentSpan)
 
 	compiler.scopes[compiler.scopeIndex].previousInstruction = compiler.scopes[compiler.scopeIndex].lastInstruction
 	compiler.scopes[compiler.scopeIndex].lastInstruction = EmittedInstruction{
@@ -370,6 +458,7 @@ func (compiler *Compiler) emit(opcode code.Opcode, operands ...int) int {
 
 func (compiler *Compiler) removeLastInstruction() {
 	compiler.scopes[compiler.scopeIndex].instructions = compiler.scopes[compiler.scopeIndex].instructions[:compiler.scopes[compiler.scopeIndex].lastInstruction.Position]
+	compiler.scopes[compiler.scopeIndex].lineTable = compiler.scopes[compiler.scopeIndex].lineTable.Truncate(compiler.scopes[compiler.scopeIndex].lastInstruction.Position)
 	compiler.scopes[compiler.scopeIndex].lastInstruction = compiler.scopes[compiler.scopeIndex].previousInstruction
 }
 
@@ -384,6 +473,7 @@ func (compiler *Compiler) Bytecode() *Bytecode {
 	return &Bytecode{
 		Instructions: compiler.scopes[compiler.scopeIndex].instructions,
 		Constants:    compiler.constants,
+		LineTable:    compiler.scopes[compiler.scopeIndex].lineTable,
 	}
 }
 
@@ -432,7 +522,18 @@ func (compiler *Compiler) leaveScope() code.Instructions {
 	return instructions
 }
 
+// enterBlock starts a block of the current scope, whose definitions are
+// dropped by leaveBlock.
+func (compiler *Compiler) enterBlock() {
+	compiler.symbolTable = NewBlockSymbolTable(compiler.symbolTable)
+}
+
+func (compiler *Compiler) leaveBlock() {
+	compiler.symbolTable = compiler.symbolTable.Outer
+}
+
 type Bytecode struct {
 	Instructions code.Instructions
 	Constants    []object.Object
+	LineTable    code.LineTable
 }
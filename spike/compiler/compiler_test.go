@@ -101,13 +101,68 @@ func Test_Compiler(t *testing.T) {
 		{
 			code: "1 < 2",
 			expectedConstants: []object.Object{
+				&object.Integer{Value: 1},
 				&object.Integer{Value: 2},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpLessThan).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code: "1 <= 2",
+			expectedConstants: []object.Object{
 				&object.Integer{Value: 1},
+				&object.Integer{Value: 2},
 			},
 			expectedInstructions: code.NewBuilder().
 				Make(code.OpConstant, 0).
 				Make(code.OpConstant, 1).
-				Make(code.OpGreaterThan).
+				Make(code.OpLessOrEqual).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code: "1 >= 2",
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 1},
+				&object.Integer{Value: 2},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpGreaterOrEqual).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:              "true && false",
+			expectedConstants: []object.Object{},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpTrue).
+				Make(code.OpJumpNotTrue, 12).
+				Make(code.OpFalse).
+				Make(code.OpJumpNotTrue, 12).
+				Make(code.OpTrue).
+				Make(code.OpJump, 13).
+				Make(code.OpFalse).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:              "true || false",
+			expectedConstants: []object.Object{},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpTrue).
+				Make(code.OpJumpNotTrue, 7).
+				Make(code.OpJump, 11).
+				Make(code.OpFalse).
+				Make(code.OpJumpNotTrue, 15).
+				Make(code.OpTrue).
+				Make(code.OpJump, 16).
+				Make(code.OpFalse).
 				Make(code.OpPop).
 				Build(),
 		},
@@ -270,6 +325,109 @@ func Test_Compiler(t *testing.T) {
 				Make(code.OpPop).
 				Build(),
 		},
+		{
+			code:              `[:ok, :error, :ok]`,
+			expectedConstants: []object.Object{object.NewAtom("ok"), object.NewAtom("error")},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpConstant, 0).
+				Make(code.OpArray, 3).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code: `case 1 of 2 -> { 3 } end`,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 1},
+				&object.Integer{Value: 2},
+				&object.Integer{Value: 3},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpSetGlobal, 0).
+				Make(code.OpGetGlobal, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpMatchEqual).
+				Make(code.OpJumpNotTrue, 22).
+				Make(code.OpConstant, 2).
+				Make(code.OpJump, 26).
+				Make(code.OpGetGlobal, 0).
+				Make(code.OpNoMatch).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code: `case [1] of [x, ..._] -> { x } end`,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 1},
+				&object.Integer{Value: 0},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpArray, 1).
+				Make(code.OpSetGlobal, 0).
+				Make(code.OpGetGlobal, 0).
+				Make(code.OpMatchArray, 1, 1).
+				Make(code.OpJumpNotTrue, 35).
+				Make(code.OpGetGlobal, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpIndex).
+				Make(code.OpSetGlobal, 1).
+				Make(code.OpGetGlobal, 1).
+				Make(code.OpJump, 39).
+				Make(code.OpGetGlobal, 0).
+				Make(code.OpNoMatch).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code: `receive 2 -> { 3 } after 10 -> { 4 } end`,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 10},
+				&object.Integer{Value: 2},
+				&object.Integer{Value: 3},
+				&object.Integer{Value: 4},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpReceiveStart).
+				Make(code.OpReceive, 31).
+				Make(code.OpSetGlobal, 0).
+				Make(code.OpGetGlobal, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpMatchEqual).
+				Make(code.OpJumpNotTrue, 27).
+				Make(code.OpReceiveAccept).
+				Make(code.OpConstant, 2).
+				Make(code.OpJump, 34).
+				Make(code.OpReceiveNext).
+				Make(code.OpJump, 4).
+				Make(code.OpConstant, 3).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:              `()`,
+			expectedConstants: []object.Object{},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpTuple, 0).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code: `(1, "a")`,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 1},
+				&object.String{Value: "a"},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpTuple, 2).
+				Make(code.OpPop).
+				Build(),
+		},
 		{
 			code: `[1, 2, 3]`,
 			expectedConstants: []object.Object{
@@ -428,6 +586,29 @@ func Test_Compiler(t *testing.T) {
 				Make(code.OpPop).
 				Build(),
 		},
+		{
+			code: `fn () { yield 1; yield }`,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 1},
+				&object.CompiledFunction{
+					Instructions: code.NewBuilder().
+						Make(code.OpConstant, 0).
+						Make(code.OpYield).
+						Make(code.OpPop).
+						Make(code.OpNull).
+						Make(code.OpYield).
+						Make(code.OpReturnValue).
+						Build(),
+					LocalsCount:     0,
+					ParametersCount: 0,
+					Generator:       true,
+				},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpClosure, 1, 0).
+				Make(code.OpPop).
+				Build(),
+		},
 		{
 			code: `fn () { 1; 2 }`,
 			expectedConstants: []object.Object{
@@ -761,12 +942,70 @@ func Test_Compiler(t *testing.T) {
 	for _, testCase := range testCases {
 		t.Run(testCase.code, func(t *testing.T) {
 			bytecode := compileCode(t, testCase.code)
-			assert.Equal(t, testCase.expectedConstants, bytecode.Constants)
+			assert.Equal(t, testCase.expectedConstants, withoutDebugInfo(bytecode.Constants))
 			assert.Equal(t, testCase.expectedInstructions.String(), bytecode.Instructions.String())
 		})
 	}
 }
 
+func Test_Compiler_rebinding(t *testing.T) {
+	testCases := []struct {
+		code          string
+		expectedError string
+	}{
+		{
+			code:          `let x = 1; let x = 2`,
+			expectedError: "1:16: x is already defined in this scope",
+		},
+		{
+			code:          "let f = fn(a) {\n  let a = 1\n}",
+			expectedError: "2:7: a is already defined in this scope",
+		},
+		{
+			code:          "let f = fn() {\n  missing\n}",
+			expectedError: "2:3: undefined identifier: missing",
+		},
+		{
+			code: `let x = 1; let f = fn() { let x = 2; x }; let len = fn() { x }`,
+		},
+		{
+			code:          `let x = [x]`,
+			expectedError: "1:10: undefined identifier: x",
+		},
+		{
+			code:          `case 1 of X -> { X } end; X`,
+			expectedError: "1:27: undefined identifier: X",
+		},
+		{
+			code:          `case (1, 2) of (X, 3) -> { 0 } _ -> { X } end`,
+			expectedError: "1:39: undefined identifier: X",
+		},
+		{
+			code:          `receive X -> { X } after 0 -> { 0 } end; X`,
+			expectedError: "1:42: undefined identifier: X",
+		},
+		{
+			code: `let X = 10; case 3 of X -> { let y = X; y } end; let y = X`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.code, func(t *testing.T) {
+			program, err := parser.New(lexer.New(strings.NewReader(testCase.code))).ParseProgram()
+			assert.NoError(t, err)
+
+			err = New().Compile(program)
+			if testCase.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.IsType(t, &Error{}, err)
+			assert.EqualError(t, err, testCase.expectedError)
+		})
+	}
+}
+
 func compileCode(t *testing.T, input string) *Bytecode {
 	l := lexer.New(strings.NewReader(input))
 	p := parser.New(l)
@@ -780,3 +1019,37 @@ func compileCode(t *testing.T, input string) *Bytecode {
 
 	return compiler.Bytecode()
 }
+
+func withoutDebugInfo(constants []object.Object) []object.Object {
+	result := make([]object.Object, len(constants))
+	for i, constant := range constants {
+		if function, ok := constant.(*object.CompiledFunction); ok {
+			stripped := *function
+			stripped.Name = ""
+			stripped.LineTable = nil
+			constant = &stripped
+		}
+		result[i] = constant
+	}
+
+	return result
+}
+
+func Test_Compiler_lineTable(t *testing.T) {
+	input := "let add = fn(a, b) {\n  a + b\n};\nadd(1, 2)"
+	bytecode := compileCode(t, input)
+
+	function := bytecode.Constants[0].(*object.CompiledFunction)
+	assert.Equal(t, "add", function.Name)
+
+	span, ok := function.LineTable.Lookup(0)
+	assert.True(t, ok)
+	assert.Equal(t, 2, span.Start.Line)
+	assert.Equal(t, 3, span.Start.Column)
+
+	callOffset := len(bytecode.Instructions) - 3
+	span, ok = bytecode.LineTable.Lookup(callOffset)
+	assert.True(t, ok)
+	assert.Equal(t, 4, span.Start.Line)
+	assert.Equal(t, 1, span.Start.Column)
+}
@@ -0,0 +1,16 @@
+package compiler
+
+import (
+	"fmt"
+	"spike-interpreter-go/spike/lexer"
+)
+
+// Error is a compilation error located in the source.
+type Error struct {
+	Span    lexer.Span
+	Message string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s: %s", err.Span.Start, err.Message)
+}
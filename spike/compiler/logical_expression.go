@@ -0,0 +1,48 @@
+package compiler
+
+import (
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/parser/ast"
+)
+
+// compileLogicalExpression compiles && and || with jumps, so the right
+// operand only runs when the left one does not decide the result. Operands
+// go through OpJumpNotTrue, which fails for anything but booleans.
+func (compiler *Compiler) compileLogicalExpression(node *ast.InfixExpression) error {
+	err := compiler.Compile(node.Left)
+	if err != nil {
+		return err
+	}
+
+	leftJumpIndex := compiler.emit(code.OpJumpNotTrue, -1)
+
+	trueJumpIndex := -1
+	if node.Operator == "||" {
+		trueJumpIndex = compiler.emit(code.OpJump, -1)
+		compiler.changeOperand(leftJumpIndex, len(compiler.scopes[compiler.scopeIndex].instructions))
+	}
+
+	err = compiler.Compile(node.Right)
+	if err != nil {
+		return err
+	}
+
+	rightJumpIndex := compiler.emit(code.OpJumpNotTrue, -1)
+
+	if trueJumpIndex >= 0 {
+		compiler.changeOperand(trueJumpIndex, len(compiler.scopes[compiler.scopeIndex].instructions))
+	}
+	compiler.emit(code.OpTrue)
+	endJumpIndex := compiler.emit(code.OpJump, -1)
+
+	falseIndex := len(compiler.scopes[compiler.scopeIndex].instructions)
+	compiler.emit(code.OpFalse)
+	compiler.changeOperand(rightJumpIndex, falseIndex)
+	if node.Operator == "&&" {
+		compiler.changeOperand(leftJumpIndex, falseIndex)
+	}
+
+	compiler.changeOperand(endJumpIndex, len(compiler.scopes[compiler.scopeIndex].instructions))
+
+	return nil
+}
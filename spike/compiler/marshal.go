@@ -0,0 +1,459 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"math/big"
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+
+	"github.com/pkg/errors"
+)
+
+// BytecodeMagic starts every bytecode file.
+const BytecodeMagic = "SPKC"
+
+// BytecodeVersion is the version of the bytecode format written by Marshal.
+// It changes whenever the format or the meaning of an opcode changes, as
+// the VM can not run bytecode written for another version.
+const BytecodeVersion uint16 = 1
+
+// Tags of the entries of the constant pool.
+const (
+	integerTag byte = iota + 1
+	bigIntegerTag
+	floatTag
+	stringTag
+	atomTag
+	functionTag
+)
+
+// ErrNotBytecode is returned by Unmarshal for data without the magic header.
+var ErrNotBytecode = errors.New("not a Spike bytecode file")
+
+// VersionError is returned by Unmarshal for bytecode of another version.
+type VersionError struct {
+	Version uint16
+}
+
+func (err *VersionError) Error() string {
+	return fmt.Sprintf(
+		"bytecode version %d is not supported, expected version %d: recompile the program",
+		err.Version,
+		BytecodeVersion,
+	)
+}
+
+// IsBytecode tells whether data starts like a bytecode file.
+func IsBytecode(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(BytecodeMagic))
+}
+
+// Marshal encodes bytecode in the bytecode file format:
+//
+//	magic "SPKC", version uint16
+//	file names of the debug info
+//	constant pool, each constant a tag followed by its value
+//	instructions
+//	line table of the instructions
+//	CRC-32 checksum of everything before, uint32
+//
+// Integers are big-endian or varints, strings and byte slices are prefixed
+// with their length.
+func Marshal(bytecode *Bytecode) ([]byte, error) {
+	encoder := &encoder{files: map[string]int{}}
+	encoder.collectFiles(bytecode.LineTable)
+	for _, constant := range bytecode.Constants {
+		if function, ok := constant.(*object.CompiledFunction); ok {
+			encoder.collectFiles(function.LineTable)
+		}
+	}
+
+	encoder.buffer.WriteString(BytecodeMagic)
+	encoder.uint16(BytecodeVersion)
+
+	encoder.uvarint(uint64(len(encoder.fileNames)))
+	for _, name := range encoder.fileNames {
+		encoder.string(name)
+	}
+
+	encoder.uvarint(uint64(len(bytecode.Constants)))
+	for i, constant := range bytecode.Constants {
+		if err := encoder.constant(constant); err != nil {
+			return nil, errors.Wrapf(err, "constant %d", i)
+		}
+	}
+
+	encoder.bytes(bytecode.Instructions)
+	encoder.lineTable(bytecode.LineTable)
+
+	checksum := crc32.ChecksumIEEE(encoder.buffer.Bytes())
+	encoder.uint32(checksum)
+
+	return encoder.buffer.Bytes(), nil
+}
+
+// Unmarshal decodes bytecode written by Marshal.
+func Unmarshal(data []byte) (*Bytecode, error) {
+	if !IsBytecode(data) {
+		return nil, ErrNotBytecode
+	}
+
+	decoder := &decoder{data: data, offset: len(BytecodeMagic)}
+	version := decoder.uint16()
+	if decoder.err == nil && version != BytecodeVersion {
+		return nil, &VersionError{Version: version}
+	}
+
+	if len(data) < len(BytecodeMagic)+2+4 {
+		return nil, errors.New("truncated bytecode")
+	}
+	body := data[:len(data)-4]
+	checksum := binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != checksum {
+		return nil, errors.New("bytecode checksum mismatch, the file is corrupted")
+	}
+	decoder.data = body
+
+	fileCount := decoder.length()
+	for i := 0; i < fileCount && decoder.err == nil; i++ {
+		decoder.fileNames = append(decoder.fileNames, decoder.string())
+	}
+
+	bytecode := &Bytecode{Constants: []object.Object{}}
+	constantCount := decoder.length()
+	for i := 0; i < constantCount && decoder.err == nil; i++ {
+		bytecode.Constants = append(bytecode.Constants, decoder.constant())
+	}
+
+	bytecode.Instructions = decoder.bytes()
+	bytecode.LineTable = decoder.lineTable()
+
+	if decoder.err == nil && decoder.offset != len(body) {
+		decoder.err = errors.Errorf("%d unexpected bytes after the bytecode", len(body)-decoder.offset)
+	}
+	if decoder.err != nil {
+		return nil, decoder.err
+	}
+
+	return bytecode, nil
+}
+
+type encoder struct {
+	buffer    bytes.Buffer
+	files     map[string]int
+	fileNames []string
+}
+
+func (encoder *encoder) collectFiles(table code.LineTable) {
+	for _, entry := range table {
+		for _, name := range []string{entry.Span.Start.File, entry.Span.End.File} {
+			if _, ok := encoder.files[name]; !ok {
+				encoder.files[name] = len(encoder.fileNames)
+				encoder.fileNames = append(encoder.fileNames, name)
+			}
+		}
+	}
+}
+
+func (encoder *encoder) uint16(value uint16) {
+	var buffer [2]byte
+	binary.BigEndian.PutUint16(buffer[:], value)
+	encoder.buffer.Write(buffer[:])
+}
+
+func (encoder *encoder) uint32(value uint32) {
+	var buffer [4]byte
+	binary.BigEndian.PutUint32(buffer[:], value)
+	encoder.buffer.Write(buffer[:])
+}
+
+func (encoder *encoder) uint64(value uint64) {
+	var buffer [8]byte
+	binary.BigEndian.PutUint64(buffer[:], value)
+	encoder.buffer.Write(buffer[:])
+}
+
+func (encoder *encoder) uvarint(value uint64) {
+	var buffer [binary.MaxVarintLen64]byte
+	encoder.buffer.Write(buffer[:binary.PutUvarint(buffer[:], value)])
+}
+
+func (encoder *encoder) varint(value int64) {
+	var buffer [binary.MaxVarintLen64]byte
+	encoder.buffer.Write(buffer[:binary.PutVarint(buffer[:], value)])
+}
+
+func (encoder *encoder) bytes(value []byte) {
+	encoder.uvarint(uint64(len(value)))
+	encoder.buffer.Write(value)
+}
+
+func (encoder *encoder) string(value string) {
+	encoder.bytes([]byte(value))
+}
+
+func (encoder *encoder) boolean(value bool) {
+	if value {
+		encoder.buffer.WriteByte(1)
+	} else {
+		encoder.buffer.WriteByte(0)
+	}
+}
+
+func (encoder *encoder) position(position lexer.Position) {
+	encoder.uvarint(uint64(encoder.files[position.File]))
+	encoder.uvarint(uint64(position.Line))
+	encoder.uvarint(uint64(position.Column))
+	encoder.uvarint(uint64(position.Offset))
+}
+
+func (encoder *encoder) lineTable(table code.LineTable) {
+	encoder.uvarint(uint64(len(table)))
+	for _, entry := range table {
+		encoder.uvarint(uint64(entry.Offset))
+		encoder.position(entry.Span.Start)
+		encoder.position(entry.Span.End)
+	}
+}
+
+func (encoder *encoder) constant(constant object.Object) error {
+	switch constant := constant.(type) {
+	case *object.Integer:
+		encoder.buffer.WriteByte(integerTag)
+		encoder.varint(constant.Value)
+
+	case *object.BigInteger:
+		encoder.buffer.WriteByte(bigIntegerTag)
+		encoder.boolean(constant.Value.Sign() < 0)
+		encoder.bytes(constant.Value.Bytes())
+
+	case *object.Float:
+		encoder.buffer.WriteByte(floatTag)
+		encoder.uint64(math.Float64bits(constant.Value))
+
+	case *object.String:
+		encoder.buffer.WriteByte(stringTag)
+		encoder.string(constant.Value)
+
+	case *object.Atom:
+		encoder.buffer.WriteByte(atomTag)
+		encoder.string(constant.Name)
+
+	case *object.CompiledFunction:
+		encoder.buffer.WriteByte(functionTag)
+		encoder.string(constant.Name)
+		encoder.uvarint(uint64(constant.LocalsCount))
+		encoder.uvarint(uint64(constant.ParametersCount))
+		encoder.boolean(constant.Generator)
+		encoder.bytes(constant.Instructions)
+		encoder.lineTable(constant.LineTable)
+
+	default:
+		return errors.Errorf("unable to encode %s", constant.Type())
+	}
+
+	return nil
+}
+
+// decoder reads values written by encoder. The first error is kept in err
+// and later reads return zero values, so callers check it once at the end.
+type decoder struct {
+	data      []byte
+	offset    int
+	fileNames []string
+	err       error
+}
+
+func (decoder *decoder) fail(format string, args ...interface{}) {
+	if decoder.err == nil {
+		decoder.err = errors.Errorf("invalid bytecode at byte %d: %s", decoder.offset, fmt.Sprintf(format, args...))
+	}
+}
+
+func (decoder *decoder) next(n int) []byte {
+	if decoder.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(decoder.data)-decoder.offset {
+		decoder.fail("truncated")
+		return nil
+	}
+
+	value := decoder.data[decoder.offset : decoder.offset+n]
+	decoder.offset += n
+
+	return value
+}
+
+func (decoder *decoder) byte() byte {
+	value := decoder.next(1)
+	if value == nil {
+		return 0
+	}
+
+	return value[0]
+}
+
+func (decoder *decoder) uint16() uint16 {
+	value := decoder.next(2)
+	if value == nil {
+		return 0
+	}
+
+	return binary.BigEndian.Uint16(value)
+}
+
+func (decoder *decoder) uint64() uint64 {
+	value := decoder.next(8)
+	if value == nil {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(value)
+}
+
+func (decoder *decoder) uvarint() uint64 {
+	if decoder.err != nil {
+		return 0
+	}
+
+	value, n := binary.Uvarint(decoder.data[decoder.offset:])
+	if n <= 0 {
+		decoder.fail("malformed number")
+		return 0
+	}
+	decoder.offset += n
+
+	return value
+}
+
+func (decoder *decoder) varint() int64 {
+	if decoder.err != nil {
+		return 0
+	}
+
+	value, n := binary.Varint(decoder.data[decoder.offset:])
+	if n <= 0 {
+		decoder.fail("malformed number")
+		return 0
+	}
+	decoder.offset += n
+
+	return value
+}
+
+// integer reads a non-negative int.
+func (decoder *decoder) integer() int {
+	value := decoder.uvarint()
+	if value > math.MaxInt32 {
+		decoder.fail("number %d out of range", value)
+		return 0
+	}
+
+	return int(value)
+}
+
+// length reads a count or size, which can not exceed the remaining data.
+func (decoder *decoder) length() int {
+	value := decoder.uvarint()
+	if value > uint64(len(decoder.data)-decoder.offset) {
+		decoder.fail("length %d exceeds the data", value)
+		return 0
+	}
+
+	return int(value)
+}
+
+func (decoder *decoder) bytes() []byte {
+	value := decoder.next(decoder.length())
+	if value == nil {
+		return nil
+	}
+
+	return append([]byte{}, value...)
+}
+
+func (decoder *decoder) string() string {
+	return string(decoder.bytes())
+}
+
+func (decoder *decoder) boolean() bool {
+	return decoder.byte() != 0
+}
+
+func (decoder *decoder) position() lexer.Position {
+	file := decoder.uvarint()
+	position := lexer.Position{
+		Line:   decoder.integer(),
+		Column: decoder.integer(),
+		Offset: decoder.integer(),
+	}
+
+	if file >= uint64(len(decoder.fileNames)) {
+		decoder.fail("file %d is not in the file table", file)
+		return position
+	}
+	position.File = decoder.fileNames[file]
+
+	return position
+}
+
+func (decoder *decoder) lineTable() code.LineTable {
+	count := decoder.length()
+	if count == 0 {
+		return nil
+	}
+
+	table := make(code.LineTable, 0, count)
+	for i := 0; i < count && decoder.err == nil; i++ {
+		table = append(table, code.LineTableEntry{
+			Offset: decoder.integer(),
+			Span:   lexer.Span{Start: decoder.position(), End: decoder.position()},
+		})
+	}
+
+	return table
+}
+
+func (decoder *decoder) constant() object.Object {
+	switch tag := decoder.byte(); tag {
+	case integerTag:
+		return &object.Integer{Value: decoder.varint()}
+
+	case bigIntegerTag:
+		negative := decoder.boolean()
+		value := new(big.Int).SetBytes(decoder.bytes())
+		if negative {
+			value.Neg(value)
+		}
+		return object.NewInteger(value)
+
+	case floatTag:
+		return &object.Float{Value: math.Float64frombits(decoder.uint64())}
+
+	case stringTag:
+		return &object.String{Value: decoder.string()}
+
+	case atomTag:
+		return object.NewAtom(decoder.string())
+
+	case functionTag:
+		return &object.CompiledFunction{
+			Name:            decoder.string(),
+			LocalsCount:     decoder.integer(),
+			ParametersCount: decoder.integer(),
+			Generator:       decoder.boolean(),
+			Instructions:    decoder.bytes(),
+			LineTable:       decoder.lineTable(),
+		}
+
+	default:
+		decoder.fail("unknown constant tag %d", tag)
+		return nil
+	}
+}
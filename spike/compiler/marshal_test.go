@@ -0,0 +1,148 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"math/big"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func compileForMarshal(t *testing.T, source string) *Bytecode {
+	program, err := parser.New(lexer.NewWithFileName(strings.NewReader(source), "main.spk")).ParseProgram()
+	assert.NoError(t, err)
+
+	compiler := New()
+	assert.NoError(t, compiler.Compile(program))
+
+	return compiler.Bytecode()
+}
+
+func Test_Marshal(t *testing.T) {
+	testCases := []string{
+		"",
+		"1 + 2",
+		`let greeting = "Hello"; let pi = 3.14; [greeting, pi, :ok, -9223372036854775807]`,
+		"let add = fn(a, b) { let c = a + b; c }; add(1, 2)",
+		"let outer = fn(a) { fn(b) { a + b } }; outer(1)(2)",
+		"let numbers = fn() { yield 1; yield 2 }; numbers()",
+	}
+
+	for _, source := range testCases {
+		t.Run(source, func(t *testing.T) {
+			bytecode := compileForMarshal(t, source)
+
+			data, err := Marshal(bytecode)
+			assert.NoError(t, err)
+			assert.True(t, IsBytecode(data))
+
+			unmarshaled, err := Unmarshal(data)
+			assert.NoError(t, err)
+			assert.Equal(t, bytecode.Instructions, unmarshaled.Instructions)
+			assert.Equal(t, bytecode.LineTable, unmarshaled.LineTable)
+			assert.Equal(t, bytecode.Constants, unmarshaled.Constants)
+		})
+	}
+}
+
+func Test_Marshal_constants(t *testing.T) {
+	bigInteger, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	bytecode := &Bytecode{
+		Constants: []object.Object{
+			&object.Integer{Value: -42},
+			&object.BigInteger{Value: bigInteger},
+			&object.Float{Value: 0.1},
+			&object.String{Value: "spike"},
+			object.NewAtom("ok"),
+		},
+	}
+
+	data, err := Marshal(bytecode)
+	assert.NoError(t, err)
+
+	unmarshaled, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, bytecode.Constants, unmarshaled.Constants)
+	assert.True(t, unmarshaled.Constants[4] == object.NewAtom("ok"), "atoms are interned")
+
+	data, err = Marshal(&Bytecode{Constants: []object.Object{&object.BigInteger{Value: big.NewInt(-7)}}})
+	assert.NoError(t, err)
+	unmarshaled, err = Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []object.Object{&object.Integer{Value: -7}}, unmarshaled.Constants)
+
+	_, err = Marshal(&Bytecode{Constants: []object.Object{&object.Array{}}})
+	assert.EqualError(t, err, "constant 0: unable to encode array")
+}
+
+func Test_Unmarshal_errors(t *testing.T) {
+	data, err := Marshal(compileForMarshal(t, "let add = fn(a, b) { a + b }; add(1, 2)"))
+	assert.NoError(t, err)
+
+	withChecksum := func(body []byte) []byte {
+		var checksum [4]byte
+		binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(body))
+		return append(append([]byte{}, body...), checksum[:]...)
+	}
+
+	otherVersion := append([]byte{}, data...)
+	binary.BigEndian.PutUint16(otherVersion[len(BytecodeMagic):], BytecodeVersion+1)
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)/2] ^= 0xff
+
+	testCases := []struct {
+		name          string
+		data          []byte
+		expectedError string
+	}{
+		{
+			name:          "source code",
+			data:          []byte("1 + 2"),
+			expectedError: "not a Spike bytecode file",
+		},
+		{
+			name:          "other version",
+			data:          otherVersion,
+			expectedError: "bytecode version 2 is not supported, expected version 1: recompile the program",
+		},
+		{
+			name:          "corrupted",
+			data:          corrupted,
+			expectedError: "bytecode checksum mismatch, the file is corrupted",
+		},
+		{
+			name:          "no checksum",
+			data:          []byte(BytecodeMagic + "\x00\x01"),
+			expectedError: "truncated bytecode",
+		},
+		{
+			name:          "truncated",
+			data:          withChecksum(data[:len(data)-10]),
+			expectedError: "invalid bytecode at byte",
+		},
+		{
+			name:          "unknown constant tag",
+			data:          withChecksum([]byte(BytecodeMagic + "\x00\x01\x00\x01\x63")),
+			expectedError: "invalid bytecode at byte 9: unknown constant tag 99",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			bytecode, err := Unmarshal(testCase.data)
+			assert.Nil(t, bytecode)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), testCase.expectedError)
+			}
+		})
+	}
+
+	_, err = Unmarshal(otherVersion)
+	assert.IsType(t, &VersionError{}, err)
+}
@@ -0,0 +1,519 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+)
+
+// OptimizationLevel selects the passes Optimize runs.
+type OptimizationLevel int
+
+const (
+	// O0 leaves bytecode as the compiler emitted it.
+	O0 OptimizationLevel = iota
+	// O1 threads jumps, removes unreachable code and jumps to the next
+	// instruction, and merges equal constants.
+	O1
+	// O2 also folds operations on integer, string and boolean literals.
+	O2
+)
+
+// MaxOptimizationLevel is the highest level Optimize knows.
+const MaxOptimizationLevel = O2
+
+var foldedOperators = map[code.Opcode]string{
+	code.OpAdd:            "+",
+	code.OpSub:            "-",
+	code.OpMul:            "*",
+	code.OpDiv:            "/",
+	code.OpEqual:          "==",
+	code.OpNotEqual:       "!=",
+	code.OpGreaterThan:    ">",
+	code.OpGreaterOrEqual: ">=",
+	code.OpLessThan:       "<",
+	code.OpLessOrEqual:    "<=",
+}
+
+// Optimize returns bytecode rewritten by the passes of level. It behaves the
+// same as bytecode, runtime errors included, only faster. bytecode itself is
+// left alone: functions that change are copied.
+func Optimize(bytecode *Bytecode, level OptimizationLevel) *Bytecode {
+	if level <= O0 {
+		return bytecode
+	}
+
+	optimizer := &optimizer{
+		level:     level,
+		constants: append([]object.Object{}, bytecode.Constants...),
+	}
+
+	instructions, lineTable := optimizer.function(bytecode.Instructions, bytecode.LineTable)
+	for i, constant := range optimizer.constants {
+		if function, ok := constant.(*object.CompiledFunction); ok {
+			optimized := *function
+			optimized.Instructions, optimized.LineTable = optimizer.function(function.Instructions, function.LineTable)
+			optimizer.constants[i] = &optimized
+		}
+	}
+
+	if optimizer.malformed {
+		return &Bytecode{Instructions: instructions, LineTable: lineTable, Constants: optimizer.constants}
+	}
+
+	instructions, constants := optimizer.compactConstants(instructions)
+
+	return &Bytecode{Instructions: instructions, LineTable: lineTable, Constants: constants}
+}
+
+type optimizer struct {
+	level     OptimizationLevel
+	constants []object.Object
+	// malformed is set when instructions that do not decode are left as they
+	// are, so that constants can not be renumbered.
+	malformed bool
+}
+
+// instruction is a decoded instruction. Jumps refer to the index of their
+// target in the instruction list rather than to its offset, so that passes
+// can remove and replace instructions without fixing jumps up.
+type instruction struct {
+	opcode   code.Opcode
+	operands []int
+	span     lexer.Span
+	hasSpan  bool
+	removed  bool
+}
+
+// function runs the passes over instructions until none of them changes
+// anything and returns the rewritten instructions with their line table.
+func (optimizer *optimizer) function(instructions code.Instructions, lineTable code.LineTable) (code.Instructions, code.LineTable) {
+	list, ok := decodeInstructions(instructions, lineTable)
+	if !ok {
+		optimizer.malformed = true
+		return instructions, lineTable
+	}
+
+	for changed := true; changed; {
+		changed = false
+		if optimizer.level >= O2 {
+			changed = optimizer.foldConstants(list) || changed
+		}
+		changed = threadJumps(list) || changed
+		changed = removeUnreachable(list) || changed
+		changed = removeJumpsToNext(list) || changed
+	}
+
+	return encodeInstructions(list)
+}
+
+// decodeInstructions fails for instructions that do not decode or jump into
+// the middle of an instruction, which are left unoptimized.
+func decodeInstructions(instructions code.Instructions, lineTable code.LineTable) ([]*instruction, bool) {
+	var list []*instruction
+	indexes := map[int]int{len(instructions): -1}
+
+	for offset := 0; offset < len(instructions); {
+		_, operands, length, err := instructions.ReadInstruction(offset)
+		if err != nil {
+			return nil, false
+		}
+
+		span, hasSpan := lineTable.Lookup(offset)
+		indexes[offset] = len(list)
+		list = append(list, &instruction{
+			opcode:   code.Opcode(instructions[offset]),
+			operands: operands,
+			span:     span,
+			hasSpan:  hasSpan,
+		})
+		offset += length
+	}
+	indexes[len(instructions)] = len(list)
+
+	for _, instruction := range list {
+		if !code.IsJump(instruction.opcode) {
+			continue
+		}
+
+		target, ok := indexes[instruction.operands[0]]
+		if !ok {
+			return nil, false
+		}
+		instruction.operands[0] = target
+	}
+
+	return list, true
+}
+
+// encodeInstructions drops removed instructions. Jumps to them land on the
+// next instruction kept.
+func encodeInstructions(list []*instruction) (code.Instructions, code.LineTable) {
+	offsets := make([]int, len(list)+1)
+	offset := 0
+	for i, instruction := range list {
+		offsets[i] = offset
+		if !instruction.removed {
+			definition, _ := code.Lookup(instruction.opcode)
+			offset += 1 + definition.Width()
+		}
+	}
+	offsets[len(list)] = offset
+
+	instructions := code.Instructions{}
+	var lineTable code.LineTable
+	for _, instruction := range list {
+		if instruction.removed {
+			continue
+		}
+
+		operands := instruction.operands
+		if code.IsJump(instruction.opcode) {
+			operands = append([]int{offsets[operands[0]]}, operands[1:]...)
+		}
+
+		if instruction.hasSpan {
+			lineTable = lineTable.Add(len(instructions), instruction.span)
+		}
+		encoded, _ := code.Make(instruction.opcode, operands...)
+		instructions = append(instructions, encoded...)
+	}
+
+	return instructions, lineTable
+}
+
+// next returns the index of the first instruction kept from index on, which
+// is len(list) past the end.
+func next(list []*instruction, index int) int {
+	for index < len(list) && list[index].removed {
+		index++
+	}
+
+	return index
+}
+
+// targets returns the indexes jumps land on.
+func targets(list []*instruction) map[int]bool {
+	targets := map[int]bool{}
+	for _, instruction := range list {
+		if !instruction.removed && code.IsJump(instruction.opcode) {
+			targets[next(list, instruction.operands[0])] = true
+		}
+	}
+
+	return targets
+}
+
+// threadJumps makes jumps landing on an OpJump land on its target instead.
+func threadJumps(list []*instruction) bool {
+	changed := false
+	for _, instruction := range list {
+		if instruction.removed || !code.IsJump(instruction.opcode) {
+			continue
+		}
+
+		// Jumps that go round in circles are left alone.
+		target := next(list, instruction.operands[0])
+		seen := map[int]bool{}
+		for target < len(list) && list[target].opcode == code.OpJump && !seen[target] {
+			seen[target] = true
+			target = next(list, list[target].operands[0])
+		}
+
+		if !seen[target] && target != instruction.operands[0] {
+			instruction.operands[0] = target
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// removeUnreachable removes the instructions no path from the first one
+// reaches, such as code following a return.
+func removeUnreachable(list []*instruction) bool {
+	reachable := make([]bool, len(list))
+	pending := []int{next(list, 0)}
+	for len(pending) > 0 {
+		index := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if index >= len(list) || reachable[index] {
+			continue
+		}
+		reachable[index] = true
+
+		instruction := list[index]
+		if code.IsJump(instruction.opcode) {
+			pending = append(pending, next(list, instruction.operands[0]))
+		}
+
+		switch instruction.opcode {
+		case code.OpJump, code.OpReturnValue, code.OpReturn, code.OpNoMatch:
+		default:
+			pending = append(pending, next(list, index+1))
+		}
+	}
+
+	changed := false
+	for i, instruction := range list {
+		if !instruction.removed && !reachable[i] {
+			instruction.removed = true
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// removeJumpsToNext removes jumps landing on the instruction that follows
+// them anyway.
+func removeJumpsToNext(list []*instruction) bool {
+	changed := false
+	for i, instruction := range list {
+		if !instruction.removed && instruction.opcode == code.OpJump && next(list, instruction.operands[0]) == next(list, i+1) {
+			instruction.removed = true
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// foldConstants replaces operations on literals by their result and
+// conditional jumps on boolean literals by what they do. Operations that
+// fail are left to fail at runtime. Only instructions no jump lands on
+// are folded into the one before them.
+func (optimizer *optimizer) foldConstants(list []*instruction) bool {
+	changed := false
+	jumpTargets := targets(list)
+
+	var window []int
+	for i, instruction := range list {
+		if instruction.removed {
+			continue
+		}
+		if jumpTargets[i] {
+			window = window[:0]
+		}
+		window = append(window, i)
+
+		if optimizer.foldLast(list, window) {
+			changed = true
+
+			kept := window[:0]
+			for _, index := range window {
+				if !list[index].removed {
+					kept = append(kept, index)
+				}
+			}
+			window = kept
+		}
+	}
+
+	return changed
+}
+
+// foldLast folds the last instruction of window, a run of instructions kept
+// that no jump lands in, with the literals before it.
+func (optimizer *optimizer) foldLast(list []*instruction, window []int) bool {
+	last := list[window[len(window)-1]]
+	literal := func(fromEnd int) (object.Object, *instruction) {
+		if len(window) <= fromEnd {
+			return nil, nil
+		}
+		instruction := list[window[len(window)-1-fromEnd]]
+		return optimizer.literal(instruction), instruction
+	}
+
+	switch last.opcode {
+	case code.OpMinus, code.OpBang:
+		operand, operandInstruction := literal(1)
+		result := foldPrefix(last.opcode, operand)
+		if result == nil {
+			return false
+		}
+		operandInstruction.removed = true
+		return optimizer.replace(last, result)
+
+	case code.OpJumpNotTrue:
+		condition, conditionInstruction := literal(1)
+		if condition != &object.True && condition != &object.False {
+			return false
+		}
+		conditionInstruction.removed = true
+		if condition == &object.True {
+			last.removed = true
+		} else {
+			last.opcode = code.OpJump
+		}
+		return true
+	}
+
+	operator, ok := foldedOperators[last.opcode]
+	if !ok {
+		return false
+	}
+
+	right, rightInstruction := literal(1)
+	left, leftInstruction := literal(2)
+	result := foldInfix(last.opcode, operator, left, right)
+	if result == nil {
+		return false
+	}
+	leftInstruction.removed = true
+	rightInstruction.removed = true
+	return optimizer.replace(last, result)
+}
+
+// literal returns the integer, string or boolean instruction pushes, if any.
+func (optimizer *optimizer) literal(instruction *instruction) object.Object {
+	switch instruction.opcode {
+	case code.OpTrue:
+		return &object.True
+	case code.OpFalse:
+		return &object.False
+	case code.OpConstant:
+		switch constant := optimizer.constants[instruction.operands[0]].(type) {
+		case *object.Integer, *object.String:
+			return constant
+		}
+	}
+
+	return nil
+}
+
+// replace turns instruction into one pushing value. It keeps its span, which
+// covers the whole expression folded.
+func (optimizer *optimizer) replace(instruction *instruction, value object.Object) bool {
+	switch value {
+	case &object.True:
+		instruction.opcode, instruction.operands = code.OpTrue, nil
+	case &object.False:
+		instruction.opcode, instruction.operands = code.OpFalse, nil
+	default:
+		instruction.opcode = code.OpConstant
+		instruction.operands = []int{len(optimizer.constants)}
+		optimizer.constants = append(optimizer.constants, value)
+	}
+
+	return true
+}
+
+func foldPrefix(opcode code.Opcode, operand object.Object) object.Object {
+	switch {
+	case operand == nil:
+		return nil
+	case opcode == code.OpBang && operand == &object.True:
+		return &object.False
+	case opcode == code.OpBang && operand == &object.False:
+		return &object.True
+	case opcode == code.OpMinus:
+		if integer, ok := operand.(*object.Integer); ok && integer.Value != math.MinInt64 {
+			return &object.Integer{Value: -integer.Value}
+		}
+	}
+
+	return nil
+}
+
+// foldInfix computes an operation the way the VM does, or returns nil when
+// it would fail or not result in an integer, a string or a boolean.
+func foldInfix(opcode code.Opcode, operator string, left, right object.Object) object.Object {
+	if left == nil || right == nil {
+		return nil
+	}
+
+	switch opcode {
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv:
+		leftString, leftIsString := left.(*object.String)
+		rightString, rightIsString := right.(*object.String)
+		if opcode == code.OpAdd && leftIsString && rightIsString {
+			return &object.String{Value: leftString.Value + rightString.Value}
+		}
+		if !object.IsInteger(left) || !object.IsInteger(right) {
+			return nil
+		}
+
+		result, err := object.IntegerOperation(operator, left, right)
+		if err != nil {
+			return nil
+		}
+		if _, ok := result.(*object.Integer); !ok {
+			return nil
+		}
+		return result
+	}
+
+	result, err := object.CompareObjects(operator, left, right)
+	if err != nil {
+		return nil
+	}
+	if result {
+		return &object.True
+	}
+	return &object.False
+}
+
+// compactConstants returns the constants main uses, directly or through the
+// functions it creates, with equal literals merged, and main renumbered
+// accordingly. Constants only used by code removed are dropped.
+func (optimizer *optimizer) compactConstants(main code.Instructions) (code.Instructions, []object.Object) {
+	constants := []object.Object{}
+	indexes := map[int]int{}
+	literals := map[string]int{}
+
+	var renumber func(instructions code.Instructions) code.Instructions
+	renumber = func(instructions code.Instructions) code.Instructions {
+		renumbered := append(code.Instructions{}, instructions...)
+		for offset := 0; offset < len(instructions); {
+			_, operands, length, _ := instructions.ReadInstruction(offset)
+			opcode := code.Opcode(instructions[offset])
+			if opcode != code.OpConstant && opcode != code.OpClosure {
+				offset += length
+				continue
+			}
+
+			index, ok := indexes[operands[0]]
+			if !ok {
+				constant := optimizer.constants[operands[0]]
+				key, mergeable := literalKey(constant)
+				if index, ok = literals[key]; !ok || !mergeable {
+					index = len(constants)
+					constants = append(constants, constant)
+					if mergeable {
+						literals[key] = index
+					}
+				}
+				indexes[operands[0]] = index
+
+				if function, ok := constant.(*object.CompiledFunction); ok {
+					renumberedFunction := *function
+					renumberedFunction.Instructions = renumber(function.Instructions)
+					constants[index] = &renumberedFunction
+				}
+			}
+
+			binary.BigEndian.PutUint16(renumbered[offset+1:], uint16(index))
+			offset += length
+		}
+
+		return renumbered
+	}
+
+	return renumber(main), constants
+}
+
+// literalKey identifies literal constants by type and value.
+func literalKey(constant object.Object) (string, bool) {
+	switch constant := constant.(type) {
+	case *object.Float:
+		return fmt.Sprintf("float %x", math.Float64bits(constant.Value)), true
+	case *object.Integer, *object.BigInteger, *object.String, *object.Atom:
+		return fmt.Sprintf("%s %s", constant.Type(), constant.Inspect()), true
+	}
+
+	return "", false
+}
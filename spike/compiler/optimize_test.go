@@ -0,0 +1,202 @@
+package compiler
+
+import (
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/object"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Optimize(t *testing.T) {
+	testCases := []struct {
+		code                 string
+		level                OptimizationLevel
+		expectedConstants    []object.Object
+		expectedInstructions code.Instructions
+	}{
+		{
+			code:  "2 * 60 * 60",
+			level: O2,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 7200},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:  "2 * 60 * 60",
+			level: O1,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 2},
+				&object.Integer{Value: 60},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpMul).
+				Make(code.OpConstant, 1).
+				Make(code.OpMul).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:              `"a" + "b" == "ab"`,
+			level:             O2,
+			expectedConstants: []object.Object{},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpTrue).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:  "-5; !true",
+			level: O2,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: -5},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpPop).
+				Make(code.OpFalse).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:  "1 / 0",
+			level: O2,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 1},
+				&object.Integer{Value: 0},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpDiv).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:  `"a" + 1`,
+			level: O2,
+			expectedConstants: []object.Object{
+				&object.String{Value: "a"},
+				&object.Integer{Value: 1},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpAdd).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:  "9223372036854775807 + 1",
+			level: O2,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 9223372036854775807},
+				&object.Integer{Value: 1},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpConstant, 1).
+				Make(code.OpAdd).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:  "if (1 < 2) { 10 } else { 20 }",
+			level: O2,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 10},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:              "if (false) { 10 }",
+			level:             O2,
+			expectedConstants: []object.Object{},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpNull).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:  "let x = 1; if (x) { if (x) { 2 } else { 3 } } else { 4 }",
+			level: O1,
+			expectedConstants: []object.Object{
+				&object.Integer{Value: 1},
+				&object.Integer{Value: 2},
+				&object.Integer{Value: 3},
+				&object.Integer{Value: 4},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpConstant, 0).
+				Make(code.OpSetGlobal, 0).
+				Make(code.OpGetGlobal, 0).
+				Make(code.OpJumpNotTrue, 30).
+				Make(code.OpGetGlobal, 0).
+				Make(code.OpJumpNotTrue, 24).
+				Make(code.OpConstant, 1).
+				Make(code.OpJump, 33).
+				Make(code.OpConstant, 2).
+				Make(code.OpJump, 33).
+				Make(code.OpConstant, 3).
+				Make(code.OpPop).
+				Build(),
+		},
+		{
+			code:  "fn() { return 1; 2 }",
+			level: O1,
+			expectedConstants: []object.Object{
+				&object.CompiledFunction{
+					Instructions: code.NewBuilder().
+						Make(code.OpConstant, 1).
+						Make(code.OpReturnValue).
+						Build(),
+				},
+				&object.Integer{Value: 1},
+			},
+			expectedInstructions: code.NewBuilder().
+				Make(code.OpClosure, 0, 0).
+				Make(code.OpPop).
+				Build(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.code, func(t *testing.T) {
+			bytecode := compileCode(t, testCase.code)
+			instructions := bytecode.Instructions.String()
+
+			optimized := Optimize(bytecode, testCase.level)
+			assert.Equal(t, testCase.expectedConstants, withoutDebugInfo(optimized.Constants))
+			assert.Equal(t, testCase.expectedInstructions.String(), optimized.Instructions.String())
+			assert.Equal(t, instructions, bytecode.Instructions.String())
+		})
+	}
+}
+
+func Test_Optimize_O0(t *testing.T) {
+	bytecode := compileCode(t, "if (true) { 2 * 60 }")
+
+	assert.True(t, bytecode == Optimize(bytecode, O0))
+}
+
+func Test_Optimize_lineTable(t *testing.T) {
+	bytecode := compileCode(t, "if (true) {\n  1\n};\n2 + 3")
+	optimized := Optimize(bytecode, O2)
+
+	span, ok := optimized.LineTable.Lookup(0)
+	assert.True(t, ok)
+	assert.Equal(t, 2, span.Start.Line)
+
+	span, ok = optimized.LineTable.Lookup(len(optimized.Instructions) - 4)
+	assert.True(t, ok)
+	assert.Equal(t, 4, span.Start.Line)
+}
@@ -0,0 +1,90 @@
+package compiler
+
+import (
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/parser/ast"
+)
+
+// receivedMessageName names the hidden variable holding the message matched
+// by a receive expression.
+const receivedMessageName = "$receive"
+
+// compileReceiveExpression compiles a loop over the mailbox. OpReceiveStart
+// pushes a cursor built from the timeout, which stays on the stack while
+// clauses are tested. OpReceive pushes the message under the cursor, or pops
+// the cursor and jumps to the after clause once the timeout expires. A
+// matching clause removes the message with OpReceiveAccept, otherwise
+// OpReceiveNext moves the cursor to the next message.
+func (compiler *Compiler) compileReceiveExpression(node *ast.ReceiveExpression) error {
+	if node.Timeout != nil {
+		err := compiler.Compile(node.Timeout)
+		if err != nil {
+			return err
+		}
+	} else {
+		compiler.emit(code.OpNull)
+	}
+	compiler.emit(code.OpReceiveStart)
+
+	loopStart := len(compiler.scopes[compiler.scopeIndex].instructions)
+	timeoutJump := compiler.emit(code.OpReceive, -1)
+
+	message := compiler.symbolTable.Define(receivedMessageName)
+	compiler.storeSymbol(message)
+	loadMessage := func() error {
+		compiler.loadSymbol(message)
+		return nil
+	}
+
+	endJumps := make([]int, 0, len(node.Clauses))
+	for _, clause := range node.Clauses {
+		failJumps := make([]int, 0)
+		compiler.enterBlock()
+
+		err := compiler.compilePattern(clause.Pattern, loadMessage, &failJumps)
+		if err != nil {
+			return err
+		}
+
+		if clause.Guard != nil {
+			err := compiler.Compile(clause.Guard)
+			if err != nil {
+				return err
+			}
+			failJumps = append(failJumps, compiler.emit(code.OpJumpNotTrue, -1))
+		}
+
+		compiler.emit(code.OpReceiveAccept)
+		err = compiler.compileClauseBody(clause.Body)
+		if err != nil {
+			return err
+		}
+		endJumps = append(endJumps, compiler.emit(code.OpJump, -1))
+		compiler.leaveBlock()
+
+		nextClauseIndex := len(compiler.scopes[compiler.scopeIndex].instructions)
+		for _, failJump := range failJumps {
+			compiler.changeOperand(failJump, nextClauseIndex)
+		}
+	}
+
+	compiler.emit(code.OpReceiveNext)
+	compiler.emit(code.OpJump, loopStart)
+
+	compiler.changeOperand(timeoutJump, len(compiler.scopes[compiler.scopeIndex].instructions))
+	if node.After != nil {
+		err := compiler.compileClauseBody(node.After)
+		if err != nil {
+			return err
+		}
+	} else {
+		compiler.emit(code.OpNull)
+	}
+
+	endIndex := len(compiler.scopes[compiler.scopeIndex].instructions)
+	for _, endJump := range endJumps {
+		compiler.changeOperand(endJump, endIndex)
+	}
+
+	return nil
+}
@@ -1,5 +1,7 @@
 package compiler
 
+import "spike-interpreter-go/spike/object"
+
 type SymbolScope string
 
 const (
@@ -20,6 +22,10 @@ type SymbolTable struct {
 	FreeSymbols    []Symbol
 	store          map[string]Symbol
 	numDefinitions int
+
+	// block is set for tables of blocks, which define their symbols in the
+	// scope of Outer.
+	block bool
 }
 
 func NewSymbolTable() *SymbolTable {
@@ -29,6 +35,17 @@ func NewSymbolTable() *SymbolTable {
 	}
 }
 
+// NewGlobalSymbolTable returns a symbol table for a whole program, knowing
+// the builtins. Tables passed to NewWithState should start out as one.
+func NewGlobalSymbolTable() *SymbolTable {
+	symbolTable := NewSymbolTable()
+	for i, builtin := range object.Builtins {
+		symbolTable.DefineBuiltin(i, builtin.Name)
+	}
+
+	return symbolTable
+}
+
 func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
 	return &SymbolTable{
 		Outer:          outer,
@@ -37,19 +54,60 @@ func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
 	}
 }
 
+// NewBlockSymbolTable returns a table for a block of outer's scope, such as a
+// case clause. The names it defines are only visible in the block, but their
+// symbols are allocated in outer's scope and never reused.
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	return &SymbolTable{
+		Outer: outer,
+		store: make(map[string]Symbol),
+		block: true,
+	}
+}
+
+// Copy returns a table with the symbols of symbolTable, which can be changed
+// without changing symbolTable. Compiling with a copy lets a failed
+// compilation be discarded.
+func (symbolTable *SymbolTable) Copy() *SymbolTable {
+	copied := &SymbolTable{
+		Outer:          symbolTable.Outer,
+		FreeSymbols:    append([]Symbol{}, symbolTable.FreeSymbols...),
+		store:          make(map[string]Symbol, len(symbolTable.store)),
+		numDefinitions: symbolTable.numDefinitions,
+		block:          symbolTable.block,
+	}
+	for name, symbol := range symbolTable.store {
+		copied.store[name] = symbol
+	}
+
+	return copied
+}
+
 func (symbolTable *SymbolTable) Define(name string) Symbol {
-	symbol := Symbol{Name: name, Index: symbolTable.numDefinitions}
-	if symbolTable.Outer == nil {
+	scope := symbolTable
+	for scope.block {
+		scope = scope.Outer
+	}
+
+	symbol := Symbol{Name: name, Index: scope.numDefinitions}
+	if scope.Outer == nil {
 		symbol.SymbolScope = GlobalScope
 	} else {
 		symbol.SymbolScope = LocalScope
 	}
 	symbolTable.store[name] = symbol
-	symbolTable.numDefinitions++
+	scope.numDefinitions++
 
 	return symbol
 }
 
+// Defines reports whether name was defined in this table. Builtins and free
+// variables belong to enclosing scopes, so they can be shadowed.
+func (symbolTable *SymbolTable) Defines(name string) bool {
+	symbol, ok := symbolTable.store[name]
+	return ok && (symbol.SymbolScope == GlobalScope || symbol.SymbolScope == LocalScope)
+}
+
 func (symbolTable *SymbolTable) DefineBuiltin(index int, name string) {
 	symbol := Symbol{Name: name, Index: index, SymbolScope: BuiltinScope}
 	symbolTable.store[name] = symbol
@@ -58,6 +116,10 @@ func (symbolTable *SymbolTable) DefineBuiltin(index int, name string) {
 func (symbolTable *SymbolTable) Resolve(name string) (Symbol, bool) {
 	symbol, ok := symbolTable.store[name]
 
+	if !ok && symbolTable.block {
+		return symbolTable.Outer.Resolve(name)
+	}
+
 	if !ok && symbolTable.Outer != nil {
 		symbol, ok = symbolTable.Outer.Resolve(name)
 		if !ok {
@@ -143,3 +143,54 @@ func Test_SymbolTable_resolveFreeVars(t *testing.T) {
 		},
 	}, local2.FreeSymbols)
 }
+
+func Test_SymbolTable_Defines(t *testing.T) {
+	global := NewSymbolTable()
+	global.DefineBuiltin(0, "len")
+	global.Define("a")
+
+	local := NewEnclosedSymbolTable(global)
+	local.Define("b")
+	local.Resolve("a")
+
+	assert.True(t, global.Defines("a"))
+	assert.False(t, global.Defines("len"))
+	assert.True(t, local.Defines("b"))
+	assert.False(t, local.Defines("a"))
+}
+
+func Test_SymbolTable_Copy(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	copied := global.Copy()
+	b := copied.Define("b")
+
+	assert.Equal(t, Symbol{Name: "b", SymbolScope: GlobalScope, Index: 1}, b)
+	assert.True(t, copied.Defines("a"))
+	assert.False(t, global.Defines("b"))
+	assert.Equal(t, Symbol{Name: "b", SymbolScope: GlobalScope, Index: 1}, global.Define("b"))
+}
+
+func Test_SymbolTable_block(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	block := NewBlockSymbolTable(global)
+	assert.Equal(t, Symbol{Name: "a", SymbolScope: GlobalScope, Index: 1}, block.Define("a"))
+	assert.Equal(t, Symbol{Name: "b", SymbolScope: GlobalScope, Index: 2}, block.Define("b"))
+	assert.Equal(t, Symbol{Name: "c", SymbolScope: GlobalScope, Index: 3}, global.Define("c"))
+
+	a, _ := global.Resolve("a")
+	assert.Equal(t, Symbol{Name: "a", SymbolScope: GlobalScope, Index: 0}, a)
+	_, ok := global.Resolve("b")
+	assert.False(t, ok)
+
+	local := NewEnclosedSymbolTable(global)
+	local.Define("d")
+	localBlock := NewBlockSymbolTable(NewBlockSymbolTable(local))
+	assert.Equal(t, Symbol{Name: "e", SymbolScope: LocalScope, Index: 1}, localBlock.Define("e"))
+	d, _ := localBlock.Resolve("d")
+	assert.Equal(t, Symbol{Name: "d", SymbolScope: LocalScope, Index: 0}, d)
+	assert.Equal(t, 2, local.numDefinitions)
+}
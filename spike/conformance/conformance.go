@@ -0,0 +1,211 @@
+// Package conformance runs Spike programs through both the tree-walking
+// evaluator and the bytecode VM and reports where they disagree.
+package conformance
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/eval"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"spike-interpreter-go/spike/parser/ast"
+	"spike-interpreter-go/spike/vm"
+	"strings"
+)
+
+// Engine runs a parsed program and returns its result.
+type Engine struct {
+	Name string
+	Run  func(program *ast.Program) (object.Object, error)
+}
+
+var Eval = Engine{
+	Name: "eval",
+	Run: func(program *ast.Program) (object.Object, error) {
+		return eval.Eval(program, object.NewEnvironment())
+	},
+}
+
+// VM verifies the bytecode before running it, so that programs the verifier
+// rejects show up as divergences.
+var VM = vmEngine("vm", compiler.O0)
+
+// OptimizedVM runs bytecode optimized at the highest level.
+var OptimizedVM = vmEngine("vm-O2", compiler.O2)
+
+func vmEngine(name string, level compiler.OptimizationLevel) Engine {
+	return Engine{
+		Name: name,
+		Run: func(program *ast.Program) (object.Object, error) {
+			comp := compiler.New()
+			if err := comp.Compile(program); err != nil {
+				return nil, err
+			}
+
+			bytecode := compiler.Optimize(comp.Bytecode(), level)
+			if err := vm.Verify(bytecode); err != nil {
+				return nil, err
+			}
+
+			machine := vm.New(bytecode)
+			if err := machine.Run(); err != nil {
+				if runtimeError, ok := err.(*vm.RuntimeError); ok {
+					return nil, runtimeError.Err
+				}
+				return nil, err
+			}
+
+			return machine.LastPoppedStackElement(), nil
+		},
+	}
+}
+
+// Outcome is what running a program produced, normalized so that the
+// outcomes of different engines can be compared as strings.
+type Outcome struct {
+	Value string
+	Error string
+}
+
+func (outcome Outcome) String() string {
+	if outcome.Error != "" {
+		return "error: " + outcome.Error
+	}
+
+	return outcome.Value
+}
+
+// Execute runs program with engine, turning panics into errors.
+func Execute(engine Engine, program *ast.Program) (outcome Outcome) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			outcome = Outcome{Error: fmt.Sprintf("panic: %v", recovered)}
+		}
+	}()
+
+	result, err := engine.Run(program)
+	if err != nil {
+		return Outcome{Error: normalizeError(err)}
+	}
+
+	return Outcome{Value: Describe(result)}
+}
+
+var positionPrefix = regexp.MustCompile(`(?:[^\s:]+:)?\d+:\d+: `)
+
+// normalizeError drops source positions, which the engines attach to
+// different nodes.
+func normalizeError(err error) string {
+	return positionPrefix.ReplaceAllString(err.Error(), "")
+}
+
+// Describe renders obj like Inspect, except that functions, which the
+// engines represent by different objects, all render as "fn".
+func Describe(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return object.NullObject.Inspect()
+
+	case *object.Function, *object.Closure, *object.CompiledFunction, *object.BuiltinFunction:
+		return "fn"
+
+	case *object.Array:
+		return "[" + describeAll(obj.Elements) + "]"
+
+	case *object.Tuple:
+		if len(obj.Elements) == 1 {
+			return "(" + Describe(obj.Elements[0]) + ",)"
+		}
+		return "(" + describeAll(obj.Elements) + ")"
+
+	case *object.Hash:
+		pairs := make([]string, 0, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			pairs = append(pairs, Describe(pair.Key)+": "+Describe(pair.Value))
+		}
+		sort.Strings(pairs)
+
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+
+	return obj.Inspect()
+}
+
+func describeAll(objects []object.Object) string {
+	described := make([]string, len(objects))
+	for i, obj := range objects {
+		described[i] = Describe(obj)
+	}
+
+	return strings.Join(described, ", ")
+}
+
+// Divergence records a program for which the engines disagree.
+type Divergence struct {
+	Name     string
+	Program  string
+	Outcomes map[string]Outcome
+}
+
+func (divergence Divergence) String() string {
+	out := strings.Builder{}
+	out.WriteString(fmt.Sprintf("%s:\n", divergence.Name))
+	for _, line := range strings.Split(strings.TrimSpace(divergence.Program), "\n") {
+		out.WriteString("    " + line + "\n")
+	}
+
+	names := make([]string, 0, len(divergence.Outcomes))
+	for name := range divergence.Outcomes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		out.WriteString(fmt.Sprintf("  %-9s %s\n", name+":", divergence.Outcomes[name]))
+	}
+
+	return out.String()
+}
+
+// Compare runs program with every engine and returns a divergence when their
+// outcomes are not all the same.
+func Compare(name, source string, program *ast.Program, engines ...Engine) *Divergence {
+	outcomes := make(map[string]Outcome, len(engines))
+	diverged := false
+	for i, engine := range engines {
+		outcomes[engine.Name] = Execute(engine, program)
+		if i > 0 && outcomes[engine.Name] != outcomes[engines[0].Name] {
+			diverged = true
+		}
+	}
+
+	if !diverged {
+		return nil
+	}
+
+	return &Divergence{Name: name, Program: source, Outcomes: outcomes}
+}
+
+// Report formats divergences for humans, one program after another.
+func Report(divergences []Divergence) string {
+	if len(divergences) == 0 {
+		return "no divergences\n"
+	}
+
+	out := strings.Builder{}
+	out.WriteString(fmt.Sprintf("%d divergences\n\n", len(divergences)))
+	for _, divergence := range divergences {
+		out.WriteString(divergence.String())
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// Parse parses source as a Spike program.
+func Parse(source string) (*ast.Program, error) {
+	return parser.New(lexer.New(strings.NewReader(source))).ParseProgram()
+}
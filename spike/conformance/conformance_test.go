@@ -0,0 +1,96 @@
+package conformance
+
+import (
+	"flag"
+	"fmt"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser/ast"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Suite(t *testing.T) {
+	cases, err := LoadSuite("testdata")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, cases)
+
+	divergences := RunSuite(cases, Eval, VM, OptimizedVM)
+	if len(divergences) > 0 {
+		t.Error(Report(divergences))
+	}
+}
+
+func Test_ReadSuite(t *testing.T) {
+	cases, err := ReadSuite("sample.spike", strings.NewReader("\n1 + 1\n// expect: 2\n\nlet a = 1;\na / 0\n// error: division by zero\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Case{
+		{Name: "sample.spike:2", Source: "1 + 1\n", Expected: Outcome{Value: "2"}},
+		{Name: "sample.spike:5", Source: "let a = 1;\na / 0\n", Expected: Outcome{Error: "division by zero"}},
+	}, cases)
+}
+
+func Test_ReadSuite_withErrors(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{
+			input:         "// expect: 1\n",
+			expectedError: "sample.spike:1: expectation without a program",
+		},
+		{
+			input:         "1\n// expect: 1\n\n2\n",
+			expectedError: "sample.spike:4: program without an expectation",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			_, err := ReadSuite("sample.spike", strings.NewReader(testCase.input))
+
+			assert.EqualError(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_Compare(t *testing.T) {
+	program, err := Parse("[1, 2][5]")
+	assert.NoError(t, err)
+
+	assert.Nil(t, Compare("index", "[1, 2][5]", program, Eval, VM))
+
+	divergence := Compare("index", "[1, 2][5]", program, Eval, Engine{
+		Name: "strict",
+		Run: func(program *ast.Program) (object.Object, error) {
+			return nil, errors.New("index out of range")
+		},
+	})
+
+	assert.Equal(t, "index:\n    [1, 2][5]\n  eval:     null\n  strict:   error: index out of range\n", divergence.String())
+}
+
+var (
+	fuzzSeed       = flag.Int64("fuzz.seed", 1, "seed of the first program generated by Test_Fuzz")
+	fuzzIterations = flag.Int("fuzz.iterations", 2000, "number of programs generated by Test_Fuzz")
+)
+
+func Test_Fuzz(t *testing.T) {
+	var divergences []Divergence
+	for seed := *fuzzSeed; seed < *fuzzSeed+int64(*fuzzIterations); seed++ {
+		program := NewFuzzer(seed).Program()
+		name := fmt.Sprintf("seed %d", seed)
+
+		if divergence := Compare(name, program.String(), program, Eval, VM, OptimizedVM); divergence != nil {
+			divergences = append(divergences, *divergence)
+		}
+	}
+
+	if len(divergences) > 0 {
+		t.Error(Report(divergences))
+	}
+}
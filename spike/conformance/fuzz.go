@@ -0,0 +1,393 @@
+package conformance
+
+import (
+	"fmt"
+	"math/rand"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/parser/ast"
+)
+
+var (
+	infixOperators = []lexer.Token{
+		lexer.PlusToken,
+		lexer.MinusToken,
+		lexer.AsteriskToken,
+		lexer.SlashToken,
+		lexer.EqualToken,
+		lexer.NotEqualToken,
+		lexer.LessThanToken,
+		lexer.GreaterThanToken,
+		lexer.LessOrEqualToken,
+		lexer.GreaterOrEqualToken,
+		lexer.AndToken,
+		lexer.OrToken,
+	}
+	prefixOperators = []lexer.Token{lexer.MinusToken, lexer.BangToken}
+	integers        = []int64{0, 1, 2, 3, 7, 10, 9223372036854775807}
+	floats          = []float64{0.5, 1.5, 2.25, 3}
+	texts           = []string{"", "a", "bc", "spike"}
+	atoms           = []string{"ok", "error"}
+)
+
+// binding is a name visible to the generated code. Functions remember their
+// arity so that most calls to them pass the right number of arguments.
+type binding struct {
+	name  string
+	arity int
+}
+
+// Fuzzer generates random programs that parse and compile, but may well fail
+// at runtime, out of ast nodes. Programs never call a function recursively,
+// so they always terminate. Case patterns often bind names already bound,
+// which the clauses shadow.
+type Fuzzer struct {
+	MaxDepth int
+
+	random    *rand.Rand
+	scopes    [][]binding
+	names     int
+	functions int
+}
+
+func NewFuzzer(seed int64) *Fuzzer {
+	return &Fuzzer{MaxDepth: 3, random: rand.New(rand.NewSource(seed))}
+}
+
+// Program generates a few let statements followed by an expression.
+func (fuzzer *Fuzzer) Program() *ast.Program {
+	fuzzer.scopes = [][]binding{nil}
+	fuzzer.names = 0
+	fuzzer.functions = 0
+
+	program := &ast.Program{Statements: fuzzer.lets(fuzzer.MaxDepth)}
+	program.Statements = append(program.Statements, &ast.ExpressionStatement{
+		Expression: fuzzer.expression(fuzzer.MaxDepth),
+	})
+
+	return program
+}
+
+func (fuzzer *Fuzzer) lets(depth int) []ast.Statement {
+	statements := make([]ast.Statement, fuzzer.random.Intn(3))
+	for i := range statements {
+		value := fuzzer.expression(depth)
+		if fuzzer.random.Intn(3) == 0 {
+			value = fuzzer.function(depth)
+		}
+
+		name := fuzzer.bind(value)
+		statements[i] = &ast.LetStatement{
+			Token: lexer.LetToken,
+			Name:  identifier(name),
+			Value: value,
+		}
+	}
+
+	return statements
+}
+
+// bind makes value available under a fresh name in the innermost scope.
+func (fuzzer *Fuzzer) bind(value ast.Expression) string {
+	fuzzer.names++
+	name := fmt.Sprintf("v%d", fuzzer.names)
+
+	arity := -1
+	if function, ok := value.(*ast.FunctionExpression); ok {
+		arity = len(function.Parameters)
+	}
+
+	scope := len(fuzzer.scopes) - 1
+	fuzzer.scopes[scope] = append(fuzzer.scopes[scope], binding{name: name, arity: arity})
+
+	return name
+}
+
+// visible returns the bindings in scope, leaving out the ones shadowed by an
+// inner scope.
+func (fuzzer *Fuzzer) visible() []binding {
+	var bindings []binding
+	shadowed := map[string]bool{}
+	for i := len(fuzzer.scopes) - 1; i >= 0; i-- {
+		for _, binding := range fuzzer.scopes[i] {
+			if !shadowed[binding.name] {
+				shadowed[binding.name] = true
+				bindings = append(bindings, binding)
+			}
+		}
+	}
+
+	return bindings
+}
+
+func (fuzzer *Fuzzer) expression(depth int) ast.Expression {
+	if depth <= 0 {
+		return fuzzer.leaf()
+	}
+
+	switch fuzzer.random.Intn(13) {
+	case 0, 1:
+		return fuzzer.leaf()
+	case 2:
+		operator := prefixOperators[fuzzer.random.Intn(len(prefixOperators))]
+		return &ast.PrefixExpression{
+			Token:    operator,
+			Operator: operator.Literal,
+			Right:    fuzzer.expression(depth - 1),
+		}
+	case 3, 4, 5:
+		operator := infixOperators[fuzzer.random.Intn(len(infixOperators))]
+		return &ast.InfixExpression{
+			Token:    operator,
+			Left:     fuzzer.expression(depth - 1),
+			Operator: operator.Literal,
+			Right:    fuzzer.expression(depth - 1),
+		}
+	case 6:
+		return fuzzer.ifExpression(depth)
+	case 7:
+		return &ast.Array{Token: lexer.LeftBracketToken, Elements: fuzzer.expressions(depth - 1)}
+	case 8:
+		return &ast.Tuple{Token: lexer.LeftParenthesisToken, Elements: fuzzer.expressions(depth - 1)}
+	case 9:
+		return fuzzer.hash(depth)
+	case 10:
+		return &ast.IndexExpression{
+			Token: lexer.LeftBracketToken,
+			Array: fuzzer.expression(depth - 1),
+			Index: fuzzer.expression(depth - 1),
+		}
+	case 11:
+		return fuzzer.caseExpression(depth)
+	}
+
+	return fuzzer.call(depth)
+}
+
+func (fuzzer *Fuzzer) expressions(depth int) []ast.Expression {
+	expressions := make([]ast.Expression, fuzzer.random.Intn(4))
+	for i := range expressions {
+		expressions[i] = fuzzer.expression(depth)
+	}
+
+	return expressions
+}
+
+func (fuzzer *Fuzzer) leaf() ast.Expression {
+	bindings := fuzzer.visible()
+	if len(bindings) > 0 && fuzzer.random.Intn(3) == 0 {
+		return identifier(bindings[fuzzer.random.Intn(len(bindings))].name)
+	}
+
+	return fuzzer.literal()
+}
+
+func (fuzzer *Fuzzer) literal() ast.Expression {
+	switch fuzzer.random.Intn(5) {
+	case 0:
+		value := integers[fuzzer.random.Intn(len(integers))]
+		return &ast.Integer{Token: lexer.Token{Type: lexer.Integer, Literal: fmt.Sprint(value)}, Value: value}
+	case 1:
+		value := floats[fuzzer.random.Intn(len(floats))]
+		return &ast.Float{Token: lexer.Token{Type: lexer.Float, Literal: fmt.Sprint(value)}, Value: value}
+	case 2:
+		if fuzzer.random.Intn(2) == 0 {
+			return &ast.Boolean{Token: lexer.TrueToken, Value: true}
+		}
+		return &ast.Boolean{Token: lexer.FalseToken, Value: false}
+	case 3:
+		value := texts[fuzzer.random.Intn(len(texts))]
+		return &ast.String{Token: lexer.Token{Type: lexer.String, Literal: value}, Value: value}
+	}
+
+	name := atoms[fuzzer.random.Intn(len(atoms))]
+	return &ast.Atom{Token: lexer.ColonToken, Name: name}
+}
+
+func (fuzzer *Fuzzer) ifExpression(depth int) ast.Expression {
+	expression := &ast.IfExpression{
+		Token:     lexer.IfToken,
+		Condition: fuzzer.expression(depth - 1),
+		Then:      fuzzer.block(depth - 1),
+	}
+	if fuzzer.random.Intn(3) > 0 {
+		expression.Else = fuzzer.block(depth - 1)
+	}
+
+	return expression
+}
+
+func (fuzzer *Fuzzer) caseExpression(depth int) ast.Expression {
+	expression := &ast.CaseExpression{Token: lexer.CaseToken, Subject: fuzzer.expression(depth - 1)}
+	for i := fuzzer.random.Intn(3); i >= 0; i-- {
+		expression.Clauses = append(expression.Clauses, fuzzer.clause(depth-1))
+	}
+
+	return expression
+}
+
+// clause binds the names of its pattern in a scope of its own, visible to
+// its guard and body only.
+func (fuzzer *Fuzzer) clause(depth int) *ast.CaseClause {
+	fuzzer.scopes = append(fuzzer.scopes, nil)
+	defer func() {
+		fuzzer.scopes = fuzzer.scopes[:len(fuzzer.scopes)-1]
+	}()
+
+	clause := &ast.CaseClause{Pattern: fuzzer.pattern(depth)}
+	if fuzzer.random.Intn(4) == 0 {
+		clause.Guard = fuzzer.expression(depth)
+	}
+	clause.Body = fuzzer.block(depth)
+
+	return clause
+}
+
+func (fuzzer *Fuzzer) pattern(depth int) ast.Pattern {
+	switch choice := fuzzer.random.Intn(6); {
+	case depth > 0 && choice == 0:
+		return &ast.TuplePattern{Token: lexer.LeftParenthesisToken, Elements: fuzzer.patterns(depth - 1)}
+	case depth > 0 && choice == 1:
+		pattern := &ast.ArrayPattern{Token: lexer.LeftBracketToken, Elements: fuzzer.patterns(depth - 1)}
+		if fuzzer.random.Intn(2) == 0 {
+			pattern.Rest = fuzzer.pattern(0)
+		}
+		return pattern
+	case choice == 2:
+		return &ast.WildcardPattern{Token: lexer.Token{Type: lexer.Identifier, Literal: "_"}}
+	case choice == 3:
+		return &ast.LiteralPattern{Value: fuzzer.literal()}
+	}
+
+	return &ast.BindingPattern{Name: identifier(fuzzer.bindPattern())}
+}
+
+func (fuzzer *Fuzzer) patterns(depth int) []ast.Pattern {
+	patterns := make([]ast.Pattern, fuzzer.random.Intn(3))
+	for i := range patterns {
+		patterns[i] = fuzzer.pattern(depth)
+	}
+
+	return patterns
+}
+
+// bindPattern binds a name in the scope of a clause, reusing a name bound
+// outside of it half of the time. Names are bound once per pattern.
+func (fuzzer *Fuzzer) bindPattern() string {
+	scope := len(fuzzer.scopes) - 1
+	bound := map[string]bool{}
+	for _, binding := range fuzzer.scopes[scope] {
+		bound[binding.name] = true
+	}
+
+	var outer []string
+	for _, binding := range fuzzer.visible() {
+		if !bound[binding.name] {
+			outer = append(outer, binding.name)
+		}
+	}
+
+	if len(outer) == 0 || fuzzer.random.Intn(2) == 0 {
+		return fuzzer.bind(nil)
+	}
+
+	name := outer[fuzzer.random.Intn(len(outer))]
+	fuzzer.scopes[scope] = append(fuzzer.scopes[scope], binding{name: name, arity: -1})
+
+	return name
+}
+
+// hash uses distinct literal keys, as a hash literal with two equal keys has
+// no defined value.
+func (fuzzer *Fuzzer) hash(depth int) ast.Expression {
+	hash := &ast.Hash{Token: lexer.LeftBraceToken, Pairs: map[ast.Expression]ast.Expression{}}
+	seen := map[string]bool{}
+	for i := fuzzer.random.Intn(4); i > 0; i-- {
+		key := fuzzer.literal()
+		if seen[key.String()] {
+			continue
+		}
+		seen[key.String()] = true
+
+		hash.Pairs[key] = fuzzer.expression(depth - 1)
+	}
+
+	return hash
+}
+
+// block generates statements whose bindings are only visible inside it, as
+// the names a branch binds are not bound when the other branch runs.
+func (fuzzer *Fuzzer) block(depth int) *ast.BlockStatement {
+	fuzzer.scopes = append(fuzzer.scopes, nil)
+	defer func() {
+		fuzzer.scopes = fuzzer.scopes[:len(fuzzer.scopes)-1]
+	}()
+
+	statements := fuzzer.lets(depth)
+	result := fuzzer.expression(depth)
+	if fuzzer.random.Intn(5) == 0 && fuzzer.functions > 0 {
+		statements = append(statements, &ast.ReturnStatement{Token: lexer.ReturnToken, Result: result})
+	} else {
+		statements = append(statements, &ast.ExpressionStatement{Expression: result})
+	}
+
+	return &ast.BlockStatement{Token: lexer.LeftBraceToken, Statements: statements}
+}
+
+func (fuzzer *Fuzzer) function(depth int) *ast.FunctionExpression {
+	fuzzer.scopes = append(fuzzer.scopes, nil)
+	fuzzer.functions++
+	defer func() {
+		fuzzer.scopes = fuzzer.scopes[:len(fuzzer.scopes)-1]
+		fuzzer.functions--
+	}()
+
+	parameters := make([]*ast.Identifier, fuzzer.random.Intn(3))
+	for i := range parameters {
+		parameters[i] = identifier(fuzzer.bind(nil))
+	}
+
+	return &ast.FunctionExpression{
+		Token:      lexer.FnToken,
+		Parameters: parameters,
+		Body:       fuzzer.block(depth - 1),
+	}
+}
+
+// call calls a bound function, a function literal or a builtin, with the
+// right number of arguments most of the time.
+func (fuzzer *Fuzzer) call(depth int) ast.Expression {
+	var functions []binding
+	for _, binding := range fuzzer.visible() {
+		if binding.arity >= 0 {
+			functions = append(functions, binding)
+		}
+	}
+
+	var function ast.Expression
+	arity := 1
+	switch {
+	case len(functions) > 0 && fuzzer.random.Intn(2) == 0:
+		callee := functions[fuzzer.random.Intn(len(functions))]
+		function, arity = identifier(callee.name), callee.arity
+	case fuzzer.random.Intn(3) == 0:
+		function = identifier([]string{"len", "str"}[fuzzer.random.Intn(2)])
+	default:
+		literal := fuzzer.function(depth)
+		function, arity = literal, len(literal.Parameters)
+	}
+
+	if fuzzer.random.Intn(10) == 0 {
+		arity = fuzzer.random.Intn(3)
+	}
+
+	arguments := make([]ast.Expression, arity)
+	for i := range arguments {
+		arguments[i] = fuzzer.expression(depth - 1)
+	}
+
+	return &ast.CallExpression{Token: lexer.LeftParenthesisToken, Function: function, Arguments: arguments}
+}
+
+func identifier(name string) *ast.Identifier {
+	return &ast.Identifier{Token: lexer.Token{Type: lexer.Identifier, Literal: name}, Value: name}
+}
@@ -0,0 +1,138 @@
+package conformance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	expectValuePrefix = "// expect: "
+	expectErrorPrefix = "// error: "
+)
+
+// Case is a program of the conformance suite with the outcome every engine
+// must produce.
+type Case struct {
+	Name     string
+	Source   string
+	Expected Outcome
+}
+
+// ReadSuite reads cases from r. Each case is a program followed by a
+// "// expect: <value>" or "// error: <message>" line.
+func ReadSuite(name string, r io.Reader) ([]Case, error) {
+	var cases []Case
+
+	scanner := bufio.NewScanner(r)
+	source := strings.Builder{}
+	start := 1
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		var expected Outcome
+		switch {
+		case strings.HasPrefix(text, expectValuePrefix):
+			expected.Value = strings.TrimPrefix(text, expectValuePrefix)
+		case strings.HasPrefix(text, expectErrorPrefix):
+			expected.Error = strings.TrimPrefix(text, expectErrorPrefix)
+		default:
+			if source.Len() == 0 && strings.TrimSpace(text) == "" {
+				start = line + 1
+				continue
+			}
+			source.WriteString(text)
+			source.WriteString("\n")
+			continue
+		}
+
+		if strings.TrimSpace(source.String()) == "" {
+			return nil, errors.Errorf("%s:%d: expectation without a program", name, line)
+		}
+
+		cases = append(cases, Case{
+			Name:     fmt.Sprintf("%s:%d", name, start),
+			Source:   source.String(),
+			Expected: expected,
+		})
+		source.Reset()
+		start = line + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(source.String()) != "" {
+		return nil, errors.Errorf("%s:%d: program without an expectation", name, start)
+	}
+
+	return cases, nil
+}
+
+// LoadSuite reads the cases of every .spike file in directory.
+func LoadSuite(directory string) ([]Case, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, "*.spike"))
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []Case
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fileCases, err := ReadSuite(filepath.Base(path), file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		cases = append(cases, fileCases...)
+	}
+
+	return cases, nil
+}
+
+// RunSuite runs every case with every engine and returns the divergences,
+// counting an engine producing something other than the expected outcome as
+// diverging too.
+func RunSuite(cases []Case, engines ...Engine) []Divergence {
+	var divergences []Divergence
+	for _, testCase := range cases {
+		program, err := Parse(testCase.Source)
+		if err != nil {
+			divergences = append(divergences, Divergence{
+				Name:     testCase.Name,
+				Program:  testCase.Source,
+				Outcomes: map[string]Outcome{"parser": {Error: normalizeError(err)}},
+			})
+			continue
+		}
+
+		divergence := Divergence{
+			Name:     testCase.Name,
+			Program:  testCase.Source,
+			Outcomes: map[string]Outcome{"expected": testCase.Expected},
+		}
+		diverged := false
+		for _, engine := range engines {
+			outcome := Execute(engine, program)
+			divergence.Outcomes[engine.Name] = outcome
+			if outcome != testCase.Expected {
+				diverged = true
+			}
+		}
+
+		if diverged {
+			divergences = append(divergences, divergence)
+		}
+	}
+
+	return divergences
+}
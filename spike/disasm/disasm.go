@@ -0,0 +1,277 @@
+// Package disasm renders bytecode for humans.
+package disasm
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/object"
+	"strings"
+)
+
+const (
+	anonymousFunctionName = "<anonymous>"
+	// annotationColumn is where comments on instructions start.
+	annotationColumn = 32
+)
+
+// Bytecode disassembles the main program of bytecode followed by every
+// function in its constant pool, each function after the one it is defined
+// in. Operands referring to constants and builtins are annotated with them,
+// jump targets get labels and, when the bytecode has debug info, every
+// source line is shown before the instructions compiled from it, taking its
+// text from sources when they have the file. Malformed bytecode is reported
+// inline.
+func Bytecode(bytecode *compiler.Bytecode, sources map[string][]byte) string {
+	disassembler := &disassembler{
+		constants: bytecode.Constants,
+		sources:   sources,
+		printed:   map[int]bool{},
+	}
+
+	main := &object.CompiledFunction{
+		Instructions: bytecode.Instructions,
+		LineTable:    bytecode.LineTable,
+	}
+	disassembler.function(main, -1)
+
+	for i, constant := range bytecode.Constants {
+		if function, ok := constant.(*object.CompiledFunction); ok && !disassembler.printed[i] {
+			disassembler.function(function, i)
+		}
+	}
+
+	if len(bytecode.Constants) > 0 {
+		disassembler.out.WriteString("\nconstants:\n")
+		for i := range bytecode.Constants {
+			fmt.Fprintf(&disassembler.out, "    %d: %s\n", i, disassembler.describe(i))
+		}
+	}
+
+	return disassembler.out.String()
+}
+
+type disassembler struct {
+	constants []object.Object
+	sources   map[string][]byte
+	// printed holds the constant indexes of the functions already printed.
+	printed map[int]bool
+	out     bytes.Buffer
+}
+
+type instruction struct {
+	offset     int
+	opcode     code.Opcode
+	definition *code.Definition
+	operands   []int
+	err        error
+}
+
+// function prints function, then the functions it defines. index is the
+// constant index of function, -1 for the main program.
+func (disassembler *disassembler) function(function *object.CompiledFunction, index int) {
+	if index >= 0 {
+		disassembler.printed[index] = true
+		disassembler.out.WriteString("\n")
+	}
+
+	header := "main"
+	if index >= 0 {
+		header = fmt.Sprintf("function %s (constant %d)", functionName(function), index)
+	}
+	if function.Generator {
+		header += ", generator"
+	}
+	disassembler.out.WriteString(header + ":\n")
+
+	instructions := decode(function.Instructions)
+	labels := jumpLabels(instructions, len(function.Instructions))
+
+	var nested []int
+	lastLine := ""
+	for _, instruction := range instructions {
+		if span, ok := function.LineTable.Lookup(instruction.offset); ok {
+			if line := disassembler.sourceLine(span.Start.File, span.Start.Line); line != lastLine {
+				fmt.Fprintf(&disassembler.out, "    ; %s\n", line)
+				lastLine = line
+			}
+		}
+
+		if label, ok := labels[instruction.offset]; ok {
+			fmt.Fprintf(&disassembler.out, "%s:\n", label)
+		}
+
+		if instruction.err != nil {
+			fmt.Fprintf(&disassembler.out, "    %04d ERROR: %s\n", instruction.offset, instruction.err)
+			continue
+		}
+
+		text, annotation := disassembler.instruction(instruction, labels)
+		line := fmt.Sprintf("    %04d %s", instruction.offset, text)
+		if annotation != "" {
+			line = fmt.Sprintf("%-*s ; %s", annotationColumn-1, line, annotation)
+		}
+		disassembler.out.WriteString(line + "\n")
+
+		if instruction.opcode == code.OpClosure {
+			nested = append(nested, instruction.operands[0])
+		}
+	}
+
+	if label, ok := labels[len(function.Instructions)]; ok {
+		fmt.Fprintf(&disassembler.out, "%s:\n", label)
+	}
+
+	for _, index := range nested {
+		if function, ok := disassembler.constant(index).(*object.CompiledFunction); ok && !disassembler.printed[index] {
+			disassembler.function(function, index)
+		}
+	}
+}
+
+// instruction renders an instruction and the comment on its operands.
+func (disassembler *disassembler) instruction(instruction instruction, labels map[int]string) (string, string) {
+	definition, operands := instruction.definition, instruction.operands
+	text := definition.Name
+	for _, operand := range operands {
+		text += fmt.Sprintf(" %d", operand)
+	}
+
+	opcode := instruction.opcode
+	switch {
+	case code.IsJump(opcode):
+		if label, ok := labels[operands[0]]; ok {
+			return definition.Name + " " + label, ""
+		}
+		return text, fmt.Sprintf("ERROR: jump target %d is not an instruction", operands[0])
+
+	case opcode == code.OpConstant:
+		if disassembler.constant(operands[0]) == nil {
+			return text, fmt.Sprintf("ERROR: constant %d out of range", operands[0])
+		}
+		return text, disassembler.describe(operands[0])
+
+	case opcode == code.OpClosure:
+		function, ok := disassembler.constant(operands[0]).(*object.CompiledFunction)
+		if !ok {
+			return text, fmt.Sprintf("ERROR: constant %d is not a function", operands[0])
+		}
+		return text, fmt.Sprintf("fn %s, %d free variables", functionName(function), operands[1])
+
+	case opcode == code.OpGetBuiltin:
+		if operands[0] >= len(object.Builtins) {
+			return text, fmt.Sprintf("ERROR: builtin %d out of range", operands[0])
+		}
+		return text, object.Builtins[operands[0]].Name
+	}
+
+	return text, ""
+}
+
+func (disassembler *disassembler) constant(index int) object.Object {
+	if index < 0 || index >= len(disassembler.constants) {
+		return nil
+	}
+
+	return disassembler.constants[index]
+}
+
+func (disassembler *disassembler) describe(index int) string {
+	switch constant := disassembler.constant(index).(type) {
+	case nil:
+		return "<nil>"
+	case *object.CompiledFunction:
+		return "fn " + functionName(constant)
+	case *object.String:
+		// Inspect does not escape, a newline would break the listing.
+		return fmt.Sprintf("%q", constant.Value)
+	default:
+		return constant.Inspect()
+	}
+}
+
+// sourceLine renders a line of a file, with its text when sources have it.
+func (disassembler *disassembler) sourceLine(file string, line int) string {
+	location := fmt.Sprintf("%d", line)
+	if file != "" {
+		location = fmt.Sprintf("%s:%d", file, line)
+	}
+
+	source, ok := disassembler.sources[file]
+	if !ok {
+		return location
+	}
+
+	lines := strings.Split(string(source), "\n")
+	if line < 1 || line > len(lines) {
+		return location
+	}
+
+	return location + ": " + strings.TrimSpace(lines[line-1])
+}
+
+func functionName(function *object.CompiledFunction) string {
+	name := function.Name
+	if name == "" {
+		name = anonymousFunctionName
+	}
+
+	return fmt.Sprintf("%s/%d", name, function.ParametersCount)
+}
+
+// decode splits instructions, which may be malformed, into instructions.
+func decode(instructions code.Instructions) []instruction {
+	var decoded []instruction
+	for offset := 0; offset < len(instructions); {
+		definition, operands, length, err := instructions.ReadInstruction(offset)
+		decoded = append(decoded, instruction{
+			offset:     offset,
+			opcode:     code.Opcode(instructions[offset]),
+			definition: definition,
+			operands:   operands,
+			err:        err,
+		})
+		offset += length
+	}
+
+	return decoded
+}
+
+// jumpLabels names the targets of jumps L1, L2, ... in the order of their
+// offsets. Targets that are neither the offset of an instruction nor the end
+// of the instructions get no label.
+func jumpLabels(instructions []instruction, end int) map[int]string {
+	starts := map[int]bool{end: true}
+	for _, instruction := range instructions {
+		if instruction.err == nil {
+			starts[instruction.offset] = true
+		}
+	}
+
+	var targets []int
+	seen := map[int]bool{}
+	for _, instruction := range instructions {
+		if instruction.err != nil {
+			continue
+		}
+		if !code.IsJump(instruction.opcode) {
+			continue
+		}
+
+		target := instruction.operands[0]
+		if starts[target] && !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	sort.Ints(targets)
+
+	labels := make(map[int]string, len(targets))
+	for i, target := range targets {
+		labels[target] = fmt.Sprintf("L%d", i+1)
+	}
+
+	return labels
+}
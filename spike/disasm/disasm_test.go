@@ -0,0 +1,119 @@
+package disasm
+
+import (
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Bytecode(t *testing.T) {
+	source := "let twice = fn(f) { fn(x) { f(f(x)) } }\nif (true) { twice(len) } else { :none }"
+	program, err := parser.New(lexer.NewWithFileName(strings.NewReader(source), "main.spk")).ParseProgram()
+	assert.NoError(t, err)
+	comp := compiler.New()
+	assert.NoError(t, comp.Compile(program))
+
+	expected := `main:
+    ; main.spk:1: let twice = fn(f) { fn(x) { f(f(x)) } }
+    0000 OpClosure 1 0          ; fn twice/1, 0 free variables
+    0004 OpSetGlobal 0
+    ; main.spk:2: if (true) { twice(len) } else { :none }
+    0007 OpTrue
+    0008 OpJumpNotTrue L1
+    0011 OpGetGlobal 0
+    0014 OpGetBuiltin 0         ; len
+    0016 OpCall 1
+    0018 OpJump L2
+L1:
+    0021 OpConstant 2           ; :none
+L2:
+    0024 OpPop
+
+function twice/1 (constant 1):
+    ; main.spk:1: let twice = fn(f) { fn(x) { f(f(x)) } }
+    0000 OpGetLocal 0
+    0002 OpClosure 0 1          ; fn <anonymous>/1, 1 free variables
+    0006 OpReturnValue
+
+function <anonymous>/1 (constant 0):
+    ; main.spk:1: let twice = fn(f) { fn(x) { f(f(x)) } }
+    0000 OpGetFreeVar 0
+    0002 OpGetFreeVar 0
+    0004 OpGetLocal 0
+    0006 OpCall 1
+    0008 OpCall 1
+    0010 OpReturnValue
+
+constants:
+    0: fn <anonymous>/1
+    1: fn twice/1
+    2: :none
+`
+
+	assert.Equal(t, expected, Bytecode(comp.Bytecode(), map[string][]byte{"main.spk": []byte(source)}))
+
+	withoutSources := Bytecode(comp.Bytecode(), nil)
+	assert.Contains(t, withoutSources, "    ; main.spk:2\n    0007 OpTrue\n")
+}
+
+func Test_Bytecode_escapesStrings(t *testing.T) {
+	program, err := parser.New(lexer.New(strings.NewReader(`"a\nb"`))).ParseProgram()
+	assert.NoError(t, err)
+	comp := compiler.New()
+	assert.NoError(t, comp.Compile(program))
+
+	listing := Bytecode(comp.Bytecode(), nil)
+
+	assert.Contains(t, listing, "    0000 OpConstant 0           ; \"a\\nb\"\n")
+	assert.Contains(t, listing, "constants:\n    0: \"a\\nb\"\n")
+}
+
+func Test_Bytecode_malformed(t *testing.T) {
+	recursive := &object.CompiledFunction{
+		Name:            "loop",
+		Instructions:    code.NewBuilder().Make(code.OpClosure, 1, 0).Make(code.OpReturnValue).Build(),
+		ParametersCount: 0,
+	}
+	instructions := code.NewBuilder().
+		Make(code.OpConstant, 7).
+		Make(code.OpJump, 4).
+		Make(code.OpClosure, 0, 0).
+		Make(code.OpClosure, 1, 0).
+		Make(code.OpGetBuiltin, 200).
+		Make(code.OpJumpNotTrue, 22).
+		Build()
+	instructions = append(instructions, 255, byte(code.OpConstant), 1)
+
+	bytecode := &compiler.Bytecode{
+		Instructions: instructions,
+		Constants:    []object.Object{&object.Integer{Value: 1}, recursive},
+	}
+
+	expected := `main:
+    0000 OpConstant 7           ; ERROR: constant 7 out of range
+    0003 OpJump 4               ; ERROR: jump target 4 is not an instruction
+    0006 OpClosure 0 0          ; ERROR: constant 0 is not a function
+    0010 OpClosure 1 0          ; fn loop/0, 0 free variables
+    0014 OpGetBuiltin 200       ; ERROR: builtin 200 out of range
+    0016 OpJumpNotTrue L1
+    0019 ERROR: opcode 255 undefined
+    0020 ERROR: OpConstant truncated, expected 2 operand bytes, got 1
+L1:
+
+function loop/0 (constant 1):
+    0000 OpClosure 1 0          ; fn loop/0, 0 free variables
+    0004 OpReturnValue
+
+constants:
+    0: 1
+    1: fn loop/0
+`
+
+	assert.Equal(t, expected, Bytecode(bytecode, nil))
+}
@@ -8,4 +8,21 @@ var builtins = map[string]*object.BuiltinFunction{
 	"len":   object.GetBuiltinByName("len"),
 	"print": object.GetBuiltinByName("print"),
 	"read":  object.GetBuiltinByName("read"),
+	"str":   object.GetBuiltinByName("str"),
+	"int":   object.GetBuiltinByName("int"),
+	"float": object.GetBuiltinByName("float"),
+	"round": object.GetBuiltinByName("round"),
+	"spawn": object.GetBuiltinByName("spawn"),
+	"send":  object.GetBuiltinByName("send"),
+	"self":  object.GetBuiltinByName("self"),
+
+	"spawn_link": object.GetBuiltinByName("spawn_link"),
+	"monitor":    object.GetBuiltinByName("monitor"),
+	"supervisor": object.GetBuiltinByName("supervisor"),
+
+	"next": object.GetBuiltinByName("next"),
+	"done": object.GetBuiltinByName("done"),
+
+	object.ProcessBuiltinName:  object.GetBuiltinByName(object.ProcessBuiltinName),
+	object.ToStringBuiltinName: object.GetBuiltinByName(object.ToStringBuiltinName),
 }
@@ -0,0 +1,129 @@
+package eval
+
+import (
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser/ast"
+
+	"github.com/pkg/errors"
+)
+
+// evalCaseExpression evaluates the body of the first clause whose pattern
+// matches and whose guard holds. Each clause binds its variables in its own
+// environment, so bindings of a failed clause are discarded.
+func evalCaseExpression(node *ast.CaseExpression, environment *object.Environment) (object.Object, error) {
+	subject, err := Eval(node.Subject, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, clause := range node.Clauses {
+		clauseEnvironment := object.ExtendEnvironment(environment)
+
+		matched, err := matchPattern(clause.Pattern, subject, clauseEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		if clause.Guard != nil {
+			guard, err := Eval(clause.Guard, clauseEnvironment)
+			if err != nil {
+				return nil, err
+			}
+			guardBool, ok := guard.(*object.Boolean)
+			if !ok {
+				return nil, errors.Errorf("expected boolean, got %s", guard.Type())
+			}
+			if !guardBool.Value {
+				continue
+			}
+		}
+
+		return Eval(clause.Body, clauseEnvironment)
+	}
+
+	return nil, errors.Errorf("no clause matched: %s", subject.Inspect())
+}
+
+func matchPattern(pattern ast.Pattern, value object.Object, environment *object.Environment) (bool, error) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+
+	case *ast.BindingPattern:
+		environment.Set(pattern.Name.Value, value)
+		return true, nil
+
+	case *ast.LiteralPattern:
+		literal, err := Eval(pattern.Value, environment)
+		if err != nil {
+			return false, err
+		}
+		return literal.Equal(value), nil
+
+	case *ast.TuplePattern:
+		tuple, ok := value.(*object.Tuple)
+		if !ok || len(tuple.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+		return matchPatterns(pattern.Elements, tuple.Elements, environment)
+
+	case *ast.ArrayPattern:
+		array, ok := value.(*object.Array)
+		if !ok || len(array.Elements) < len(pattern.Elements) {
+			return false, nil
+		}
+		if pattern.Rest == nil && len(array.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+
+		matched, err := matchPatterns(pattern.Elements, array.Elements[:len(pattern.Elements)], environment)
+		if err != nil || !matched || pattern.Rest == nil {
+			return matched, err
+		}
+
+		rest := make([]object.Object, len(array.Elements)-len(pattern.Elements))
+		copy(rest, array.Elements[len(pattern.Elements):])
+		return matchPattern(pattern.Rest, &object.Array{Elements: rest}, environment)
+
+	case *ast.HashPattern:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+
+		for i, keyExpression := range pattern.Keys {
+			key, err := Eval(keyExpression, environment)
+			if err != nil {
+				return false, err
+			}
+
+			pair, ok := hash.Pairs[key.(object.Hashable).GetHashKey()]
+			if !ok {
+				return false, nil
+			}
+
+			matched, err := matchPattern(pattern.Values[i], pair.Value, environment)
+			if err != nil || !matched {
+				return matched, err
+			}
+		}
+
+		return true, nil
+	}
+
+	return false, errors.Errorf("unknown pattern: %T", pattern)
+}
+
+func matchPatterns(patterns []ast.Pattern, values []object.Object, environment *object.Environment) (bool, error) {
+	for i, pattern := range patterns {
+		matched, err := matchPattern(pattern, values[i], environment)
+		if err != nil || !matched {
+			return matched, err
+		}
+	}
+
+	return true, nil
+}
@@ -51,6 +51,78 @@ func Test_Eval_withErrors(t *testing.T) {
 			input:         "x;",
 			expectedError: "undefined identifier: x",
 		},
+		{
+			input:         "1 / 0",
+			expectedError: "division by zero",
+		},
+		{
+			input:         `int("abc")`,
+			expectedError: `unable to convert "abc" to integer`,
+		},
+		{
+			input:         `case (1, 2) of (1, 3) -> { 1 } end`,
+			expectedError: "no clause matched: (1, 2)",
+		},
+		{
+			input:         `process User { call get() { 1 } }; spawn User()`,
+			expectedError: "spawn is not supported by this engine",
+		},
+		{
+			input:         `receive x -> { x } end`,
+			expectedError: "receive is not supported by this engine",
+		},
+		{
+			input:         `spawn_link(fn() { 1 })`,
+			expectedError: "spawn_link is not supported by this engine",
+		},
+		{
+			input:         `self()`,
+			expectedError: "self is not supported by this engine",
+		},
+		{
+			input:         `let broken = fn() { yield 1 / 0 }; next(broken())`,
+			expectedError: "division by zero",
+		},
+		{
+			input:         `let x = 1; let f = fn(x) { let x = 2 }; f(3)`,
+			expectedError: "1:32: x is already defined in this scope",
+		},
+		{
+			input:         `1 && true`,
+			expectedError: "expected boolean, got integer",
+		},
+		{
+			input:         `false || "yes"`,
+			expectedError: "expected boolean, got string",
+		},
+		{
+			input:         `next(1)`,
+			expectedError: "unable to resume integer",
+		},
+		{
+			input:         `if (1) { 2 }`,
+			expectedError: "expected boolean, got integer",
+		},
+		{
+			input:         `let f = fn(a, b) { a }; f(1)`,
+			expectedError: "mismatched number of function call arguments. Expected 2, got 1",
+		},
+		{
+			input:         `1(2)`,
+			expectedError: "calling non-function integer",
+		},
+		{
+			input:         `let x = 1 + true; x`,
+			expectedError: "type mismatch: integer + boolean",
+		},
+		{
+			input:         `(1 / 0) < (1 + true)`,
+			expectedError: "division by zero",
+		},
+		{
+			input:         `{"a": 1}[[1]]`,
+			expectedError: "unusable as hash key: array",
+		},
 	}
 
 	for _, testCase := range testCases {
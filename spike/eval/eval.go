@@ -1,13 +1,38 @@
 package eval
 
 import (
+	"context"
+	"math/big"
+	"sort"
 	"spike-interpreter-go/spike/object"
 	"spike-interpreter-go/spike/parser/ast"
 
 	"github.com/pkg/errors"
 )
 
+// EvalWithLimits evaluates node in environment like Eval, stopping with an
+// object.InterruptedError once ctx is done, or with an
+// object.InstructionLimitError, object.CallDepthError or
+// object.MemoryLimitError when evaluation exceeds limits. Every expression
+// evaluated counts as an instruction. The limits apply to everything node
+// evaluates, including calls to functions defined before, but not to later
+// calls to the functions node defines. Bindings are made in environment.
+func EvalWithLimits(
+	ctx context.Context,
+	node ast.Node,
+	environment *object.Environment,
+	limits object.Limits,
+) (object.Object, error) {
+	return Eval(node, environment.WithMeter(object.NewMeter(ctx, limits)))
+}
+
 func Eval(node ast.Node, environment *object.Environment) (object.Object, error) {
+	if meter := environment.Meter(); meter != nil {
+		if err := meter.Step(); err != nil {
+			return nil, err
+		}
+	}
+
 	switch node := node.(type) {
 	case *ast.Program:
 		return evalProgram(node, environment)
@@ -15,6 +40,10 @@ func Eval(node ast.Node, environment *object.Environment) (object.Object, error)
 		return Eval(node.Expression, environment)
 	case *ast.Integer:
 		return &object.Integer{Value: node.Value}, nil
+	case *ast.BigInteger:
+		return object.NewInteger(new(big.Int).Set(node.Value)), nil
+	case *ast.Float:
+		return &object.Float{Value: node.Value}, nil
 	case *ast.Boolean:
 		return evalBoolean(node)
 	case *ast.Array:
@@ -30,80 +59,112 @@ func Eval(node ast.Node, environment *object.Environment) (object.Object, error)
 			array.Elements = append(array.Elements, evaluatedElement)
 		}
 
-		return array, nil
-
-	case *ast.Hash:
-		hash := &object.Hash{
-			Pairs: make(map[object.HashKey]object.HashPair, len(node.Pairs)),
+		return allocate(array, environment)
+	case *ast.CaseExpression:
+		return evalCaseExpression(node, environment)
+	case *ast.ReceiveExpression:
+		return nil, errors.New("receive is not supported by this engine")
+	case *ast.YieldExpression:
+		return evalYieldExpression(node, environment)
+	case *ast.Atom:
+		return object.NewAtom(node.Name), nil
+	case *ast.Tuple:
+		elements, err := evalExpressions(node.Elements, environment)
+		if err != nil {
+			return nil, err
 		}
 
-		for key, value := range node.Pairs {
-			evaluatedKey, err := Eval(key, environment)
-			if err != nil {
-				return nil, err
-			}
-			evalutedValue, err := Eval(value, environment)
-			if err != nil {
-				return nil, err
-			}
-
-			hashable, isHashable := evaluatedKey.(object.Hashable)
-			if !isHashable {
-				return nil, errors.Errorf("%s does not implement Hashable", evaluatedKey.Type())
-			}
+		return allocate(&object.Tuple{Elements: elements}, environment)
 
-			hash.Pairs[hashable.GetHashKey()] = object.HashPair{
-				Key:   evaluatedKey,
-				Value: evalutedValue,
-			}
+	case *ast.Hash:
+		hash, err := evalHash(node, environment)
+		if err != nil {
+			return nil, err
 		}
 
-		return hash, nil
+		return allocate(hash, environment)
 
 	case *ast.PrefixExpression:
 		right, err := Eval(node.Right, environment)
 		if err != nil {
 			return nil, err
 		}
-		return evalPrefixExpression(right, node.Operator)
+		result, err := evalPrefixExpression(right, node.Operator)
+		if err != nil {
+			return nil, err
+		}
+		return allocate(result, environment)
 	case *ast.InfixExpression:
 		left, err := Eval(node.Left, environment)
 		if err != nil {
 			return nil, err
 		}
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(left, node, environment)
+		}
 		right, err := Eval(node.Right, environment)
 		if err != nil {
 			return nil, err
 		}
 
-		return evalInfixExpression(left, right, node.Operator)
+		result, err := evalInfixExpression(left, right, node.Operator)
+		if err != nil {
+			return nil, err
+		}
+		return allocate(result, environment)
 	case *ast.IfExpression:
-		condition, _ := Eval(node.Condition, environment)
-		if condition.Equal(&object.True) {
+		condition, err := Eval(node.Condition, environment)
+		if err != nil {
+			return nil, err
+		}
+		conditionBool, ok := condition.(*object.Boolean)
+		if !ok {
+			return nil, errors.Errorf("expected boolean, got %s", condition.Type())
+		}
+
+		if conditionBool.Value {
 			return Eval(node.Then, environment)
-		} else {
+		} else if node.Else != nil {
 			return Eval(node.Else, environment)
 		}
+		return &object.NullObject, nil
 	case *ast.BlockStatement:
 		return evalStatements(node.Statements, environment)
 	case *ast.ReturnStatement:
-		result, _ := Eval(node.Result, environment)
-		return &object.Return{Value: result}, nil
+		result, err := Eval(node.Result, environment)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &returnSignal{value: result}
 	case *ast.LetStatement:
-		result, _ := Eval(node.Value, environment)
+		if environment.Defines(node.Name.Value) {
+			return nil, errors.Errorf("%s: %s is already defined in this scope", node.Name.Token.Span.Start, node.Name.Value)
+		}
+
+		result, err := Eval(node.Value, environment)
+		if err != nil {
+			return nil, err
+		}
 		environment.Set(node.Name.Value, result)
 	case *ast.Identifier:
 		return evalIdentifier(node.Value, environment)
 	case *ast.FunctionExpression:
-		return &object.Function{
+		return allocate(&object.Function{
 			Parameters:  node.Parameters,
 			Body:        node.Body,
 			Environment: environment,
-		}, nil
+			Generator:   node.Generator,
+		}, environment)
 	case *ast.CallExpression:
-		function, _ := Eval(node.Function, environment)
-		arguments, _ := evalExpressions(node.Arguments, environment)
-		return applyFunction(function, arguments)
+		function, err := Eval(node.Function, environment)
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := evalExpressions(node.Arguments, environment)
+		if err != nil {
+			return nil, err
+		}
+		return applyFunction(function, arguments, environment.Meter())
 	case *ast.String:
 		return &object.String{Value: node.Value}, nil
 	case *ast.IndexExpression:
@@ -116,54 +177,89 @@ func Eval(node ast.Node, environment *object.Environment) (object.Object, error)
 			return nil, err
 		}
 
-		switch evaluatedArray.(type) {
-		case *object.Array:
-			arrayObject := evaluatedArray.(*object.Array)
-			integerObject, ok := evaluatedIndex.(*object.Integer)
-			if !ok {
-				return nil, errors.New("only integer can be used as index")
-			}
-
-			return arrayObject.Elements[integerObject.Value], nil
-		case *object.Hash:
-			hashObject := evaluatedArray.(*object.Hash)
-			hashable, ok := evaluatedIndex.(object.Hashable)
-			if !ok {
-				return nil, errors.New("hash key must implement Hashable interface")
-			}
-
-			return hashObject.Get(hashable)
-		default:
-			return nil, errors.New("index can be used only on array")
-		}
+		return object.Index(evaluatedArray, evaluatedIndex)
 	default:
 		return nil, errors.Errorf("Trying to evaluate unknown node: %T: %#v", node, node)
 	}
 	return nil, nil
 }
 
-func applyFunction(function object.Object, arguments []object.Object) (object.Object, error) {
+// allocate counts value towards the memory limit of evaluation in
+// environment.
+func allocate(value object.Object, environment *object.Environment) (object.Object, error) {
+	meter := environment.Meter()
+	if meter == nil {
+		return value, nil
+	}
+
+	if err := meter.Allocate(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// returnSignal carries the value of a return statement up to the enclosing
+// function like an error, so that every expression on the way stops
+// evaluating, as it does in the VM.
+type returnSignal struct {
+	value object.Object
+}
+
+func (signal *returnSignal) Error() string {
+	return "return outside of a function"
+}
+
+// applyFunction calls function, counting the call and the value it creates
+// on meter, which may be nil. The body is evaluated with meter, the one of the
+// caller, rather than with the meter of the environment function was defined
+// in.
+func applyFunction(function object.Object, arguments []object.Object, meter *object.Meter) (object.Object, error) {
+	if meter != nil {
+		if err := meter.Enter(); err != nil {
+			return nil, err
+		}
+		defer meter.Leave()
+	}
+
 	if builtinFunction, ok := function.(*object.BuiltinFunction); ok {
-		return builtinFunction.Function(arguments...)
+		result, err := builtinFunction.Call(nil, arguments...)
+		if err != nil || meter == nil {
+			return result, err
+		}
+		return result, meter.Allocate(result)
 	}
 
 	functionObject, ok := function.(*object.Function)
 	if !ok {
-		return nil, nil
+		return nil, errors.Errorf("calling non-function %s", function.Type())
+	}
+	if len(functionObject.Parameters) != len(arguments) {
+		return nil, errors.Errorf(
+			"mismatched number of function call arguments. Expected %d, got %d",
+			len(functionObject.Parameters),
+			len(arguments),
+		)
 	}
 
-	extendedEnvironment := object.ExtendEnvironment(functionObject.Environment)
+	extendedEnvironment := object.ExtendEnvironment(functionObject.Environment).WithMeter(meter)
 	for i, identifier := range functionObject.Parameters {
 		extendedEnvironment.Set(identifier.Value, arguments[i])
 	}
 
+	if functionObject.Generator {
+		return allocate(newGenerator(functionObject.Body, extendedEnvironment), extendedEnvironment)
+	}
+
 	result, err := Eval(functionObject.Body, extendedEnvironment)
+	if signal, ok := err.(*returnSignal); ok {
+		return signal.value, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if returnValue, ok := result.(*object.Return); ok {
-		return returnValue.Value, nil
+	if result == nil {
+		return &object.NullObject, nil
 	}
 
 	return result, nil
@@ -174,13 +270,12 @@ func evalProgram(program *ast.Program, environment *object.Environment) (object.
 	var err error
 	for _, statement := range program.Statements {
 		result, err = Eval(statement, environment)
+		if signal, ok := err.(*returnSignal); ok {
+			return signal.value, nil
+		}
 		if err != nil {
 			return nil, err
 		}
-
-		if returnValue, ok := result.(*object.Return); ok {
-			return returnValue.Value, nil
-		}
 	}
 
 	return result, err
@@ -194,10 +289,6 @@ func evalStatements(statements []ast.Statement, environment *object.Environment)
 		if err != nil {
 			return nil, err
 		}
-
-		if _, ok := result.(*object.Return); ok {
-			return result, nil
-		}
 	}
 
 	return result, err
@@ -207,13 +298,39 @@ func evalExpressions(expressions []ast.Expression, environment *object.Environme
 	result := make([]object.Object, 0)
 
 	for _, expression := range expressions {
-		evaluated, _ := Eval(expression, environment)
+		evaluated, err := Eval(expression, environment)
+		if err != nil {
+			return nil, err
+		}
 		result = append(result, evaluated)
 	}
 
 	return result, nil
 }
 
+// evalLogicalExpression evaluates the right operand of && and || only when
+// left does not decide the result. Both operands must be booleans.
+func evalLogicalExpression(left object.Object, node *ast.InfixExpression, environment *object.Environment) (object.Object, error) {
+	leftBool, ok := left.(*object.Boolean)
+	if !ok {
+		return nil, errors.Errorf("expected boolean, got %s", left.Type())
+	}
+	if leftBool.Value == (node.Operator == "||") {
+		return nativeBoolToBoolean(leftBool.Value), nil
+	}
+
+	right, err := Eval(node.Right, environment)
+	if err != nil {
+		return nil, err
+	}
+	rightBool, ok := right.(*object.Boolean)
+	if !ok {
+		return nil, errors.Errorf("expected boolean, got %s", right.Type())
+	}
+
+	return nativeBoolToBoolean(rightBool.Value), nil
+}
+
 func evalBoolean(node *ast.Boolean) (object.Object, error) {
 	if node.Value {
 		return &object.True, nil
@@ -246,8 +363,10 @@ func evalBangOperator(right object.Object) (object.Object, error) {
 
 func evalMinusOperator(right object.Object) (object.Object, error) {
 	switch rightObject := right.(type) {
-	case *object.Integer:
-		return &object.Integer{Value: -rightObject.Value}, nil
+	case *object.Integer, *object.BigInteger:
+		return object.NegateInteger(rightObject), nil
+	case *object.Float:
+		return &object.Float{Value: -rightObject.Value}, nil
 	default:
 		return nil, errors.Errorf("type mismatch: -%s", right.Type())
 	}
@@ -263,82 +382,93 @@ func evalInfixExpression(left, right object.Object, operator string) (object.Obj
 		return evalAsteriskInfixOperator(left, right)
 	case "/":
 		return evalAsteriskSlashOperator(left, right)
-	case "==":
-		equal := left.Equal(right)
-		return nativeBoolToBoolean(equal), nil
-	case "!=":
-		equal := left.Equal(right)
-		return nativeBoolToBoolean(!equal), nil
-	case "<":
-		leftComparable := left.(object.Comparable)
-		rightComparable := right.(object.Comparable)
-		result, err := leftComparable.Compare(rightComparable)
-		return nativeBoolToBoolean(result == object.LT), err
-	case ">":
-		leftComparable := left.(object.Comparable)
-		rightComparable := right.(object.Comparable)
-		result, err := leftComparable.Compare(rightComparable)
-		return nativeBoolToBoolean(result == object.GT), err
-	case "<=":
-		leftComparable := left.(object.Comparable)
-		rightComparable := right.(object.Comparable)
-		result, err := leftComparable.Compare(rightComparable)
-		return nativeBoolToBoolean(result == object.LT || result == object.EQ), err
-	case ">=":
-		leftComparable := left.(object.Comparable)
-		rightComparable := right.(object.Comparable)
-		result, err := leftComparable.Compare(rightComparable)
-		return nativeBoolToBoolean(result == object.GT || result == object.EQ), err
-	case "||":
-		leftBool := left.(*object.Boolean)
-		rightBool := right.(*object.Boolean)
-		return nativeBoolToBoolean(leftBool.Value || rightBool.Value), nil
-	case "&&":
-		leftBool := left.(*object.Boolean)
-		rightBool := right.(*object.Boolean)
-		return nativeBoolToBoolean(leftBool.Value && rightBool.Value), nil
-
+	case "==", "!=", "<", ">", "<=", ">=":
+		result, err := object.CompareObjects(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return nativeBoolToBoolean(result), nil
 	default:
-		return nil, nil
+		return nil, errors.Errorf("unknown operator: %s", operator)
 	}
 }
 
 func evalPlusInfixOperator(left, right object.Object) (object.Object, error) {
-	if left.Type() == object.IntegerType && right.Type() == object.IntegerType {
-		newValue := left.(*object.Integer).Value + right.(*object.Integer).Value
-		return &object.Integer{Value: newValue}, nil
+	if object.IsInteger(left) && object.IsInteger(right) {
+		return object.IntegerOperation("+", left, right)
+	}
+
+	if left.Type() == object.StringType && right.Type() == object.StringType {
+		newValue := left.(*object.String).Value + right.(*object.String).Value
+		return &object.String{Value: newValue}, nil
+	}
+
+	if result, ok := evalFloatInfixOperator(left, right, "+"); ok {
+		return result, nil
 	}
 
 	return nil, errors.Errorf("type mismatch: %s + %s", left.Type(), right.Type())
 }
 
 func evalMinusInfixOperator(left, right object.Object) (object.Object, error) {
-	if left.Type() == object.IntegerType && right.Type() == object.IntegerType {
-		newValue := left.(*object.Integer).Value - right.(*object.Integer).Value
-		return &object.Integer{Value: newValue}, nil
+	if object.IsInteger(left) && object.IsInteger(right) {
+		return object.IntegerOperation("-", left, right)
+	}
+
+	if result, ok := evalFloatInfixOperator(left, right, "-"); ok {
+		return result, nil
 	}
 
 	return nil, errors.Errorf("type mismatch: %s - %s", left.Type(), right.Type())
 }
 
 func evalAsteriskInfixOperator(left, right object.Object) (object.Object, error) {
-	if left.Type() == object.IntegerType && right.Type() == object.IntegerType {
-		newValue := left.(*object.Integer).Value * right.(*object.Integer).Value
-		return &object.Integer{Value: newValue}, nil
+	if object.IsInteger(left) && object.IsInteger(right) {
+		return object.IntegerOperation("*", left, right)
+	}
+
+	if result, ok := evalFloatInfixOperator(left, right, "*"); ok {
+		return result, nil
 	}
 
 	return nil, errors.Errorf("type mismatch: %s * %s", left.Type(), right.Type())
 }
 
 func evalAsteriskSlashOperator(left, right object.Object) (object.Object, error) {
-	if left.Type() == object.IntegerType && right.Type() == object.IntegerType {
-		newValue := left.(*object.Integer).Value / right.(*object.Integer).Value
-		return &object.Integer{Value: newValue}, nil
+	if object.IsInteger(left) && object.IsInteger(right) {
+		return object.IntegerOperation("/", left, right)
+	}
+
+	if result, ok := evalFloatInfixOperator(left, right, "/"); ok {
+		return result, nil
 	}
 
 	return nil, errors.Errorf("type mismatch: %s / %s", left.Type(), right.Type())
 }
 
+// evalFloatInfixOperator handles arithmetic where at least one operand is a
+// float; integers are converted to floats.
+func evalFloatInfixOperator(left, right object.Object, operator string) (object.Object, bool) {
+	leftValue, leftIsNumber := object.ToFloat(left)
+	rightValue, rightIsNumber := object.ToFloat(right)
+	if !leftIsNumber || !rightIsNumber {
+		return nil, false
+	}
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftValue + rightValue}, true
+	case "-":
+		return &object.Float{Value: leftValue - rightValue}, true
+	case "*":
+		return &object.Float{Value: leftValue * rightValue}, true
+	case "/":
+		return &object.Float{Value: leftValue / rightValue}, true
+	}
+
+	return nil, false
+}
+
 func nativeBoolToBoolean(b bool) *object.Boolean {
 	if b {
 		return &object.True
@@ -359,3 +489,41 @@ func evalIdentifier(name string, environment *object.Environment) (object.Object
 
 	return nil, err
 }
+
+// evalHash evaluates the pairs in the order the compiler emits them and only
+// then checks the keys, so that both engines fail on the same pair.
+func evalHash(node *ast.Hash, environment *object.Environment) (object.Object, error) {
+	keys := make([]ast.Expression, 0, len(node.Pairs))
+	for key := range node.Pairs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	pairs := make([]object.HashPair, 0, len(keys))
+	for _, key := range keys {
+		evaluatedKey, err := Eval(key, environment)
+		if err != nil {
+			return nil, err
+		}
+		evaluatedValue, err := Eval(node.Pairs[key], environment)
+		if err != nil {
+			return nil, err
+		}
+
+		pairs = append(pairs, object.HashPair{Key: evaluatedKey, Value: evaluatedValue})
+	}
+
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(pairs))}
+	for _, pair := range pairs {
+		hashKey, err := object.HashKeyOf(pair.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		hash.Pairs[hashKey] = pair
+	}
+
+	return hash, nil
+}
@@ -140,6 +140,10 @@ func Test_Eval_program(t *testing.T) {
 			input:    "(2 > 3) || (true != false)",
 			expected: &object.True,
 		},
+		{
+			input:    "[false && 1 / 0 == 0, true || 1 / 0 == 0, false || true, true && true]",
+			expected: &object.Array{Elements: []object.Object{&object.False, &object.True, &object.True, &object.True}},
+		},
 		{
 			input:    "if (2 > 3) { 10; } else { 11; }",
 			expected: &object.Integer{Value: 11},
@@ -236,6 +240,188 @@ func Test_Eval_program(t *testing.T) {
 			input:    `{"key1": "val1", "key2": "val2"}["key2"]`,
 			expected: &object.String{Value: "val2"},
 		},
+		{
+			input:    `"a\tb\n" + "c"`,
+			expected: &object.String{Value: "a\tb\nc"},
+		},
+		{
+			input:    `let name = "Spike"; let n = 2; "Hello ${name}, ${n + 1} times"`,
+			expected: &object.String{Value: "Hello Spike, 3 times"},
+		},
+		{
+			input:    `"${[1, "a"]}"`,
+			expected: &object.String{Value: `[1, "a"]`},
+		},
+		{
+			input:    "`raw ${x}\\n`",
+			expected: &object.String{Value: "raw ${x}\\n"},
+		},
+		{
+			input:    "1.5 + 2",
+			expected: &object.Float{Value: 3.5},
+		},
+		{
+			input:    "(10 - 2.5) * 2 / 4",
+			expected: &object.Float{Value: 3.75},
+		},
+		{
+			input:    "-1.5 < 1",
+			expected: &object.True,
+		},
+		{
+			input:    "2.0 == 2",
+			expected: &object.True,
+		},
+		{
+			input: `[int(2.9), int("-4"), float(3), round(2.5), round(3.14159, 2)]`,
+			expected: &object.Array{Elements: []object.Object{
+				&object.Integer{Value: 2},
+				&object.Integer{Value: -4},
+				&object.Float{Value: 3},
+				&object.Integer{Value: 3},
+				&object.Float{Value: 3.14},
+			}},
+		},
+		{
+			input:    `let factorial = fn(n) { if (n == 1) { 1 } else { n * factorial(n - 1) } }; str(factorial(25))`,
+			expected: &object.String{Value: "15511210043330985984000000"},
+		},
+		{
+			input:    "-(-9223372036854775807 - 1) == 9223372036854775807 + 1",
+			expected: &object.True,
+		},
+		{
+			input: `let Person = (123, "Lukasz"); [Person, Person[1], len(Person), (1,), ()]`,
+			expected: &object.Array{Elements: []object.Object{
+				&object.Tuple{Elements: []object.Object{
+					&object.Integer{Value: 123},
+					&object.String{Value: "Lukasz"},
+				}},
+				&object.String{Value: "Lukasz"},
+				&object.Integer{Value: 2},
+				&object.Tuple{Elements: []object.Object{&object.Integer{Value: 1}}},
+				&object.Tuple{Elements: []object.Object{}},
+			}},
+		},
+		{
+			input:    `let Place = (:place, 222, "Krakow"); Place[0] == :place`,
+			expected: &object.True,
+		},
+		{
+			input:    `{:name: "kenny"}[:name]`,
+			expected: &object.String{Value: "kenny"},
+		},
+		{
+			input:    ":person",
+			expected: object.NewAtom("person"),
+		},
+		{
+			input: `
+			let Person = (:person, 123, "Lukasz");
+			case Person of
+				(:person, Id, Name) -> { "Person: " + Name }
+				(:place, Id, Name) -> { "Place: " + Name }
+			end`,
+			expected: &object.String{Value: "Person: Lukasz"},
+		},
+		{
+			input: `
+			let classify = fn(n) {
+				case n of
+					0 -> { "zero" }
+					x when x < 0 -> { "negative" }
+					_ -> { "positive" }
+				end
+			};
+			[classify(0), classify(-5), classify(7)]`,
+			expected: &object.Array{Elements: []object.Object{
+				&object.String{Value: "zero"},
+				&object.String{Value: "negative"},
+				&object.String{Value: "positive"},
+			}},
+		},
+		{
+			input:    `let sum = fn(xs) { case xs of [] -> { 0 } [head, ...tail] -> { head + sum(tail) } end }; sum([1, 2, 3, 4])`,
+			expected: &object.Integer{Value: 10},
+		},
+		{
+			input:    `case {"name": "kenny", "age": 31} of {"name": N, "age": 30} -> { 1 } {"name": N} -> { N } end`,
+			expected: &object.String{Value: "kenny"},
+		},
+		{
+			input:    `case 2.0 of "2" -> { :string } -2 -> { :negative } 2 -> { :number } end`,
+			expected: object.NewAtom("number"),
+		},
+		{
+			input:    `case (1, [2, (3, 4)]) of (a, [b, (c, d)]) -> { a + b + c + d } end`,
+			expected: &object.Integer{Value: 10},
+		},
+		{
+			input: `case [1, 2, 3] of [_, ...rest] when len(rest) > 5 -> { 0 } [_, ...rest] -> { rest } end`,
+			expected: &object.Array{Elements: []object.Object{
+				&object.Integer{Value: 2},
+				&object.Integer{Value: 3},
+			}},
+		},
+		{
+			input:    "(1, 2) < (1, 3)",
+			expected: &object.True,
+		},
+		{
+			input:    `{(1, "a"): "first"}[(1, "a")]`,
+			expected: &object.String{Value: "first"},
+		},
+		{
+			input:    "(9223372036854775807 + 1) - 1",
+			expected: &object.Integer{Value: 9223372036854775807},
+		},
+		{
+			input:    `let h = {9223372036854775807 + 1: "big"}; h[9223372036854775807 * 2 / 2 + 1]`,
+			expected: &object.String{Value: "big"},
+		},
+		{
+			input:    `let x = 1; let f = fn() { let x = 2; x }; [x, f()]`,
+			expected: &object.Array{Elements: []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}}},
+		},
+		{
+			input: `let numbers = fn() { yield 1; yield 2; 3 }; let g = numbers(); [next(g), next(g), done(g), next(g), done(g), next(g)]`,
+			expected: &object.Array{Elements: []object.Object{
+				&object.Integer{Value: 1},
+				&object.Integer{Value: 2},
+				&object.False,
+				&object.Integer{Value: 3},
+				&object.True,
+				&object.NullObject,
+			}},
+		},
+		{
+			input: `let echo = fn() { let a = yield :ready; let b = yield a * 2; a + b }; let g = echo(); [next(g), next(g, 10), next(g, 5)]`,
+			expected: &object.Array{Elements: []object.Object{
+				object.NewAtom("ready"),
+				&object.Integer{Value: 20},
+				&object.Integer{Value: 15},
+			}},
+		},
+		{
+			input:    "if (false) { 1 }",
+			expected: &object.NullObject,
+		},
+		{
+			input:    "[1, 2][5]",
+			expected: &object.NullObject,
+		},
+		{
+			input:    "let f = fn(x) { [1, if (x) { return :early } else { 2 }] }; f(true)",
+			expected: object.NewAtom("early"),
+		},
+		{
+			input: `let naturals = fn(n) { yield n; naturals(n + 1) }; let g = naturals(5); [next(g), next(g), next(g)]`,
+			expected: &object.Array{Elements: []object.Object{
+				&object.Integer{Value: 5},
+				&object.Integer{Value: 6},
+				&object.Integer{Value: 7},
+			}},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -0,0 +1,133 @@
+package eval
+
+import (
+	"runtime"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser/ast"
+
+	"github.com/pkg/errors"
+)
+
+// yielderName binds the yielder of a generator in the environment of its
+// body. It is not a valid identifier, so code can not refer to it.
+const yielderName = "$yielder"
+
+const yielderType object.ObjectType = "yielder"
+
+// step is what a generator body hands to next: a yielded value, or its result
+// once finished.
+type step struct {
+	value    object.Object
+	finished bool
+	err      error
+}
+
+// errStopped unwinds the body of a generator that was dropped unfinished.
+var errStopped = errors.New("generator stopped")
+
+// yielder runs the body of a generator on a goroutine of its own, taking
+// turns with the callers of next. Closing sent makes the yield the body waits
+// at return errStopped, which ends the goroutine.
+type yielder struct {
+	sent    chan object.Object
+	steps   chan step
+	started bool
+}
+
+// resumer resumes a yielder. Only the generators running the body refer to
+// it, while the goroutine of the body keeps the yielder alive, so its
+// finalizer stops the body once nothing can resume it anymore.
+type resumer struct {
+	yielder *yielder
+}
+
+func (yielder *yielder) Type() object.ObjectType {
+	return yielderType
+}
+
+func (yielder *yielder) Inspect() string {
+	return string(yielderType)
+}
+
+func (yielder *yielder) Equal(other object.Object) bool {
+	return yielder == other
+}
+
+// newGenerator returns a generator running body in environment, which holds
+// the arguments of the call. Dropping the generator unfinished stops its body.
+func newGenerator(body ast.Statement, environment *object.Environment) *object.Generator {
+	yielder := &yielder{sent: make(chan object.Object), steps: make(chan step)}
+	environment.Set(yielderName, yielder)
+
+	handle := &resumer{yielder: yielder}
+	runtime.SetFinalizer(handle, (*resumer).stop)
+
+	return object.NewGenerator("", func(sent object.Object) (object.Object, bool, error) {
+		return handle.resume(body, environment, sent)
+	})
+}
+
+func (resumer *resumer) resume(body ast.Statement, environment *object.Environment, sent object.Object) (object.Object, bool, error) {
+	yielder := resumer.yielder
+	if yielder.started {
+		yielder.sent <- sent
+	} else {
+		yielder.started = true
+		go yielder.run(body, environment)
+	}
+
+	step := <-yielder.steps
+	return step.value, step.finished, step.err
+}
+
+// stop closes sent. A started body is waiting in yield or has finished, so
+// closing is safe and leaves no goroutine behind.
+func (resumer *resumer) stop() {
+	close(resumer.yielder.sent)
+}
+
+func (yielder *yielder) run(body ast.Statement, environment *object.Environment) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			yielder.steps <- step{finished: true, err: errors.Errorf("generator crashed: %v", recovered)}
+		}
+	}()
+
+	result, err := Eval(body, environment)
+	if errors.Cause(err) == errStopped {
+		return
+	}
+	if signal, ok := err.(*returnSignal); ok {
+		result, err = signal.value, nil
+	}
+	if result == nil && err == nil {
+		result = &object.NullObject
+	}
+
+	yielder.steps <- step{value: result, finished: true, err: err}
+}
+
+func evalYieldExpression(node *ast.YieldExpression, environment *object.Environment) (object.Object, error) {
+	var value object.Object = &object.NullObject
+	if node.Value != nil {
+		var err error
+		value, err = Eval(node.Value, environment)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	binding, err := environment.Get(yielderName)
+	if err != nil {
+		return nil, errors.New("yield outside of a generator")
+	}
+	yielder := binding.(*yielder)
+
+	yielder.steps <- step{value: value}
+	sent, ok := <-yielder.sent
+	if !ok {
+		return nil, errStopped
+	}
+
+	return sent, nil
+}
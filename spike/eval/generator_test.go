@@ -0,0 +1,31 @@
+package eval
+
+import (
+	"runtime"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Eval_droppedGeneratorsStop(t *testing.T) {
+	input := `let numbers = fn() { yield 1; yield 2 }; let first = fn() { next(numbers()) }; [first(), first(), first()]`
+	program, err := parser.New(lexer.New(strings.NewReader(input))).ParseProgram()
+	assert.NoError(t, err)
+
+	goroutines := runtime.NumGoroutine()
+	result, err := Eval(program, object.NewEnvironment())
+	assert.NoError(t, err)
+	assert.Equal(t, "[1, 1, 1]", result.Inspect())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, goroutines, runtime.NumGoroutine())
+}
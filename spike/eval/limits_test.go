@@ -0,0 +1,106 @@
+package eval
+
+import (
+	"context"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EvalWithLimits(t *testing.T) {
+	countdown := `let countdown = fn(n) { if (n == 0) { :done } else { countdown(n - 1) } }; `
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		input         string
+		context       context.Context
+		limits        object.Limits
+		expectedError error
+	}{
+		{
+			input:         countdown + "countdown(10)",
+			limits:        object.Limits{MaxCallDepth: 10},
+			expectedError: &object.CallDepthError{Limit: 10},
+		},
+		{
+			input:  countdown + "countdown(9)",
+			limits: object.Limits{MaxCallDepth: 10},
+		},
+		{
+			input:         "let loop = fn() { loop() }; loop()",
+			expectedError: &object.CallDepthError{Limit: object.DefaultMaxCallDepth},
+		},
+		{
+			input:         countdown + "countdown(100)",
+			limits:        object.Limits{MaxInstructions: 100},
+			expectedError: &object.InstructionLimitError{Limit: 100},
+		},
+		{
+			input:         `let double = fn(s) { double(s + s) }; double("spike")`,
+			limits:        object.Limits{MaxMemory: 1 << 20, MaxCallDepth: 100},
+			expectedError: &object.MemoryLimitError{Limit: 1 << 20},
+		},
+		{
+			input:         ":done",
+			context:       cancelled,
+			expectedError: &object.InterruptedError{Err: context.Canceled},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			program, err := parser.New(lexer.New(strings.NewReader(testCase.input))).ParseProgram()
+			assert.NoError(t, err)
+
+			ctx := testCase.context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			result, err := EvalWithLimits(ctx, program, object.NewEnvironment(), testCase.limits)
+			if testCase.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, object.NewAtom("done"), result)
+				return
+			}
+
+			assert.Equal(t, testCase.expectedError, err)
+		})
+	}
+}
+
+func Test_EvalWithLimits_bindings(t *testing.T) {
+	program, err := parser.New(lexer.New(strings.NewReader("let x = 1"))).ParseProgram()
+	assert.NoError(t, err)
+
+	environment := object.NewEnvironment()
+	_, err = EvalWithLimits(context.Background(), program, environment, object.Limits{})
+	assert.NoError(t, err)
+
+	x, err := environment.Get("x")
+	assert.NoError(t, err)
+	assert.Equal(t, &object.Integer{Value: 1}, x)
+	assert.Nil(t, environment.Meter())
+}
+
+func Test_EvalWithLimits_functionsDefinedBefore(t *testing.T) {
+	environment := object.NewEnvironment()
+	definition, err := parser.New(lexer.New(strings.NewReader("let loop = fn(n) { n + loop(n) }"))).ParseProgram()
+	assert.NoError(t, err)
+	_, err = Eval(definition, environment)
+	assert.NoError(t, err)
+
+	call, err := parser.New(lexer.New(strings.NewReader("loop(1)"))).ParseProgram()
+	assert.NoError(t, err)
+
+	_, err = EvalWithLimits(context.Background(), call, environment, object.Limits{MaxInstructions: 1000, MaxCallDepth: 10})
+	assert.Equal(t, &object.CallDepthError{Limit: 10}, err)
+
+	_, err = EvalWithLimits(context.Background(), call, environment, object.Limits{MaxInstructions: 100})
+	assert.Equal(t, &object.InstructionLimitError{Limit: 100}, err)
+}
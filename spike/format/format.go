@@ -0,0 +1,251 @@
+// Package format formats Spike source code.
+package format
+
+import (
+	"bytes"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/parser"
+	"strings"
+)
+
+const indentation = "    "
+
+// braceKind tells what an opening token on the nesting stack starts.
+type braceKind int
+
+const (
+	group braceKind = iota
+	hash
+	block
+	clauses
+	// timeout follows after, up to the end of its receive.
+	timeout
+)
+
+// Source formats a Spike program. Only the whitespace between tokens
+// changes: tokens are copied from source as they are, one blank line at most
+// is kept between lines and every line is indented by its nesting depth.
+// Source that does not parse is returned unchanged together with the error.
+func Source(name string, source []byte) ([]byte, error) {
+	_, err := parser.New(lexer.NewWithFileName(bytes.NewReader(source), name)).ParseProgram()
+	if err != nil {
+		return source, err
+	}
+
+	tokens, err := tokenize(name, source)
+	if err != nil {
+		return source, err
+	}
+
+	printer := &printer{source: source}
+	for i, token := range tokens {
+		var next *lexer.Token
+		if i+1 < len(tokens) {
+			next = &tokens[i+1]
+		}
+		printer.print(token, next)
+	}
+
+	if len(tokens) > 0 {
+		printer.out.WriteString("\n")
+	}
+
+	return printer.out.Bytes(), nil
+}
+
+func tokenize(name string, source []byte) ([]lexer.Token, error) {
+	lexerInstance := lexer.NewWithFileName(bytes.NewReader(source), name)
+	lexerInstance.EmitComments()
+
+	var tokens []lexer.Token
+	for {
+		token, err := lexerInstance.NextToken()
+		if err != nil {
+			return nil, err
+		}
+		if token.Type == lexer.Eof {
+			return tokens, nil
+		}
+
+		tokens = append(tokens, token)
+	}
+}
+
+type printer struct {
+	source []byte
+	out    bytes.Buffer
+	stack  []braceKind
+
+	// previous is the last token printed other than a comment, lastLine the
+	// line the last token, possibly a comment, ends on.
+	previous     *lexer.Token
+	lastLine     int
+	afterComment bool
+	// atom is set when previous is the colon of an atom, binaryMinus when it
+	// is a minus subtracting rather than negating.
+	atom        bool
+	binaryMinus bool
+	// clauseBody is set when previous closes the body of a case, receive or
+	// after clause, which is followed by the next clause rather than by an
+	// operator.
+	clauseBody bool
+}
+
+func (printer *printer) print(token lexer.Token, next *lexer.Token) {
+	switch {
+	case printer.lastLine == 0:
+		printer.indent(token)
+	case token.Span.Start.Line > printer.lastLine:
+		printer.out.WriteString("\n")
+		if token.Span.Start.Line > printer.lastLine+1 {
+			printer.out.WriteString("\n")
+		}
+		printer.indent(token)
+	case printer.afterComment || token.Type == lexer.Comment || printer.spaced(token):
+		printer.out.WriteString(" ")
+	}
+
+	printer.out.Write(printer.source[token.Span.Start.Offset:token.Span.End.Offset])
+	printer.lastLine = token.Span.End.Line
+	printer.afterComment = token.Type == lexer.Comment
+	if token.Type == lexer.Comment {
+		return
+	}
+
+	atom := token.Type == lexer.Colon &&
+		!printer.endsOperand() &&
+		next != nil &&
+		next.Type == lexer.Identifier &&
+		next.Span.Start.Offset == token.Span.End.Offset
+	binaryMinus := token.Type == lexer.Minus && printer.endsOperand()
+	clauseBody := false
+
+	switch token.Type {
+	case lexer.LeftParenthesis, lexer.LeftBracket:
+		printer.stack = append(printer.stack, group)
+	case lexer.LeftBrace:
+		printer.stack = append(printer.stack, printer.braceKind(token))
+	case lexer.Of, lexer.Receive:
+		printer.stack = append(printer.stack, clauses)
+	case lexer.After:
+		if printer.top() == clauses {
+			printer.stack[len(printer.stack)-1] = timeout
+		}
+	default:
+		if isClosing(token) && len(printer.stack) > 0 {
+			closed := printer.top()
+			printer.stack = printer.stack[:len(printer.stack)-1]
+			clauseBody = closed == block && (printer.top() == clauses || printer.top() == timeout)
+		}
+	}
+
+	printer.previous = &token
+	printer.atom = atom
+	printer.binaryMinus = binaryMinus
+	printer.clauseBody = clauseBody
+}
+
+func isClosing(token lexer.Token) bool {
+	switch token.Type {
+	case lexer.RightParenthesis, lexer.RightBracket, lexer.RightBrace, lexer.End:
+		return true
+	}
+
+	return false
+}
+
+func (printer *printer) top() braceKind {
+	if len(printer.stack) == 0 {
+		return group
+	}
+
+	return printer.stack[len(printer.stack)-1]
+}
+
+// indent starts a line at the depth of token. Closing tokens are at the
+// depth of their opening ones, and the timeout of a receive, from after to
+// end, at the depth of the receive.
+func (printer *printer) indent(token lexer.Token) {
+	depth := 0
+	for _, kind := range printer.stack {
+		if kind != timeout {
+			depth++
+		}
+	}
+
+	top := printer.top()
+	if (isClosing(token) && top != timeout || token.Type == lexer.After && top == clauses) && depth > 0 {
+		depth--
+	}
+
+	printer.out.WriteString(strings.Repeat(indentation, depth))
+}
+
+// braceKind tells a block from a hash literal or pattern by the token before
+// the opening brace. A name directly before it is the name of a process.
+func (printer *printer) braceKind(brace lexer.Token) braceKind {
+	if printer.previous == nil {
+		return hash
+	}
+
+	switch printer.previous.Type {
+	case lexer.RightParenthesis, lexer.Else, lexer.Arrow:
+		return block
+	case lexer.Identifier:
+		if printer.previous.Span.End.Line == brace.Span.Start.Line {
+			return block
+		}
+	}
+
+	return hash
+}
+
+// endsOperand tells whether the previous token can end an operand, which
+// makes a following minus binary and a following colon separate a hash key.
+func (printer *printer) endsOperand() bool {
+	if printer.previous == nil || printer.atom || printer.clauseBody {
+		return false
+	}
+
+	switch printer.previous.Type {
+	case lexer.Identifier, lexer.Integer, lexer.Float, lexer.String, lexer.Template,
+		lexer.True, lexer.False, lexer.RightParenthesis, lexer.RightBracket, lexer.RightBrace, lexer.End:
+		return true
+	}
+
+	return false
+}
+
+// spaced tells whether token, on the same line as the previous one, is
+// separated from it by a space.
+func (printer *printer) spaced(token lexer.Token) bool {
+	previous := printer.previous
+
+	switch token.Type {
+	case lexer.RightParenthesis, lexer.RightBracket, lexer.Comma, lexer.Semicolon, lexer.Dot:
+		return false
+	case lexer.RightBrace:
+		return printer.top() == block && previous.Type != lexer.LeftBrace
+	case lexer.Colon:
+		if printer.endsOperand() {
+			return false
+		}
+	case lexer.LeftParenthesis, lexer.LeftBracket:
+		if printer.endsOperand() || (token.Type == lexer.LeftParenthesis && previous.Type == lexer.Fn) {
+			return false
+		}
+	}
+
+	switch previous.Type {
+	case lexer.LeftParenthesis, lexer.LeftBracket, lexer.Dot, lexer.Ellipsis, lexer.Bang:
+		return false
+	case lexer.LeftBrace:
+		return printer.top() == block
+	case lexer.Colon:
+		return !printer.atom
+	case lexer.Minus:
+		return printer.binaryMinus
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package format
+
+import (
+	"bytes"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/parser"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Source(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "let  x=1+ 2*-3;x",
+			expected: "let x = 1 + 2 * -3; x\n",
+		},
+		{
+			input:    "let f=fn( a,b ){\nreturn a-b\n}\n\n\n\nf(1,2)",
+			expected: "let f = fn(a, b) {\n    return a - b\n}\n\nf(1, 2)\n",
+		},
+		{
+			input:    "if(!ok){1}else{2}",
+			expected: "if (!ok) { 1 } else { 2 }\n",
+		},
+		{
+			input:    `let h={"a":[1,2], :b::c}; h[:b]`,
+			expected: "let h = {\"a\": [1, 2], :b: :c}; h[:b]\n",
+		},
+		{
+			input:    "// doc\nlet x = 1 /* why */ // trailing\n/* block\n   comment */\nx",
+			expected: "// doc\nlet x = 1 /* why */ // trailing\n/* block\n   comment */\nx\n",
+		},
+		{
+			input:    "let s = `raw ${x}\\n`; \"a ${ s } b\\t\"",
+			expected: "let s = `raw ${x}\\n`; \"a ${ s } b\\t\"\n",
+		},
+		{
+			input:    "case xs of\n[] -> {0}\n[head,...tail] when head>0 -> {\nhead\n}\n{\"k\": K} -> {K}\nend",
+			expected: "case xs of\n    [] -> { 0 }\n    [head, ...tail] when head > 0 -> {\n        head\n    }\n    {\"k\": K} -> { K }\nend\n",
+		},
+		{
+			input:    "case x of (1, _) -> { :one } [a, ...rest] -> { rest } end",
+			expected: "case x of (1, _) -> { :one } [a, ...rest] -> { rest } end\n",
+		},
+		{
+			input:    "case x of 1 -> { fn(a) { a } } -1 -> { fn(a) { a }(2) } end",
+			expected: "case x of 1 -> { fn(a) { a } } -1 -> { fn(a) { a }(2) } end\n",
+		},
+		{
+			input:    "receive\n(:ping, from) -> {send(from, :pong)}\nafter 100 -> {\n:timeout\n}\nend",
+			expected: "receive\n    (:ping, from) -> { send(from, :pong) }\nafter 100 -> {\n    :timeout\n}\nend\n",
+		},
+		{
+			input:    "process User {\ncall get() {return this.name}\n}\nspawn User().get()",
+			expected: "process User {\n    call get() { return this.name }\n}\nspawn User().get()\n",
+		},
+		{
+			input:    "fn(x){x}(5) - (1,)[0]",
+			expected: "fn(x) { x }(5) - (1,)[0]\n",
+		},
+		{
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			formatted, err := Source("main.spk", []byte(testCase.input))
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, string(formatted))
+
+			again, err := Source("main.spk", formatted)
+			assert.NoError(t, err)
+			assert.Equal(t, string(formatted), string(again))
+
+			assert.Equal(t, parse(t, testCase.input), parse(t, string(formatted)))
+		})
+	}
+}
+
+func Test_Source_withError(t *testing.T) {
+	source := []byte("let x = ")
+
+	formatted, err := Source("main.spk", source)
+
+	assert.EqualError(t, err, "main.spk:1:9: unexpected end of input")
+	assert.Equal(t, source, formatted)
+}
+
+func parse(t *testing.T, source string) string {
+	program, err := parser.New(lexer.New(bytes.NewReader([]byte(source)))).ParseProgram()
+	assert.NoError(t, err)
+
+	return program.String()
+}
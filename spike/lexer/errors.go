@@ -0,0 +1,15 @@
+package lexer
+
+import "fmt"
+
+type Error struct {
+	Position Position
+	// End is where the source the error is about ends, the zero Position
+	// when it is about Position alone.
+	End     Position
+	Message string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s: %s", err.Position, err.Message)
+}
@@ -11,24 +11,77 @@ type TokenIterator interface {
 }
 
 type Lexer struct {
-	reader *bufio.Reader
+	reader       *bufio.Reader
+	position     Position
+	emitComments bool
 }
 
 func New(reader io.Reader) *Lexer {
-	return &Lexer{reader: bufio.NewReader(reader)}
+	return NewWithFileName(reader, "")
+}
+
+func NewWithFileName(reader io.Reader, fileName string) *Lexer {
+	return NewAt(reader, Position{File: fileName, Line: 1, Column: 1})
+}
+
+// NewAt creates a lexer for a fragment of a larger source, e.g. an
+// interpolated expression, starting at the given position.
+func NewAt(reader io.Reader, position Position) *Lexer {
+	return &Lexer{
+		reader:   bufio.NewReader(reader),
+		position: position,
+	}
+}
+
+// EmitComments makes NextToken return comments as Comment tokens instead of
+// skipping them, so tools like formatters can preserve them.
+func (lexer *Lexer) EmitComments() {
+	lexer.emitComments = true
 }
 
 func (lexer *Lexer) NextToken() (Token, error) {
+	for {
+		token, err := lexer.nextTokenWithComments()
+		if err != nil || token.Type != Comment || lexer.emitComments {
+			return token, err
+		}
+	}
+}
+
+func (lexer *Lexer) nextTokenWithComments() (Token, error) {
+	var token Token
+
 	err := lexer.skipWhitespace()
+	start := lexer.position
 	if err != nil {
-		return lexer.handleIOError(err)
+		token, err = lexer.handleIOError(err)
+	} else {
+		token, err = lexer.readNextToken()
 	}
 
-	return lexer.readNextToken()
+	token.Span = Span{Start: start, End: lexer.position}
+
+	return token, err
 }
 
 func (lexer *Lexer) readNextToken() (Token, error) {
-	operator, err := lexer.tryReadTwoCharOperator()
+	comment, err := lexer.tryReadComment()
+	if comment != nil {
+		return *comment, err
+	}
+	if err != nil {
+		return lexer.handleIOError(err)
+	}
+
+	operator, err := lexer.tryReadThreeCharOperator()
+	if err != nil {
+		return lexer.handleIOError(err)
+	}
+	if operator != nil {
+		return *operator, nil
+	}
+
+	operator, err = lexer.tryReadTwoCharOperator()
 	if err != nil {
 		return lexer.handleIOError(err)
 	}
@@ -61,15 +114,15 @@ func (lexer *Lexer) readNextToken() (Token, error) {
 	}
 
 	str, err := lexer.tryReadString()
+	if str != nil {
+		return *str, err
+	}
 	if err != nil {
 		return lexer.handleIOError(err)
 	}
-	if str != nil {
-		return *str, nil
-	}
 
-	invalidToken, err := lexer.reader.ReadByte()
-	return Token{Invalid, string(invalidToken)}, err
+	invalidToken, err := lexer.readByte()
+	return Token{Type: Invalid, Literal: string(invalidToken)}, err
 }
 
 func (lexer *Lexer) skipWhitespace() error {
@@ -77,7 +130,7 @@ func (lexer *Lexer) skipWhitespace() error {
 	c := make([]byte, 0, 1)
 
 	for c, err = lexer.reader.Peek(1); err == nil && isWhitespace(c[0]); c, err = lexer.reader.Peek(1) {
-		_, err2 := lexer.reader.ReadByte()
+		_, err2 := lexer.readByte()
 		if err2 != nil {
 			return err2
 		}
@@ -85,6 +138,119 @@ func (lexer *Lexer) skipWhitespace() error {
 	return err
 }
 
+func (lexer *Lexer) tryReadComment() (*Token, error) {
+	twoChars, err := lexer.reader.Peek(2)
+	if err == io.EOF {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch string(twoChars) {
+	case "//":
+		return lexer.readLineComment()
+	case "/*":
+		return lexer.readBlockComment()
+	}
+
+	return nil, nil
+}
+
+func (lexer *Lexer) readLineComment() (*Token, error) {
+	var err error
+	c := make([]byte, 0, 1)
+
+	comment := strings.Builder{}
+
+	for c, err = lexer.reader.Peek(1); err == nil && c[0] != '\n'; c, err = lexer.reader.Peek(1) {
+		b, err2 := lexer.readByte()
+		if err2 != nil {
+			return nil, err2
+		}
+
+		comment.WriteByte(b)
+	}
+
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &Token{Type: Comment, Literal: strings.TrimRight(comment.String(), "\r")}, nil
+}
+
+// readBlockComment reads a /* ... */ comment. Block comments nest, so every
+// opening /* needs its own closing */. An unterminated comment is an invalid
+// token with an error pointing at its opening /*.
+func (lexer *Lexer) readBlockComment() (*Token, error) {
+	start := lexer.position
+	comment := strings.Builder{}
+	depth := 0
+
+	for {
+		twoChars, err := lexer.reader.Peek(2)
+		if err == io.EOF {
+			err = lexer.skipBytes(len(twoChars))
+			if err != nil {
+				return nil, err
+			}
+
+			return &Token{Type: Invalid, Literal: "/*"}, &Error{
+				Position: start,
+				End:      start.advance('/').advance('*'),
+				Message:  "unterminated block comment",
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch string(twoChars) {
+		case "/*":
+			depth++
+		case "*/":
+			depth--
+		default:
+			b, err := lexer.readByte()
+			if err != nil {
+				return nil, err
+			}
+			comment.WriteByte(b)
+			continue
+		}
+
+		comment.Write(twoChars)
+		err = lexer.skipBytes(2)
+		if err != nil {
+			return nil, err
+		}
+
+		if depth == 0 {
+			return &Token{Type: Comment, Literal: comment.String()}, nil
+		}
+	}
+}
+
+func (lexer *Lexer) tryReadThreeCharOperator() (*Token, error) {
+	threeChars, err := lexer.reader.Peek(3)
+	if err == io.EOF {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := lookupThreeCharOperator(string(threeChars))
+	if t == nil {
+		return nil, nil
+	}
+
+	err = lexer.skipBytes(len(threeChars))
+	return t, err
+}
+
 func (lexer *Lexer) tryReadTwoCharOperator() (*Token, error) {
 	twoChars, err := lexer.reader.Peek(2)
 	if err == io.EOF {
@@ -100,7 +266,7 @@ func (lexer *Lexer) tryReadTwoCharOperator() (*Token, error) {
 		return nil, nil
 	}
 
-	_, err = lexer.reader.Read(twoChars)
+	err = lexer.skipBytes(len(twoChars))
 	return t, err
 }
 
@@ -116,7 +282,7 @@ func (lexer *Lexer) tryReadOneCharOperator() (*Token, error) {
 
 	}
 
-	_, err = lexer.reader.Read(char)
+	err = lexer.skipBytes(len(char))
 	return t, err
 }
 
@@ -140,7 +306,7 @@ func (lexer *Lexer) tryReadIdentifier() (*Token, error) {
 		return keyword, nil
 	}
 
-	return &Token{Identifier, identifier}, nil
+	return &Token{Type: Identifier, Literal: identifier}, nil
 }
 
 func (lexer *Lexer) tryReadNumber() (*Token, error) {
@@ -153,35 +319,122 @@ func (lexer *Lexer) tryReadNumber() (*Token, error) {
 		return nil, nil
 	}
 
-	number, err := lexer.readNumber()
+	number, err := lexer.readDigits()
+	if err != nil {
+		return nil, err
+	}
+
+	fraction, err := lexer.readFraction()
+	if err != nil {
+		return nil, err
+	}
+
+	exponent, err := lexer.readExponent()
 	if err != nil {
 		return nil, err
 	}
 
-	return &Token{Integer, number}, nil
+	if fraction == "" && exponent == "" {
+		return &Token{Type: Integer, Literal: number}, nil
+	}
+
+	return &Token{Type: Float, Literal: number + fraction + exponent}, nil
 }
 
+// readFraction reads the ".5" part of a float literal. The dot must be
+// followed by a digit, otherwise it is not part of the number.
+func (lexer *Lexer) readFraction() (string, error) {
+	chars, err := lexer.reader.Peek(2)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	if len(chars) < 2 || chars[0] != '.' || !isNumber(chars[1]) {
+		return "", nil
+	}
+
+	_, err = lexer.readByte()
+	if err != nil {
+		return "", err
+	}
+
+	digits, err := lexer.readDigits()
+	return "." + digits, err
+}
+
+// readExponent reads the "e-3" part of a float literal, if present.
+func (lexer *Lexer) readExponent() (string, error) {
+	chars, err := lexer.reader.Peek(3)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	if len(chars) < 2 || (chars[0] != 'e' && chars[0] != 'E') {
+		return "", nil
+	}
+
+	prefixLength := 1
+	if chars[1] == '+' || chars[1] == '-' {
+		prefixLength = 2
+	}
+
+	if len(chars) <= prefixLength || !isNumber(chars[prefixLength]) {
+		return "", nil
+	}
+
+	prefix := string(chars[:prefixLength])
+	err = lexer.skipBytes(prefixLength)
+	if err != nil {
+		return "", err
+	}
+
+	digits, err := lexer.readDigits()
+	return prefix + digits, err
+}
+
+// tryReadString reads a "quoted" or `raw` string. Quoted strings have their
+// escape sequences decoded, unless they contain ${...} interpolations: those
+// are returned undecoded as a Template token, see SplitTemplate.
 func (lexer *Lexer) tryReadString() (*Token, error) {
 	char, err := lexer.reader.Peek(1)
 	if err != nil {
 		return nil, err
 	}
 
-	if char[0] != '"' {
+	quote := char[0]
+	if quote != '"' && quote != '`' {
 		return nil, nil
 	}
 
-	_, err = lexer.reader.ReadByte()
+	_, err = lexer.readByte()
 	if err != nil {
 		return nil, err
 	}
 
-	str, err := lexer.readString()
+	if quote == '`' {
+		str, err := lexer.readRawString()
+		if err != nil {
+			return &Token{Type: Invalid, Literal: "`" + str}, unterminatedStringError(err)
+		}
+
+		return &Token{Type: String, Literal: str}, nil
+	}
+
+	str, interpolated, err := lexer.readString()
 	if err != nil {
-		return nil, err
+		return &Token{Type: Invalid, Literal: `"` + str}, unterminatedStringError(err)
+	}
+
+	if interpolated {
+		return &Token{Type: Template, Literal: str}, nil
 	}
 
-	return &Token{String, str}, nil
+	unescaped, err := unescape(str)
+	if err != nil {
+		return &Token{Type: Invalid, Literal: `"` + str + `"`}, err
+	}
+
+	return &Token{Type: String, Literal: unescaped}, nil
 }
 
 func (lexer *Lexer) readIdentifier() (string, error) {
@@ -191,7 +444,7 @@ func (lexer *Lexer) readIdentifier() (string, error) {
 	identifier := strings.Builder{}
 
 	for c, err = lexer.reader.Peek(1); err == nil && isIdentifierCharacter(c[0]); c, err = lexer.reader.Peek(1) {
-		b, err2 := lexer.reader.ReadByte()
+		b, err2 := lexer.readByte()
 		if err2 != nil {
 			return "", err2
 		}
@@ -206,14 +459,14 @@ func (lexer *Lexer) readIdentifier() (string, error) {
 	return identifier.String(), nil
 }
 
-func (lexer *Lexer) readNumber() (string, error) {
+func (lexer *Lexer) readDigits() (string, error) {
 	var err error
 	c := make([]byte, 0, 1)
 
 	number := strings.Builder{}
 
 	for c, err = lexer.reader.Peek(1); err == nil && isNumber(c[0]); c, err = lexer.reader.Peek(1) {
-		b, err2 := lexer.reader.ReadByte()
+		b, err2 := lexer.readByte()
 		if err2 != nil {
 			return "", err2
 		}
@@ -228,15 +481,71 @@ func (lexer *Lexer) readNumber() (string, error) {
 	return number.String(), nil
 }
 
-func (lexer *Lexer) readString() (string, error) {
+// readString reads the raw contents of a quoted string up to the closing
+// quote. Quotes inside ${...} interpolations do not end the string.
+func (lexer *Lexer) readString() (string, bool, error) {
+	str := strings.Builder{}
+	interpolated := false
+	depth := 0
+
+	for {
+		b, err := lexer.readByte()
+		if err != nil {
+			return str.String(), interpolated, err
+		}
+
+		if depth == 0 && b == '"' {
+			return str.String(), interpolated, nil
+		}
+
+		str.WriteByte(b)
+
+		switch {
+		case b == '\\' && depth == 0:
+			escaped, err := lexer.readByte()
+			if err != nil {
+				return str.String(), interpolated, err
+			}
+			str.WriteByte(escaped)
+
+		case b == '$' && depth == 0:
+			next, err := lexer.reader.Peek(1)
+			if err == nil && next[0] == '{' {
+				_, err = lexer.readByte()
+				if err != nil {
+					return str.String(), interpolated, err
+				}
+				str.WriteByte('{')
+				interpolated = true
+				depth = 1
+			}
+
+		case b == '"':
+			nested, _, err := lexer.readString()
+			str.WriteString(nested)
+			if err != nil {
+				return str.String(), interpolated, err
+			}
+			str.WriteByte('"')
+
+		case b == '{' && depth > 0:
+			depth++
+
+		case b == '}' && depth > 0:
+			depth--
+		}
+	}
+}
+
+func (lexer *Lexer) readRawString() (string, error) {
 	str := strings.Builder{}
 	for {
-		b, err := lexer.reader.ReadByte()
+		b, err := lexer.readByte()
 		if err != nil {
 			return str.String(), err
 		}
 
-		if b == '"' {
+		if b == '`' {
 			return str.String(), nil
 		}
 
@@ -244,6 +553,28 @@ func (lexer *Lexer) readString() (string, error) {
 	}
 }
 
+func (lexer *Lexer) readByte() (byte, error) {
+	b, err := lexer.reader.ReadByte()
+	if err != nil {
+		return b, err
+	}
+
+	lexer.position = lexer.position.advance(b)
+
+	return b, nil
+}
+
+func (lexer *Lexer) skipBytes(n int) error {
+	for i := 0; i < n; i++ {
+		_, err := lexer.readByte()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (lexer *Lexer) handleIOError(err error) (Token, error) {
 	if err == io.EOF {
 		return EOFToken, nil
@@ -253,11 +584,11 @@ func (lexer *Lexer) handleIOError(err error) (Token, error) {
 }
 
 func isIdentifierFirstCharacter(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
 }
 
 func isIdentifierCharacter(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z')
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || c == '_'
 }
 
 func isNumber(c byte) bool {
@@ -286,6 +617,15 @@ func lookupOneCharOperator(literal string) *Token {
 	return &token
 }
 
+func lookupThreeCharOperator(literal string) *Token {
+	token, ok := threeCharOperators[literal]
+	if !ok {
+		return nil
+	}
+
+	return &token
+}
+
 func lookupTwoCharOperator(literal string) *Token {
 	token, ok := twoCharOperators[literal]
 	if !ok {
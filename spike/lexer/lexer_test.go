@@ -28,11 +28,11 @@ func Test_Lexer_token(t *testing.T) {
 
 			token, err := l.NextToken()
 			assert.NoError(t, err)
-			assert.Equal(t, testCase.expectedToken, token)
+			assert.Equal(t, testCase.expectedToken, withoutSpan(token))
 
 			token, err = l.NextToken()
 			assert.NoError(t, err)
-			assert.Equal(t, EOFToken, token)
+			assert.Equal(t, EOFToken, withoutSpan(token))
 		})
 	}
 }
@@ -43,23 +43,25 @@ func Test_Lexer_code_sample(t *testing.T) {
 let variable = (10 + 20) * 5; 
 return variable2 ! VAR3 - true false / < > == !=
 <= >= || && if else { } fn , "hello world" [ ] :
+case of when end -> ... _ snake_case
+receive after yield
 `)
 	expectedTokens := []Token{
 		LetToken,
-		{Identifier, "variable"},
+		{Type: Identifier, Literal: "variable"},
 		AssignToken,
 		LeftParenthesisToken,
-		{Integer, "10"},
+		{Type: Integer, Literal: "10"},
 		PlusToken,
-		{Integer, "20"},
+		{Type: Integer, Literal: "20"},
 		RightParenthesisToken,
 		AsteriskToken,
-		{Integer, "5"},
+		{Type: Integer, Literal: "5"},
 		SemicolonToken,
 		ReturnToken,
-		{Identifier, "variable2"},
+		{Type: Identifier, Literal: "variable2"},
 		BangToken,
-		{Identifier, "VAR3"},
+		{Type: Identifier, Literal: "VAR3"},
 		MinusToken,
 		TrueToken,
 		FalseToken,
@@ -78,10 +80,21 @@ return variable2 ! VAR3 - true false / < > == !=
 		RightBraceToken,
 		FnToken,
 		CommaToken,
-		{String, "hello world"},
+		{Type: String, Literal: "hello world"},
 		LeftBracketToken,
 		RightBracketToken,
 		ColonToken,
+		CaseToken,
+		OfToken,
+		WhenToken,
+		EndToken,
+		ArrowToken,
+		EllipsisToken,
+		{Type: Identifier, Literal: "_"},
+		{Type: Identifier, Literal: "snake_case"},
+		ReceiveToken,
+		AfterToken,
+		YieldToken,
 	}
 
 	lexer := New(input)
@@ -94,11 +107,47 @@ return variable2 ! VAR3 - true false / < > == !=
 	assert.Exactly(t, expectedTokens, tokens)
 }
 
+func Test_Lexer_tokenSpans(t *testing.T) {
+	// given
+	input := strings.NewReader("let x = 10;\n  \"ab\" <= y")
+	expectedSpans := []struct {
+		literal string
+		span    Span
+	}{
+		{"let", Span{Start: Position{File: "main.spk", Line: 1, Column: 1, Offset: 0}, End: Position{File: "main.spk", Line: 1, Column: 4, Offset: 3}}},
+		{"x", Span{Start: Position{File: "main.spk", Line: 1, Column: 5, Offset: 4}, End: Position{File: "main.spk", Line: 1, Column: 6, Offset: 5}}},
+		{"=", Span{Start: Position{File: "main.spk", Line: 1, Column: 7, Offset: 6}, End: Position{File: "main.spk", Line: 1, Column: 8, Offset: 7}}},
+		{"10", Span{Start: Position{File: "main.spk", Line: 1, Column: 9, Offset: 8}, End: Position{File: "main.spk", Line: 1, Column: 11, Offset: 10}}},
+		{";", Span{Start: Position{File: "main.spk", Line: 1, Column: 11, Offset: 10}, End: Position{File: "main.spk", Line: 1, Column: 12, Offset: 11}}},
+		{"ab", Span{Start: Position{File: "main.spk", Line: 2, Column: 3, Offset: 14}, End: Position{File: "main.spk", Line: 2, Column: 7, Offset: 18}}},
+		{"<=", Span{Start: Position{File: "main.spk", Line: 2, Column: 8, Offset: 19}, End: Position{File: "main.spk", Line: 2, Column: 10, Offset: 21}}},
+		{"y", Span{Start: Position{File: "main.spk", Line: 2, Column: 11, Offset: 22}, End: Position{File: "main.spk", Line: 2, Column: 12, Offset: 23}}},
+		{"", Span{Start: Position{File: "main.spk", Line: 2, Column: 12, Offset: 23}, End: Position{File: "main.spk", Line: 2, Column: 12, Offset: 23}}},
+	}
+
+	lexer := NewWithFileName(input, "main.spk")
+
+	for _, expected := range expectedSpans {
+		// when
+		token, err := lexer.NextToken()
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, expected.literal, token.Literal)
+		assert.Equal(t, expected.span, token.Span)
+	}
+}
+
+func Test_Position_String(t *testing.T) {
+	assert.Equal(t, "main.spk:12:7", Position{File: "main.spk", Line: 12, Column: 7}.String())
+	assert.Equal(t, "12:7", Position{Line: 12, Column: 7}.String())
+}
+
 func Test_Lexer_invalidToken(t *testing.T) {
 	// given
 	input := strings.NewReader("^")
 	expectedTokens := []Token{
-		{Invalid, "^"},
+		{Type: Invalid, Literal: "^"},
 	}
 
 	lexer := New(input)
@@ -114,13 +163,114 @@ func Test_Lexer_invalidToken(t *testing.T) {
 func iteratorToSlice(iterator TokenIterator) ([]Token, error) {
 	result := make([]Token, 0)
 
-	for token, err := iterator.NextToken(); token != EOFToken; token, err = iterator.NextToken() {
+	for token, err := iterator.NextToken(); token.Type != Eof; token, err = iterator.NextToken() {
 		if err != nil {
 			return nil, err
 		}
 
-		result = append(result, token)
+		result = append(result, withoutSpan(token))
 	}
 
 	return result, nil
 }
+
+func withoutSpan(token Token) Token {
+	token.Span = Span{}
+	return token
+}
+
+func Test_Lexer_comments(t *testing.T) {
+	// given
+	input := `let a = 1; // trailing comment
+/* block /* nested */ still comment */ a / 2
+// last line`
+	expectedTokens := []Token{
+		LetToken,
+		{Type: Identifier, Literal: "a"},
+		AssignToken,
+		{Type: Integer, Literal: "1"},
+		SemicolonToken,
+		{Type: Identifier, Literal: "a"},
+		SlashToken,
+		{Type: Integer, Literal: "2"},
+	}
+
+	lexer := New(strings.NewReader(input))
+
+	// when
+	tokens, err := iteratorToSlice(lexer)
+
+	// then
+	assert.NoError(t, err)
+	assert.Exactly(t, expectedTokens, tokens)
+}
+
+func Test_Lexer_emitComments(t *testing.T) {
+	// given
+	input := "// doc\r\nlet /* a */ x"
+	expectedTokens := []Token{
+		{Type: Comment, Literal: "// doc"},
+		LetToken,
+		{Type: Comment, Literal: "/* a */"},
+		{Type: Identifier, Literal: "x"},
+	}
+
+	lexer := New(strings.NewReader(input))
+	lexer.EmitComments()
+
+	// when
+	tokens, err := iteratorToSlice(lexer)
+
+	// then
+	assert.NoError(t, err)
+	assert.Exactly(t, expectedTokens, tokens)
+}
+
+func Test_Lexer_unterminatedBlockComment(t *testing.T) {
+	// given
+	lexer := New(strings.NewReader("1 /* never\nclosed *"))
+
+	// when
+	first, err := lexer.NextToken()
+	assert.NoError(t, err)
+	second, err := lexer.NextToken()
+	third, _ := lexer.NextToken()
+
+	// then
+	assert.Equal(t, Token{Type: Integer, Literal: "1"}, withoutSpan(first))
+	assert.Equal(t, Token{Type: Invalid, Literal: "/*"}, withoutSpan(second))
+	assert.Equal(t, &Error{
+		Position: Position{Line: 1, Column: 3, Offset: 2},
+		End:      Position{Line: 1, Column: 5, Offset: 4},
+		Message:  "unterminated block comment",
+	}, err)
+	assert.Equal(t, Eof, third.Type)
+}
+
+func Test_Lexer_numbers(t *testing.T) {
+	// given
+	input := strings.NewReader("10 1.5 0.25 1e-3 2E+10 3e2 7. x 4.e")
+	expectedTokens := []Token{
+		{Type: Integer, Literal: "10"},
+		{Type: Float, Literal: "1.5"},
+		{Type: Float, Literal: "0.25"},
+		{Type: Float, Literal: "1e-3"},
+		{Type: Float, Literal: "2E+10"},
+		{Type: Float, Literal: "3e2"},
+		{Type: Integer, Literal: "7"},
+		{Type: Dot, Literal: "."},
+		{Type: Identifier, Literal: "x"},
+		{Type: Integer, Literal: "4"},
+		{Type: Dot, Literal: "."},
+		{Type: Identifier, Literal: "e"},
+	}
+
+	lexer := New(input)
+
+	// when
+	tokens, err := iteratorToSlice(lexer)
+
+	// then
+	assert.NoError(t, err)
+	assert.Exactly(t, expectedTokens, tokens)
+}
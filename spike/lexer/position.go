@@ -0,0 +1,39 @@
+package lexer
+
+import "fmt"
+
+type Position struct {
+	File   string
+	Line   int
+	Column int
+	Offset int
+}
+
+func (position Position) String() string {
+	if position.File == "" {
+		return fmt.Sprintf("%d:%d", position.Line, position.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", position.File, position.Line, position.Column)
+}
+
+func (position Position) advance(b byte) Position {
+	position.Offset++
+	if b == '\n' {
+		position.Line++
+		position.Column = 1
+	} else {
+		position.Column++
+	}
+
+	return position
+}
+
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (span Span) String() string {
+	return span.Start.String()
+}
@@ -0,0 +1,176 @@
+package lexer
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+var escapeSequences = map[byte]byte{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'\\': '\\',
+	'"':  '"',
+	'$':  '$',
+}
+
+// TemplatePart is a piece of an interpolated string: either literal text or
+// the source code of an embedded ${...} expression.
+type TemplatePart struct {
+	Text         string
+	IsExpression bool
+	Position     Position
+}
+
+// SplitTemplate splits the literal of a Template token into text parts, with
+// escape sequences decoded, and expression parts positioned in the source.
+func SplitTemplate(token Token) ([]TemplatePart, error) {
+	raw := token.Literal
+	position := token.Span.Start.advance('"')
+
+	parts := make([]TemplatePart, 0)
+	text := strings.Builder{}
+	textStart := position
+
+	for i := 0; i < len(raw); {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw):
+			text.WriteString(raw[i : i+2])
+			position = position.advance(raw[i]).advance(raw[i+1])
+			i += 2
+
+		case strings.HasPrefix(raw[i:], "${"):
+			unescaped, err := unescape(text.String())
+			if err != nil {
+				return nil, &Error{Position: textStart, Message: err.Error()}
+			}
+			if unescaped != "" {
+				parts = append(parts, TemplatePart{Text: unescaped, Position: textStart})
+			}
+			text.Reset()
+
+			position = position.advance('$').advance('{')
+			end := matchingBrace(raw, i+2)
+			if end < 0 {
+				return nil, &Error{Position: position, Message: "unterminated interpolation"}
+			}
+
+			expression := raw[i+2 : end]
+			if strings.TrimSpace(expression) == "" {
+				return nil, &Error{Position: position, Message: "empty interpolation"}
+			}
+			parts = append(parts, TemplatePart{Text: expression, IsExpression: true, Position: position})
+
+			for j := i + 2; j <= end; j++ {
+				position = position.advance(raw[j])
+			}
+			i = end + 1
+			textStart = position
+
+		default:
+			text.WriteByte(raw[i])
+			position = position.advance(raw[i])
+			i++
+		}
+	}
+
+	unescaped, err := unescape(text.String())
+	if err != nil {
+		return nil, &Error{Position: textStart, Message: err.Error()}
+	}
+	if unescaped != "" {
+		parts = append(parts, TemplatePart{Text: unescaped, Position: textStart})
+	}
+
+	return parts, nil
+}
+
+func matchingBrace(raw string, from int) int {
+	depth := 1
+	inString := false
+
+	for i := from; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && inString:
+			i++
+		case raw[i] == '"':
+			inString = !inString
+		case inString:
+		case raw[i] == '{':
+			depth++
+		case raw[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func unescape(raw string) (string, error) {
+	if !strings.Contains(raw, "\\") {
+		return raw, nil
+	}
+
+	out := strings.Builder{}
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			out.WriteByte(raw[i])
+			continue
+		}
+
+		i++
+		if i >= len(raw) {
+			return "", errors.New("unfinished escape sequence")
+		}
+
+		if raw[i] == 'u' {
+			r, length, err := readUnicodeEscape(raw[i+1:])
+			if err != nil {
+				return "", err
+			}
+
+			out.WriteRune(r)
+			i += length
+			continue
+		}
+
+		decoded, ok := escapeSequences[raw[i]]
+		if !ok {
+			return "", errors.Errorf("invalid escape sequence \\%c", raw[i])
+		}
+		out.WriteByte(decoded)
+	}
+
+	return out.String(), nil
+}
+
+// readUnicodeEscape reads the {XXXX} part of a \u{XXXX} escape sequence.
+func readUnicodeEscape(raw string) (rune, int, error) {
+	end := strings.IndexByte(raw, '}')
+	if !strings.HasPrefix(raw, "{") || end < 2 {
+		return 0, 0, errors.New(`invalid unicode escape sequence, expected \u{XXXX}`)
+	}
+
+	code, err := strconv.ParseUint(raw[1:end], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return 0, 0, errors.Errorf("invalid unicode code point \\u%s", raw[:end+1])
+	}
+
+	return rune(code), end + 1, nil
+}
+
+func unterminatedStringError(err error) error {
+	if err == io.EOF {
+		return errors.New("unterminated string")
+	}
+
+	return err
+}
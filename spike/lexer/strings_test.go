@@ -0,0 +1,104 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Lexer_strings(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedToken Token
+	}{
+		{
+			input:         `"line\nnext\ttab \\ \"quoted\""`,
+			expectedToken: Token{Type: String, Literal: "line\nnext\ttab \\ \"quoted\""},
+		},
+		{
+			input:         `"smile \u{1F600} \u{e9}"`,
+			expectedToken: Token{Type: String, Literal: "smile 😀 é"},
+		},
+		{
+			input:         `"cost: \${price}"`,
+			expectedToken: Token{Type: String, Literal: "cost: ${price}"},
+		},
+		{
+			input:         "`raw \\n\nmulti-line`",
+			expectedToken: Token{Type: String, Literal: "raw \\n\nmulti-line"},
+		},
+		{
+			input:         `"Hello ${name}!"`,
+			expectedToken: Token{Type: Template, Literal: "Hello ${name}!"},
+		},
+		{
+			input:         `"${ {"a": "}"}["a"] }"`,
+			expectedToken: Token{Type: Template, Literal: `${ {"a": "}"}["a"] }`},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			l := New(strings.NewReader(testCase.input))
+
+			token, err := l.NextToken()
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedToken, withoutSpan(token))
+
+			token, err = l.NextToken()
+			assert.NoError(t, err)
+			assert.Equal(t, EOFToken, withoutSpan(token))
+		})
+	}
+}
+
+func Test_Lexer_invalidStrings(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{
+			input:         `"bad \q escape"`,
+			expectedError: `invalid escape sequence \q`,
+		},
+		{
+			input:         `"bad \u{110000}"`,
+			expectedError: `invalid unicode code point \u{110000}`,
+		},
+		{
+			input:         `"never closed`,
+			expectedError: "unterminated string",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			token, err := New(strings.NewReader(testCase.input)).NextToken()
+
+			assert.EqualError(t, err, testCase.expectedError)
+			assert.Equal(t, Invalid, token.Type)
+		})
+	}
+}
+
+func Test_SplitTemplate(t *testing.T) {
+	token, err := New(strings.NewReader(`"a\t${x + 1} b ${name}"`)).NextToken()
+	assert.NoError(t, err)
+
+	parts, err := SplitTemplate(token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []TemplatePart{
+		{Text: "a\t", Position: Position{Line: 1, Column: 2, Offset: 1}},
+		{Text: "x + 1", IsExpression: true, Position: Position{Line: 1, Column: 7, Offset: 6}},
+		{Text: " b ", Position: Position{Line: 1, Column: 13, Offset: 12}},
+		{Text: "name", IsExpression: true, Position: Position{Line: 1, Column: 18, Offset: 17}},
+	}, parts)
+}
+
+func Test_SplitTemplate_emptyInterpolation(t *testing.T) {
+	_, err := SplitTemplate(Token{Type: Template, Literal: "a ${ }", Span: Span{Start: Position{Line: 1, Column: 1}}})
+
+	assert.EqualError(t, err, "1:6: empty interpolation")
+}
@@ -3,6 +3,7 @@ package lexer
 type Token struct {
 	Type    TokenType
 	Literal string
+	Span    Span
 }
 
 type TokenType string
@@ -31,6 +32,9 @@ const (
 	LeftBracket      TokenType = "leftBracket"
 	RightBracket     TokenType = "rightBracket"
 	Colon            TokenType = "colon"
+	Arrow            TokenType = "arrow"
+	Ellipsis         TokenType = "ellipsis"
+	Dot              TokenType = "dot"
 )
 
 var oneCharOperators = map[string]Token{
@@ -51,6 +55,7 @@ var oneCharOperators = map[string]Token{
 	"[": LeftBracketToken,
 	"]": RightBracketToken,
 	":": ColonToken,
+	".": DotToken,
 }
 
 var twoCharOperators = map[string]Token{
@@ -60,27 +65,46 @@ var twoCharOperators = map[string]Token{
 	">=": GreaterOrEqualToken,
 	"&&": AndToken,
 	"||": OrToken,
+	"->": ArrowToken,
+}
+
+var threeCharOperators = map[string]Token{
+	"...": EllipsisToken,
 }
 
 // Keywords
 const (
-	Let    TokenType = "let"
-	Return TokenType = "return"
-	True   TokenType = "true"
-	False  TokenType = "false"
-	If     TokenType = "if"
-	Else   TokenType = "else"
-	Fn     TokenType = "fn"
+	Let     TokenType = "let"
+	Return  TokenType = "return"
+	True    TokenType = "true"
+	False   TokenType = "false"
+	If      TokenType = "if"
+	Else    TokenType = "else"
+	Fn      TokenType = "fn"
+	Case    TokenType = "case"
+	Of      TokenType = "of"
+	When    TokenType = "when"
+	End     TokenType = "end"
+	Receive TokenType = "receive"
+	After   TokenType = "after"
+	Yield   TokenType = "yield"
 )
 
 var keywords = map[string]Token{
-	"let":    LetToken,
-	"return": ReturnToken,
-	"true":   TrueToken,
-	"false":  FalseToken,
-	"if":     IfToken,
-	"else":   ElseToken,
-	"fn":     FnToken,
+	"let":     LetToken,
+	"return":  ReturnToken,
+	"true":    TrueToken,
+	"false":   FalseToken,
+	"if":      IfToken,
+	"else":    ElseToken,
+	"fn":      FnToken,
+	"case":    CaseToken,
+	"of":      OfToken,
+	"when":    WhenToken,
+	"end":     EndToken,
+	"receive": ReceiveToken,
+	"after":   AfterToken,
+	"yield":   YieldToken,
 }
 
 // Other
@@ -90,7 +114,10 @@ const (
 	Invalid    TokenType = "invalid"
 	Identifier TokenType = "identifier"
 	Integer    TokenType = "integer"
+	Float      TokenType = "float"
 	String     TokenType = "string"
+	Comment    TokenType = "comment"
+	Template   TokenType = "template"
 )
 
 // Predefined tokens
@@ -126,4 +153,14 @@ var (
 	LeftBracketToken      = Token{Type: LeftBracket, Literal: "["}
 	RightBracketToken     = Token{Type: RightBracket, Literal: "]"}
 	ColonToken            = Token{Type: Colon, Literal: ":"}
+	ArrowToken            = Token{Type: Arrow, Literal: "->"}
+	EllipsisToken         = Token{Type: Ellipsis, Literal: "..."}
+	DotToken              = Token{Type: Dot, Literal: "."}
+	CaseToken             = Token{Type: Case, Literal: "case"}
+	OfToken               = Token{Type: Of, Literal: "of"}
+	WhenToken             = Token{Type: When, Literal: "when"}
+	EndToken              = Token{Type: End, Literal: "end"}
+	ReceiveToken          = Token{Type: Receive, Literal: "receive"}
+	AfterToken            = Token{Type: After, Literal: "after"}
+	YieldToken            = Token{Type: Yield, Literal: "yield"}
 )
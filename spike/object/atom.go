@@ -0,0 +1,69 @@
+package object
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var atoms = struct {
+	sync.Mutex
+	byName map[string]*Atom
+}{byName: map[string]*Atom{}}
+
+// Atom is a named symbolic constant. Atoms are interned: NewAtom returns the
+// same pointer for the same name, so equality is a pointer comparison.
+type Atom struct {
+	Name string
+}
+
+// NewAtom returns the interned atom with the given name.
+func NewAtom(name string) *Atom {
+	atoms.Lock()
+	defer atoms.Unlock()
+
+	atom, ok := atoms.byName[name]
+	if !ok {
+		atom = &Atom{Name: name}
+		atoms.byName[name] = atom
+	}
+
+	return atom
+}
+
+func (atom *Atom) Type() ObjectType {
+	return AtomType
+}
+
+func (atom *Atom) Inspect() string {
+	return ":" + atom.Name
+}
+
+func (atom *Atom) Equal(other Object) bool {
+	return atom == other
+}
+
+// Compare orders atoms alphabetically by name.
+func (atom *Atom) Compare(other Comparable) (Ordering, error) {
+	otherAtom, ok := other.(*Atom)
+	if !ok {
+		return EQ, errors.Errorf("unable to compare %s with %s", AtomType, other.(Object).Type())
+	}
+
+	return Ordering(strings.Compare(atom.Name, otherAtom.Name)), nil
+}
+
+func (atom *Atom) GetHashKey() HashKey {
+	h := fnv.New64a()
+	_, err := h.Write([]byte(atom.Name))
+	if err != nil {
+		panic(err)
+	}
+
+	return HashKey{
+		Type:  AtomType,
+		Value: h.Sum64(),
+	}
+}
@@ -0,0 +1,28 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewAtom_interns(t *testing.T) {
+	assert.True(t, NewAtom("person") == NewAtom("person"))
+	assert.False(t, NewAtom("person") == NewAtom("place"))
+}
+
+func Test_Atom_Equal(t *testing.T) {
+	assert.True(t, NewAtom("ok").Equal(NewAtom("ok")))
+	assert.False(t, NewAtom("ok").Equal(NewAtom("error")))
+	assert.False(t, NewAtom("ok").Equal(&Atom{Name: "ok"}))
+	assert.False(t, NewAtom("ok").Equal(&String{Value: "ok"}))
+}
+
+func Test_Atom_GetHashKey(t *testing.T) {
+	assert.Equal(t, NewAtom("ok").GetHashKey(), NewAtom("ok").GetHashKey())
+	assert.NotEqual(t, NewAtom("ok").GetHashKey(), (&String{Value: "ok"}).GetHashKey())
+}
+
+func Test_Atom_Inspect(t *testing.T) {
+	assert.Equal(t, ":person", NewAtom("person").Inspect())
+}
@@ -0,0 +1,188 @@
+package object
+
+import (
+	"hash/fnv"
+	"math"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// bigIntegerHashKeyType separates hash keys of big integers from keys of
+// int64 integers, which use the integer value itself as the key.
+const bigIntegerHashKeyType ObjectType = "bigInteger"
+
+// BigInteger holds integers that do not fit in int64. Arithmetic on integers
+// promotes results to BigInteger on overflow and demotes them back to Integer
+// when they fit again, so a BigInteger never holds an int64-sized value.
+type BigInteger struct {
+	Value *big.Int
+}
+
+func (integer *BigInteger) Type() ObjectType {
+	return IntegerType
+}
+
+func (integer *BigInteger) Inspect() string {
+	return integer.Value.String()
+}
+
+func (integer *BigInteger) Equal(other Object) bool {
+	switch other := other.(type) {
+	case *BigInteger:
+		return integer.Value.Cmp(other.Value) == 0
+	case *Integer:
+		return integer.Value.IsInt64() && integer.Value.Int64() == other.Value
+	case *Float:
+		value, _ := ToFloat(integer)
+		return value == other.Value
+	}
+
+	return false
+}
+
+func (integer *BigInteger) Compare(other Comparable) (Ordering, error) {
+	switch other := other.(type) {
+	case *BigInteger, *Integer:
+		return Ordering(integer.Value.Cmp(toBigInt(other.(Object)))), nil
+	case *Float:
+		value, _ := ToFloat(integer)
+		return compareFloats(value, other.Value), nil
+	}
+
+	return EQ, errors.Errorf("unable to compare %s with %s", IntegerType, other.(Object).Type())
+}
+
+func (integer *BigInteger) GetHashKey() HashKey {
+	if integer.Value.IsInt64() {
+		return (&Integer{Value: integer.Value.Int64()}).GetHashKey()
+	}
+
+	h := fnv.New64a()
+	_, err := h.Write([]byte{byte(integer.Value.Sign() + 1)})
+	if err != nil {
+		panic(err)
+	}
+	_, err = h.Write(integer.Value.Bytes())
+	if err != nil {
+		panic(err)
+	}
+
+	return HashKey{
+		Type:  bigIntegerHashKeyType,
+		Value: h.Sum64(),
+	}
+}
+
+// IsInteger reports whether obj is an Integer or a BigInteger.
+func IsInteger(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *BigInteger:
+		return true
+	}
+
+	return false
+}
+
+// NewInteger returns value as an Integer when it fits in int64 and as a
+// BigInteger otherwise.
+func NewInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+
+	return &BigInteger{Value: value}
+}
+
+// IntegerOperation applies one of the +, -, * and / operators to two integers,
+// switching to arbitrary precision when the result overflows int64.
+func IntegerOperation(operator string, left, right Object) (Object, error) {
+	leftInteger, leftIsSmall := left.(*Integer)
+	rightInteger, rightIsSmall := right.(*Integer)
+	if leftIsSmall && rightIsSmall {
+		result, ok, err := smallIntegerOperation(operator, leftInteger.Value, rightInteger.Value)
+		if ok || err != nil {
+			return result, err
+		}
+	}
+
+	leftValue := toBigInt(left)
+	rightValue := toBigInt(right)
+	result := new(big.Int)
+
+	switch operator {
+	case "+":
+		result.Add(leftValue, rightValue)
+	case "-":
+		result.Sub(leftValue, rightValue)
+	case "*":
+		result.Mul(leftValue, rightValue)
+	case "/":
+		if rightValue.Sign() == 0 {
+			return nil, errors.New("division by zero")
+		}
+		result.Quo(leftValue, rightValue)
+	default:
+		return nil, errors.Errorf("unknown integer operator: %s", operator)
+	}
+
+	return NewInteger(result), nil
+}
+
+// smallIntegerOperation computes the result in int64 arithmetic. It reports
+// false when the result would overflow.
+func smallIntegerOperation(operator string, left, right int64) (Object, bool, error) {
+	switch operator {
+	case "+":
+		result := left + right
+		if (right > 0 && result < left) || (right < 0 && result > left) {
+			return nil, false, nil
+		}
+		return &Integer{Value: result}, true, nil
+	case "-":
+		result := left - right
+		if (right > 0 && result > left) || (right < 0 && result < left) {
+			return nil, false, nil
+		}
+		return &Integer{Value: result}, true, nil
+	case "*":
+		if left == 0 || right == 0 {
+			return &Integer{Value: 0}, true, nil
+		}
+		result := left * right
+		if result/right != left || (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64) {
+			return nil, false, nil
+		}
+		return &Integer{Value: result}, true, nil
+	case "/":
+		if right == 0 {
+			return nil, false, errors.New("division by zero")
+		}
+		if left == math.MinInt64 && right == -1 {
+			return nil, false, nil
+		}
+		return &Integer{Value: left / right}, true, nil
+	}
+
+	return nil, false, errors.Errorf("unknown integer operator: %s", operator)
+}
+
+// NegateInteger returns -obj for an Integer or a BigInteger.
+func NegateInteger(obj Object) Object {
+	if integer, ok := obj.(*Integer); ok && integer.Value != math.MinInt64 {
+		return &Integer{Value: -integer.Value}
+	}
+
+	return NewInteger(new(big.Int).Neg(toBigInt(obj)))
+}
+
+func toBigInt(obj Object) *big.Int {
+	switch obj := obj.(type) {
+	case *BigInteger:
+		return obj.Value
+	case *Integer:
+		return big.NewInt(obj.Value)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bigInteger(value string) *BigInteger {
+	integer, _ := new(big.Int).SetString(value, 10)
+	return &BigInteger{Value: integer}
+}
+
+func Test_IntegerOperation(t *testing.T) {
+	testCases := []struct {
+		left           Object
+		operator       string
+		right          Object
+		expectedResult Object
+	}{
+		{
+			left:           &Integer{Value: 2},
+			operator:       "+",
+			right:          &Integer{Value: 3},
+			expectedResult: &Integer{Value: 5},
+		},
+		{
+			left:           &Integer{Value: math.MaxInt64},
+			operator:       "+",
+			right:          &Integer{Value: 1},
+			expectedResult: bigInteger("9223372036854775808"),
+		},
+		{
+			left:           &Integer{Value: math.MinInt64},
+			operator:       "-",
+			right:          &Integer{Value: 1},
+			expectedResult: bigInteger("-9223372036854775809"),
+		},
+		{
+			left:           &Integer{Value: math.MaxInt64},
+			operator:       "*",
+			right:          &Integer{Value: 2},
+			expectedResult: bigInteger("18446744073709551614"),
+		},
+		{
+			left:           &Integer{Value: math.MinInt64},
+			operator:       "/",
+			right:          &Integer{Value: -1},
+			expectedResult: bigInteger("9223372036854775808"),
+		},
+		{
+			left:           bigInteger("9223372036854775808"),
+			operator:       "-",
+			right:          &Integer{Value: 1},
+			expectedResult: &Integer{Value: math.MaxInt64},
+		},
+		{
+			left:           bigInteger("18446744073709551614"),
+			operator:       "/",
+			right:          bigInteger("-9223372036854775807"),
+			expectedResult: &Integer{Value: -2},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCaseName := fmt.Sprintf("%s %s %s", testCase.left.Inspect(), testCase.operator, testCase.right.Inspect())
+		t.Run(testCaseName, func(t *testing.T) {
+			result, err := IntegerOperation(testCase.operator, testCase.left, testCase.right)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedResult, result)
+		})
+	}
+}
+
+func Test_IntegerOperation_divisionByZero(t *testing.T) {
+	_, err := IntegerOperation("/", bigInteger("9223372036854775808"), &Integer{Value: 0})
+
+	assert.EqualError(t, err, "division by zero")
+}
+
+func Test_NegateInteger(t *testing.T) {
+	assert.Equal(t, bigInteger("9223372036854775808"), NegateInteger(&Integer{Value: math.MinInt64}))
+	assert.Equal(t, &Integer{Value: math.MinInt64}, NegateInteger(bigInteger("9223372036854775808")))
+}
+
+func Test_BigInteger_Equal(t *testing.T) {
+	big := bigInteger("9223372036854775808")
+
+	assert.True(t, big.Equal(bigInteger("9223372036854775808")))
+	assert.False(t, big.Equal(&Integer{Value: math.MaxInt64}))
+	assert.False(t, (&Integer{Value: math.MaxInt64}).Equal(big))
+	assert.True(t, big.Equal(&Float{Value: 9223372036854775808}))
+	assert.False(t, big.Equal(&True))
+}
+
+func Test_BigInteger_Compare(t *testing.T) {
+	big := bigInteger("9223372036854775808")
+
+	ordering, err := big.Compare(&Integer{Value: math.MaxInt64})
+	assert.NoError(t, err)
+	assert.Equal(t, GT, ordering)
+
+	ordering, err = (&Integer{Value: math.MaxInt64}).Compare(big)
+	assert.NoError(t, err)
+	assert.Equal(t, LT, ordering)
+
+	ordering, err = bigInteger("-9223372036854775809").Compare(&Float{Value: 0.5})
+	assert.NoError(t, err)
+	assert.Equal(t, LT, ordering)
+}
+
+func Test_BigInteger_GetHashKey(t *testing.T) {
+	assert.Equal(t, bigInteger("9223372036854775808").GetHashKey(), bigInteger("9223372036854775808").GetHashKey())
+	assert.NotEqual(t, bigInteger("9223372036854775808").GetHashKey(), bigInteger("-9223372036854775808").GetHashKey())
+	assert.Equal(t, (&Integer{Value: 5}).GetHashKey(), (&BigInteger{Value: big.NewInt(5)}).GetHashKey())
+}
@@ -1,10 +1,17 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
 
 type BuiltinFunction struct {
 	Name     string
 	Function func(args ...Object) (Object, error)
+	// HostFunction is set instead of Function by builtins that need the
+	// engine executing them, e.g. to start processes.
+	HostFunction func(host Host, args ...Object) (Object, error)
 }
 
 func (builtin *BuiltinFunction) Type() ObjectType {
@@ -15,6 +22,20 @@ func (builtin *BuiltinFunction) Inspect() string {
 	return fmt.Sprintf("builtin(%s)", builtin.Name)
 }
 
-func (builtin *BuiltinFunction) Equal(Object) bool {
-	panic("implement me")
+func (builtin *BuiltinFunction) Equal(other Object) bool {
+	return builtin == other
+}
+
+// Call runs the builtin. Host may be nil for engines that can not run
+// processes, builtins needing it then return an error.
+func (builtin *BuiltinFunction) Call(host Host, args ...Object) (Object, error) {
+	if builtin.HostFunction == nil {
+		return builtin.Function(args...)
+	}
+
+	if host == nil {
+		return nil, errors.Errorf("%s is not supported by this engine", builtin.Name)
+	}
+
+	return builtin.HostFunction(host, args...)
 }
@@ -2,6 +2,11 @@ package object
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
 	"github.com/pkg/errors"
 )
 
@@ -19,17 +24,26 @@ var Builtins = []*BuiltinFunction{
 
 			case *Array:
 				return &Integer{Value: int64(len(argument.Elements))}, nil
+
+			case *Tuple:
+				return &Integer{Value: int64(len(argument.Elements))}, nil
 			}
 
-			stringObject := args[0].(*String)
-			return &Integer{Value: int64(len(stringObject.Value))}, nil
+			return nil, errors.Errorf("len is not supported for %s", args[0].Type())
 		},
 	},
 	{
 		Name: "print",
 		Function: func(args ...Object) (Object, error) {
-			stringObject := args[0].(*String)
-			fmt.Printf(stringObject.Value)
+			if len(args) != 1 {
+				return nil, errors.New("1 function argument expected")
+			}
+
+			stringObject, ok := args[0].(*String)
+			if !ok {
+				return nil, errors.Errorf("print is not supported for %s", args[0].Type())
+			}
+			fmt.Print(stringObject.Value)
 
 			return nil, nil
 		},
@@ -46,6 +60,214 @@ var Builtins = []*BuiltinFunction{
 			return &String{Value: result}, nil
 		},
 	},
+	{
+		Name:     "str",
+		Function: toString,
+	},
+	{
+		Name: "int",
+		Function: func(args ...Object) (Object, error) {
+			if len(args) != 1 {
+				return nil, errors.New("1 function argument expected")
+			}
+
+			switch argument := args[0].(type) {
+			case *Integer, *BigInteger:
+				return argument, nil
+			case *Float:
+				return floatToInteger(math.Trunc(argument.Value))
+			case *String:
+				value, ok := new(big.Int).SetString(strings.TrimSpace(argument.Value), 10)
+				if !ok {
+					return nil, errors.Errorf("unable to convert %s to integer", argument.Inspect())
+				}
+				return NewInteger(value), nil
+			}
+
+			return nil, errors.Errorf("unable to convert %s to integer", args[0].Type())
+		},
+	},
+	{
+		Name: "float",
+		Function: func(args ...Object) (Object, error) {
+			if len(args) != 1 {
+				return nil, errors.New("1 function argument expected")
+			}
+
+			switch argument := args[0].(type) {
+			case *Integer, *BigInteger:
+				value, _ := ToFloat(argument)
+				return &Float{Value: value}, nil
+			case *Float:
+				return argument, nil
+			case *String:
+				value, err := strconv.ParseFloat(strings.TrimSpace(argument.Value), 64)
+				if err != nil {
+					return nil, errors.Errorf("unable to convert %s to float", argument.Inspect())
+				}
+				return &Float{Value: value}, nil
+			}
+
+			return nil, errors.Errorf("unable to convert %s to float", args[0].Type())
+		},
+	},
+	{
+		Name: "round",
+		Function: func(args ...Object) (Object, error) {
+			if len(args) != 1 && len(args) != 2 {
+				return nil, errors.New("1 or 2 function arguments expected")
+			}
+
+			value, ok := ToFloat(args[0])
+			if !ok {
+				return nil, errors.Errorf("unable to round %s", args[0].Type())
+			}
+
+			if len(args) == 1 {
+				return floatToInteger(math.Round(value))
+			}
+
+			digits, ok := args[1].(*Integer)
+			if !ok {
+				return nil, errors.Errorf("number of digits must be an integer, got %s", args[1].Type())
+			}
+
+			scale := math.Pow(10, float64(digits.Value))
+			return &Float{Value: math.Round(value*scale) / scale}, nil
+		},
+	},
+	{
+		// process builds the definition produced by a process statement. The
+		// name is a keyword, so it can only be called by the desugared code.
+		Name:     ProcessBuiltinName,
+		Function: newProcessDefinition,
+	},
+	{
+		Name: "spawn",
+		HostFunction: func(host Host, args ...Object) (Object, error) {
+			if len(args) < 1 {
+				return nil, errors.New("at least 1 function argument expected")
+			}
+
+			return Start(host, args[0], args[1:], false)
+		},
+	},
+	{
+		Name: "send",
+		Function: func(args ...Object) (Object, error) {
+			if len(args) != 2 {
+				return nil, errors.New("2 function arguments expected")
+			}
+
+			pid, ok := args[0].(*Pid)
+			if !ok {
+				return nil, errors.Errorf("unable to send to %s", args[0].Type())
+			}
+
+			pid.process.Mailbox.Send(args[1])
+			return args[1], nil
+		},
+	},
+	{
+		Name: "self",
+		HostFunction: func(host Host, args ...Object) (Object, error) {
+			if len(args) != 0 {
+				return nil, errors.New("0 function arguments expected")
+			}
+
+			return host.Process().Pid, nil
+		},
+	},
+	{
+		Name: "spawn_link",
+		HostFunction: func(host Host, args ...Object) (Object, error) {
+			if len(args) < 1 {
+				return nil, errors.New("at least 1 function argument expected")
+			}
+
+			return Start(host, args[0], args[1:], true)
+		},
+	},
+	{
+		Name: "monitor",
+		HostFunction: func(host Host, args ...Object) (Object, error) {
+			if len(args) != 1 {
+				return nil, errors.New("1 function argument expected")
+			}
+
+			pid, ok := args[0].(*Pid)
+			if !ok {
+				return nil, errors.Errorf("unable to monitor %s", args[0].Type())
+			}
+
+			pid.process.Monitor(host.Process())
+			return pid, nil
+		},
+	},
+	{
+		Name:     "supervisor",
+		Function: newSupervisor,
+	},
+	{
+		Name: "next",
+		Function: func(args ...Object) (Object, error) {
+			if len(args) != 1 && len(args) != 2 {
+				return nil, errors.New("1 or 2 function arguments expected")
+			}
+
+			generator, ok := args[0].(*Generator)
+			if !ok {
+				return nil, errors.Errorf("unable to resume %s", args[0].Type())
+			}
+
+			var sent Object = &NullObject
+			if len(args) == 2 {
+				sent = args[1]
+			}
+
+			return generator.Next(sent)
+		},
+	},
+	{
+		Name: "done",
+		Function: func(args ...Object) (Object, error) {
+			if len(args) != 1 {
+				return nil, errors.New("1 function argument expected")
+			}
+
+			generator, ok := args[0].(*Generator)
+			if !ok {
+				return nil, errors.Errorf("unable to resume %s", args[0].Type())
+			}
+
+			if generator.Done() {
+				return &True, nil
+			}
+			return &False, nil
+		},
+	},
+	{
+		// $str converts interpolated expressions to strings. The name is not
+		// an identifier, so programs can not rebind it the way they can str.
+		Name:     ToStringBuiltinName,
+		Function: toString,
+	},
+}
+
+const ProcessBuiltinName = "process"
+
+const ToStringBuiltinName = "$str"
+
+func toString(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, errors.New("1 function argument expected")
+	}
+
+	if str, ok := args[0].(*String); ok {
+		return str, nil
+	}
+
+	return &String{Value: args[0].Inspect()}, nil
 }
 
 func GetBuiltinByName(name string) *BuiltinFunction {
@@ -57,3 +279,56 @@ func GetBuiltinByName(name string) *BuiltinFunction {
 
 	return nil
 }
+
+// newProcessDefinition expects the name, the init function and a hash mapping
+// handler names to (kind, function) tuples.
+func newProcessDefinition(args ...Object) (Object, error) {
+	if len(args) != 3 {
+		return nil, errors.New("3 function arguments expected")
+	}
+
+	name, nameOk := args[0].(*String)
+	handlers, handlersOk := args[2].(*Hash)
+	if !nameOk || !handlersOk {
+		return nil, errors.New("invalid process definition")
+	}
+
+	definition := &ProcessDefinition{
+		Name:     name.Value,
+		Init:     args[1],
+		Handlers: make(map[string]ProcessHandler, len(handlers.Pairs)),
+	}
+
+	for _, pair := range handlers.Pairs {
+		handlerName, nameOk := pair.Key.(*String)
+		handler, handlerOk := pair.Value.(*Tuple)
+		if !nameOk || !handlerOk || len(handler.Elements) != 2 {
+			return nil, errors.New("invalid process handler")
+		}
+
+		kind, ok := handler.Elements[0].(*Atom)
+		if !ok {
+			return nil, errors.New("invalid process handler")
+		}
+
+		definition.Handlers[handlerName.Value] = ProcessHandler{
+			Kind:     HandlerKind(kind.Name),
+			Function: handler.Elements[1],
+		}
+	}
+
+	return definition, nil
+}
+
+func floatToInteger(value float64) (Object, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, errors.Errorf("unable to convert %s to integer", (&Float{Value: value}).Inspect())
+	}
+
+	if value >= math.MinInt64 && value < math.MaxInt64 {
+		return &Integer{Value: int64(value)}, nil
+	}
+
+	integer, _ := big.NewFloat(value).Int(nil)
+	return NewInteger(integer), nil
+}
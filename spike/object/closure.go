@@ -8,11 +8,11 @@ type Closure struct {
 }
 
 func (closure *Closure) Type() ObjectType {
-	return ClosureType
+	return FunctionType
 }
 
 func (closure *Closure) Inspect() string {
-	return fmt.Sprintf("Closure[%p]", closure)
+	return fmt.Sprintf("fn/%d", closure.Function.ParametersCount)
 }
 
 func (closure *Closure) Equal(other Object) bool {
@@ -0,0 +1,60 @@
+package object
+
+import (
+	"github.com/pkg/errors"
+)
+
+// CompareObjects applies the comparison operator, one of ==, !=, <, >, <= and
+// >=, in the same way for every engine. Numbers compare by value whatever
+// their type, other values are only equal to values of their own type and
+// can only be ordered when they are Comparable.
+func CompareObjects(operator string, left, right Object) (bool, error) {
+	switch operator {
+	case "==":
+		return left.Equal(right), nil
+	case "!=":
+		return !left.Equal(right), nil
+	}
+
+	leftFloat, leftIsNumber := ToFloat(left)
+	rightFloat, rightIsNumber := ToFloat(right)
+	if leftIsNumber && rightIsNumber && (left.Type() == FloatType || right.Type() == FloatType) {
+		switch operator {
+		case "<":
+			return leftFloat < rightFloat, nil
+		case ">":
+			return leftFloat > rightFloat, nil
+		case "<=":
+			return leftFloat <= rightFloat, nil
+		case ">=":
+			return leftFloat >= rightFloat, nil
+		}
+
+		return false, errors.Errorf("unknown operator: %s", operator)
+	}
+
+	leftComparable, leftOk := left.(Comparable)
+	rightComparable, rightOk := right.(Comparable)
+	sameType := left.Type() == right.Type() || (leftIsNumber && rightIsNumber)
+	if !leftOk || !rightOk || !sameType {
+		return false, errors.Errorf("unable to compare %s with %s", left.Type(), right.Type())
+	}
+
+	ordering, err := leftComparable.Compare(rightComparable)
+	if err != nil {
+		return false, err
+	}
+
+	switch operator {
+	case "<":
+		return ordering == LT, nil
+	case ">":
+		return ordering == GT, nil
+	case "<=":
+		return ordering != GT, nil
+	case ">=":
+		return ordering != LT, nil
+	}
+
+	return false, errors.Errorf("unknown operator: %s", operator)
+}
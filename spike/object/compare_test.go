@@ -0,0 +1,42 @@
+package object
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CompareObjects(t *testing.T) {
+	testCases := []struct {
+		operator string
+		left     Object
+		right    Object
+		expected bool
+	}{
+		{operator: "==", left: &Integer{Value: 2}, right: &Float{Value: 2}, expected: true},
+		{operator: "!=", left: &String{Value: "a"}, right: &Atom{Name: "a"}, expected: true},
+		{operator: "<", left: &Integer{Value: 1}, right: &Float{Value: 1.5}, expected: true},
+		{operator: "<=", left: &Float{Value: 1.5}, right: &Float{Value: 1.5}, expected: true},
+		{operator: ">", left: &Integer{Value: 1}, right: &Integer{Value: 2}, expected: false},
+		{operator: ">=", left: &Tuple{Elements: []Object{&Integer{Value: 2}}}, right: &Tuple{}, expected: true},
+	}
+
+	for _, testCase := range testCases {
+		testCaseName := fmt.Sprintf("%s %s %s", testCase.left.Inspect(), testCase.operator, testCase.right.Inspect())
+		t.Run(testCaseName, func(t *testing.T) {
+			result, err := CompareObjects(testCase.operator, testCase.left, testCase.right)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
+
+func Test_CompareObjects_withErrors(t *testing.T) {
+	_, err := CompareObjects("<", &Integer{Value: 1}, &String{Value: "a"})
+	assert.EqualError(t, err, "unable to compare integer with string")
+
+	_, err = CompareObjects(">", &Boolean{Value: true}, &Boolean{Value: false})
+	assert.EqualError(t, err, "unable to compare boolean with boolean")
+}
@@ -6,9 +6,13 @@ import (
 )
 
 type CompiledFunction struct {
+	Name            string
 	Instructions    code.Instructions
 	LocalsCount     int
 	ParametersCount int
+	LineTable       code.LineTable
+	// Generator is set for functions containing yield.
+	Generator bool
 }
 
 func (function *CompiledFunction) Type() ObjectType {
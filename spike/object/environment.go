@@ -7,6 +7,9 @@ import (
 type Environment struct {
 	variables map[string]Object
 	inner     *Environment
+	// meter enforces the limits of the evaluation running in the environment,
+	// nil when there are none.
+	meter *Meter
 }
 
 func NewEnvironment() *Environment {
@@ -16,13 +19,32 @@ func NewEnvironment() *Environment {
 
 func ExtendEnvironment(environment *Environment) *Environment {
 	variables := make(map[string]Object)
-	return &Environment{variables: variables, inner: environment}
+	return &Environment{variables: variables, inner: environment, meter: environment.meter}
+}
+
+// WithMeter returns an environment sharing the bindings of e in which
+// evaluation is metered by meter. Environments extending it share meter.
+func (e Environment) WithMeter(meter *Meter) *Environment {
+	e.meter = meter
+	return &e
+}
+
+// Meter returns the meter of evaluation in e, nil when it is not limited.
+func (e Environment) Meter() *Meter {
+	return e.meter
 }
 
 func (e Environment) Set(name string, value Object) {
 	e.variables[name] = value
 }
 
+// Defines reports whether name is bound in e itself, ignoring the
+// environments it extends.
+func (e Environment) Defines(name string) bool {
+	_, ok := e.variables[name]
+	return ok
+}
+
 func (e Environment) Get(name string) (Object, error) {
 	if value, ok := e.variables[name]; ok {
 		return value, nil
@@ -0,0 +1,83 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Float struct {
+	Value float64
+}
+
+func (float *Float) Type() ObjectType {
+	return FloatType
+}
+
+func (float *Float) Inspect() string {
+	inspected := strconv.FormatFloat(float.Value, 'g', -1, 64)
+	if strings.ContainsAny(inspected, ".eIN") {
+		return inspected
+	}
+
+	return inspected + ".0"
+}
+
+func (float *Float) Equal(other Object) bool {
+	otherValue, ok := ToFloat(other)
+	if !ok {
+		return false
+	}
+
+	return float.Value == otherValue
+}
+
+func (float *Float) Compare(other Comparable) (Ordering, error) {
+	otherValue, ok := ToFloat(other.(Object))
+	if !ok {
+		return EQ, errors.Errorf("unable to compare %s with %s", FloatType, other.(Object).Type())
+	}
+
+	return compareFloats(float.Value, otherValue), nil
+}
+
+// GetHashKey returns the same key as the equal Integer for whole numbers, so
+// that 1 and 1.0 address the same hash entry.
+func (float *Float) GetHashKey() HashKey {
+	if float.Value == math.Trunc(float.Value) && float.Value >= math.MinInt64 && float.Value < math.MaxInt64 {
+		return (&Integer{Value: int64(float.Value)}).GetHashKey()
+	}
+
+	return HashKey{
+		Type:  FloatType,
+		Value: math.Float64bits(float.Value),
+	}
+}
+
+// ToFloat converts numeric objects to float64.
+func ToFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Float:
+		return obj.Value, true
+	case *Integer:
+		return float64(obj.Value), true
+	case *BigInteger:
+		value, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return value, true
+	}
+
+	return 0, false
+}
+
+func compareFloats(left, right float64) Ordering {
+	if left > right {
+		return GT
+	} else if left < right {
+		return LT
+	}
+
+	return EQ
+}
@@ -0,0 +1,72 @@
+package object
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Float_Inspect(t *testing.T) {
+	assert.Equal(t, "1.5", (&Float{Value: 1.5}).Inspect())
+	assert.Equal(t, "2.0", (&Float{Value: 2}).Inspect())
+	assert.Equal(t, "1e-07", (&Float{Value: 0.0000001}).Inspect())
+}
+
+func Test_Float_Equal(t *testing.T) {
+	testCases := []struct {
+		left           Object
+		right          Object
+		expectedResult bool
+	}{
+		{
+			left:           &Float{Value: 1.5},
+			right:          &Float{Value: 1.5},
+			expectedResult: true,
+		},
+		{
+			left:           &Float{Value: 1.5},
+			right:          &Float{Value: 2.5},
+			expectedResult: false,
+		},
+		{
+			left:           &Float{Value: 2},
+			right:          &Integer{Value: 2},
+			expectedResult: true,
+		},
+		{
+			left:           &Integer{Value: 2},
+			right:          &Float{Value: 2},
+			expectedResult: true,
+		},
+		{
+			left:           &Float{Value: 1},
+			right:          &True,
+			expectedResult: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCaseName := fmt.Sprintf("%v == %v => %t", testCase.left, testCase.right, testCase.expectedResult)
+		t.Run(testCaseName, func(t *testing.T) {
+			result := testCase.left.Equal(testCase.right)
+
+			assert.Equal(t, testCase.expectedResult, result)
+		})
+	}
+}
+
+func Test_Float_Compare(t *testing.T) {
+	ordering, err := (&Float{Value: 1.5}).Compare(&Integer{Value: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, LT, ordering)
+
+	ordering, err = (&Integer{Value: 2}).Compare(&Float{Value: 1.5})
+	assert.NoError(t, err)
+	assert.Equal(t, GT, ordering)
+}
+
+func Test_Float_GetHashKey(t *testing.T) {
+	assert.Equal(t, (&Integer{Value: 3}).GetHashKey(), (&Float{Value: 3}).GetHashKey())
+	assert.NotEqual(t, (&Float{Value: 3.5}).GetHashKey(), (&Float{Value: 3.25}).GetHashKey())
+}
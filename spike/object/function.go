@@ -1,14 +1,16 @@
 package object
 
 import (
+	"fmt"
 	"spike-interpreter-go/spike/parser/ast"
-	"strings"
 )
 
 type Function struct {
 	Parameters  []*ast.Identifier
 	Body        ast.Statement
 	Environment *Environment
+	// Generator is set for functions containing yield.
+	Generator bool
 }
 
 func (function *Function) Type() ObjectType {
@@ -16,9 +18,7 @@ func (function *Function) Type() ObjectType {
 }
 
 func (function *Function) Inspect() string {
-	out := strings.Builder{}
-
-	return out.String()
+	return fmt.Sprintf("fn/%d", len(function.Parameters))
 }
 
 func (function *Function) Equal(other Object) bool {
@@ -0,0 +1,111 @@
+package object
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Resume runs a suspended generator until its next yield, passing sent as the
+// value of the yield expression it stopped at. It returns the yielded value,
+// or the result of the function and true once the body has finished.
+type Resume func(sent Object) (value Object, finished bool, err error)
+
+// Generator is returned by calls to functions containing yield. The body only
+// runs when next is called, up to the following yield, so values can be
+// produced lazily. Engines provide the way of resuming it.
+//
+// A generator whose function returns another generator continues with it, so
+// generators can be defined recursively without growing the stack.
+type Generator struct {
+	Name   string
+	resume Resume
+
+	mutex   sync.Mutex
+	running bool
+	done    bool
+}
+
+func NewGenerator(name string, resume Resume) *Generator {
+	return &Generator{Name: name, resume: resume}
+}
+
+func (generator *Generator) Type() ObjectType {
+	return GeneratorType
+}
+
+func (generator *Generator) Inspect() string {
+	name := generator.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+
+	return fmt.Sprintf("generator(%s)", name)
+}
+
+func (generator *Generator) Equal(other Object) bool {
+	return generator == other
+}
+
+// Done reports whether the body of the generator has finished.
+func (generator *Generator) Done() bool {
+	generator.mutex.Lock()
+	defer generator.mutex.Unlock()
+
+	return generator.done
+}
+
+// Next resumes the generator. Finished generators return null, generators
+// failing are finished as well.
+func (generator *Generator) Next(sent Object) (Object, error) {
+	generator.mutex.Lock()
+	if generator.done {
+		generator.mutex.Unlock()
+		return &NullObject, nil
+	}
+	if generator.running {
+		generator.mutex.Unlock()
+		return nil, errors.Errorf("%s is already running", generator.Inspect())
+	}
+	generator.running = true
+	generator.mutex.Unlock()
+
+	value, finished, err := generator.resume(sent)
+	for err == nil && finished {
+		delegate, ok := value.(*Generator)
+		if !ok || !generator.continueWith(delegate) {
+			break
+		}
+		value, finished, err = generator.resume(sent)
+	}
+
+	generator.mutex.Lock()
+	generator.running = false
+	generator.done = finished || err != nil
+	generator.mutex.Unlock()
+
+	return value, err
+}
+
+// continueWith takes over the execution of delegate, which is finished in
+// turn. Running or finished delegates are returned as they are.
+func (generator *Generator) continueWith(delegate *Generator) bool {
+	if delegate == generator {
+		return false
+	}
+
+	delegate.mutex.Lock()
+	defer delegate.mutex.Unlock()
+
+	if delegate.running || delegate.done {
+		return false
+	}
+	delegate.done = true
+
+	generator.mutex.Lock()
+	generator.resume = delegate.resume
+	generator.mutex.Unlock()
+
+	return true
+}
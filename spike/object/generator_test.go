@@ -0,0 +1,76 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// countdown yields from down to 1 and then returns result.
+func countdown(from int64, result Object) *Generator {
+	return NewGenerator("countdown", func(Object) (Object, bool, error) {
+		if from == 0 {
+			return result, true, nil
+		}
+		from--
+		return &Integer{Value: from + 1}, false, nil
+	})
+}
+
+func Test_Generator_Next(t *testing.T) {
+	generator := countdown(2, &String{Value: "done"})
+
+	value, err := generator.Next(&NullObject)
+	assert.NoError(t, err)
+	assert.Equal(t, &Integer{Value: 2}, value)
+
+	value, err = generator.Next(&NullObject)
+	assert.NoError(t, err)
+	assert.Equal(t, &Integer{Value: 1}, value)
+	assert.False(t, generator.Done())
+
+	value, err = generator.Next(&NullObject)
+	assert.NoError(t, err)
+	assert.Equal(t, &String{Value: "done"}, value)
+	assert.True(t, generator.Done())
+
+	value, err = generator.Next(&NullObject)
+	assert.NoError(t, err)
+	assert.Equal(t, &NullObject, value)
+}
+
+func Test_Generator_Next_continuesWithReturnedGenerator(t *testing.T) {
+	generator := countdown(1, countdown(1, &String{Value: "done"}))
+
+	values := make([]Object, 0)
+	for !generator.Done() {
+		value, err := generator.Next(&NullObject)
+		assert.NoError(t, err)
+		values = append(values, value)
+	}
+
+	assert.Equal(t, []Object{&Integer{Value: 1}, &Integer{Value: 1}, &String{Value: "done"}}, values)
+}
+
+func Test_Generator_Next_whileRunning(t *testing.T) {
+	var generator *Generator
+	generator = NewGenerator("recursive", func(Object) (Object, bool, error) {
+		value, err := generator.Next(&NullObject)
+		return value, false, err
+	})
+
+	_, err := generator.Next(&NullObject)
+	assert.EqualError(t, err, "generator(recursive) is already running")
+	assert.True(t, generator.Done())
+}
+
+func Test_Generator_Next_withError(t *testing.T) {
+	generator := NewGenerator("", func(Object) (Object, bool, error) {
+		return nil, false, errors.New("failed")
+	})
+
+	_, err := generator.Next(&NullObject)
+	assert.EqualError(t, err, "failed")
+	assert.True(t, generator.Done())
+}
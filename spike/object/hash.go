@@ -3,6 +3,7 @@ package object
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -31,6 +32,7 @@ func (hash *Hash) Inspect() string {
 		)
 	}
 
+	sort.Strings(inspectedPairs)
 	out.WriteString(strings.Join(inspectedPairs, ", "))
 	out.WriteString("}")
 
@@ -39,7 +41,7 @@ func (hash *Hash) Inspect() string {
 
 func (hash *Hash) Equal(other Object) bool {
 	otherHash, ok := other.(*Hash)
-	if !ok {
+	if !ok || len(hash.Pairs) != len(otherHash.Pairs) {
 		return false
 	}
 
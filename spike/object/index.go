@@ -0,0 +1,55 @@
+package object
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Index looks index up in container in the same way for every engine.
+// Missing elements and keys give null.
+func Index(container, index Object) (Object, error) {
+	switch container := container.(type) {
+	case *Array:
+		return elementAt(container.Elements, index)
+
+	case *Tuple:
+		return elementAt(container.Elements, index)
+
+	case *Hash:
+		key, err := HashKeyOf(index)
+		if err != nil {
+			return nil, err
+		}
+
+		pair, ok := container.Pairs[key]
+		if !ok {
+			return &NullObject, nil
+		}
+
+		return pair.Value, nil
+	}
+
+	return nil, errors.Errorf("index operator not supported: %s", container.Type())
+}
+
+func elementAt(elements []Object, index Object) (Object, error) {
+	position, ok := index.(*Integer)
+	if !ok {
+		return nil, errors.Errorf("index must be an integer, got %s", index.Type())
+	}
+	if position.Value < 0 || position.Value >= int64(len(elements)) {
+		return &NullObject, nil
+	}
+
+	return elements[position.Value], nil
+}
+
+// HashKeyOf returns the key addressing obj in hashes, failing for objects
+// that can not be hash keys.
+func HashKeyOf(obj Object) (HashKey, error) {
+	hashable, ok := obj.(Hashable)
+	if !ok || !IsHashable(obj) {
+		return HashKey{}, errors.Errorf("unusable as hash key: %s", obj.Type())
+	}
+
+	return hashable.GetHashKey(), nil
+}
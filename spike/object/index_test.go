@@ -0,0 +1,48 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Index(t *testing.T) {
+	array := &Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}
+	hash := &Hash{Pairs: map[HashKey]HashPair{
+		(&String{Value: "a"}).GetHashKey(): {Key: &String{Value: "a"}, Value: &Integer{Value: 3}},
+	}}
+
+	testCases := []struct {
+		name      string
+		container Object
+		index     Object
+		expected  Object
+	}{
+		{name: "array element", container: array, index: &Integer{Value: 1}, expected: &Integer{Value: 2}},
+		{name: "array out of range", container: array, index: &Integer{Value: 2}, expected: &NullObject},
+		{name: "negative index", container: array, index: &Integer{Value: -1}, expected: &NullObject},
+		{name: "empty tuple", container: &Tuple{}, index: &Integer{Value: 0}, expected: &NullObject},
+		{name: "hash key", container: hash, index: &String{Value: "a"}, expected: &Integer{Value: 3}},
+		{name: "missing hash key", container: hash, index: &String{Value: "b"}, expected: &NullObject},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := Index(testCase.container, testCase.index)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
+
+func Test_Index_withErrors(t *testing.T) {
+	_, err := Index(&Array{}, &String{Value: "a"})
+	assert.EqualError(t, err, "index must be an integer, got string")
+
+	_, err = Index(&Hash{}, &Array{})
+	assert.EqualError(t, err, "unusable as hash key: array")
+
+	_, err = Index(&Integer{Value: 1}, &Integer{Value: 0})
+	assert.EqualError(t, err, "index operator not supported: integer")
+}
@@ -1,6 +1,10 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
 
 type Integer struct {
 	Value int64
@@ -15,24 +19,35 @@ func (integer *Integer) Inspect() string {
 }
 
 func (integer *Integer) Equal(other Object) bool {
-	otherInteger, ok := other.(*Integer)
-	if !ok {
-		return false
+	switch other := other.(type) {
+	case *Integer:
+		return integer.Value == other.Value
+	case *BigInteger:
+		return other.Equal(integer)
+	case *Float:
+		return float64(integer.Value) == other.Value
 	}
 
-	return integer.Value == otherInteger.Value
+	return false
 }
 
 func (integer *Integer) Compare(other Comparable) (Ordering, error) {
-	otherInteger := other.(*Integer)
-
-	if integer.Value > otherInteger.Value {
-		return GT, nil
-	} else if integer.Value < otherInteger.Value {
-		return LT, nil
+	switch other := other.(type) {
+	case *Integer:
+		if integer.Value > other.Value {
+			return GT, nil
+		} else if integer.Value < other.Value {
+			return LT, nil
+		}
+
+		return EQ, nil
+	case *BigInteger:
+		return Ordering(-other.Value.Cmp(toBigInt(integer))), nil
+	case *Float:
+		return compareFloats(float64(integer.Value), other.Value), nil
 	}
 
-	return EQ, nil
+	return EQ, errors.Errorf("unable to compare %s with %s", IntegerType, other.(Object).Type())
 }
 
 func (integer *Integer) GetHashKey() HashKey {
@@ -0,0 +1,176 @@
+package object
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// DefaultMaxCallDepth is the call depth of programs whose limits leave it
+// zero.
+const DefaultMaxCallDepth = 1024
+
+// contextCheckInterval is how many instructions a meter lets run between two
+// checks of its context.
+const contextCheckInterval = 1024
+
+// Limits bound what a program may do. Zero fields are not limited, except
+// MaxCallDepth, which defaults to DefaultMaxCallDepth.
+type Limits struct {
+	// MaxInstructions is the number of instructions the VM may execute, or
+	// of expressions the evaluator may evaluate.
+	MaxInstructions int64
+	// MaxCallDepth is the number of function calls that may be in progress
+	// at once.
+	MaxCallDepth int
+	// MaxMemory is the approximate number of bytes the values created by the
+	// program may take in total, whether they are still used or not.
+	MaxMemory int64
+}
+
+// InterruptedError is the error of programs stopped because their context
+// was cancelled or its deadline passed.
+type InterruptedError struct {
+	Err error
+}
+
+func (err *InterruptedError) Error() string {
+	return fmt.Sprintf("interrupted: %s", err.Err)
+}
+
+func (err *InterruptedError) Cause() error {
+	return err.Err
+}
+
+// InstructionLimitError is the error of programs that executed
+// Limits.MaxInstructions instructions without finishing.
+type InstructionLimitError struct {
+	Limit int64
+}
+
+func (err *InstructionLimitError) Error() string {
+	return fmt.Sprintf("instruction limit of %d exceeded", err.Limit)
+}
+
+// CallDepthError is the error of calls nested deeper than
+// Limits.MaxCallDepth.
+type CallDepthError struct {
+	Limit int
+}
+
+func (err *CallDepthError) Error() string {
+	return fmt.Sprintf("call depth limit of %d exceeded", err.Limit)
+}
+
+// MemoryLimitError is the error of programs creating values beyond
+// Limits.MaxMemory bytes.
+type MemoryLimitError struct {
+	Limit int64
+}
+
+func (err *MemoryLimitError) Error() string {
+	return fmt.Sprintf("memory limit of %d bytes exceeded", err.Limit)
+}
+
+// Meter enforces limits on a program. Processes spawned by the program share
+// its meter, so the instructions and memory of all of them count towards the
+// same limits, while each process has a call stack of its own.
+type Meter struct {
+	context context.Context
+	limits  Limits
+
+	instructions int64
+	memory       int64
+	// depth is the number of calls in progress, for engines without a call
+	// stack of their own.
+	depth int
+}
+
+func NewMeter(ctx context.Context, limits Limits) *Meter {
+	if limits.MaxCallDepth <= 0 {
+		limits.MaxCallDepth = DefaultMaxCallDepth
+	}
+
+	return &Meter{context: ctx, limits: limits}
+}
+
+// MaxCallDepth returns the call depth limit.
+func (meter *Meter) MaxCallDepth() int {
+	return meter.limits.MaxCallDepth
+}
+
+// Step counts an instruction. The context is checked on the first one and
+// then every contextCheckInterval instructions.
+func (meter *Meter) Step() error {
+	instructions := atomic.AddInt64(&meter.instructions, 1)
+	if meter.limits.MaxInstructions > 0 && instructions > meter.limits.MaxInstructions {
+		return &InstructionLimitError{Limit: meter.limits.MaxInstructions}
+	}
+
+	if instructions == 1 || instructions%contextCheckInterval == 0 {
+		return meter.Interrupted()
+	}
+
+	return nil
+}
+
+// Enter counts a call starting, for engines without a call stack of their
+// own. Every successful Enter must be followed by Leave once the call returns.
+func (meter *Meter) Enter() error {
+	if meter.depth >= meter.limits.MaxCallDepth {
+		return &CallDepthError{Limit: meter.limits.MaxCallDepth}
+	}
+
+	meter.depth++
+	return nil
+}
+
+// Leave counts a call returning.
+func (meter *Meter) Leave() {
+	meter.depth--
+}
+
+// Interrupted returns an InterruptedError once the context is done.
+func (meter *Meter) Interrupted() error {
+	if err := meter.context.Err(); err != nil {
+		return &InterruptedError{Err: err}
+	}
+
+	return nil
+}
+
+// Allocate counts the memory taken by value.
+func (meter *Meter) Allocate(value Object) error {
+	memory := atomic.AddInt64(&meter.memory, SizeOf(value))
+	if meter.limits.MaxMemory > 0 && memory > meter.limits.MaxMemory {
+		return &MemoryLimitError{Limit: meter.limits.MaxMemory}
+	}
+
+	return nil
+}
+
+// SizeOf approximates the number of bytes value takes, not counting the
+// values it refers to. Booleans and null are shared, so they take none.
+func SizeOf(value Object) int64 {
+	const header = 16
+	const reference = 16
+
+	switch value := value.(type) {
+	case *Boolean, *Null:
+		return 0
+	case *String:
+		return header + int64(len(value.Value))
+	case *BigInteger:
+		return header + int64(len(value.Value.Bits()))*8
+	case *Array:
+		return header + int64(len(value.Elements))*reference
+	case *Tuple:
+		return header + int64(len(value.Elements))*reference
+	case *Hash:
+		return header + int64(len(value.Pairs))*4*reference
+	case *Closure:
+		return header + int64(len(value.FreeVariables))*reference
+	}
+
+	return header
+}
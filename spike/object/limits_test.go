@@ -0,0 +1,39 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Meter(t *testing.T) {
+	meter := NewMeter(context.Background(), Limits{MaxInstructions: 2, MaxCallDepth: 1, MaxMemory: 40})
+
+	assert.NoError(t, meter.Step())
+	assert.NoError(t, meter.Step())
+	assert.Equal(t, &InstructionLimitError{Limit: 2}, meter.Step())
+
+	assert.NoError(t, meter.Enter())
+	assert.Equal(t, &CallDepthError{Limit: 1}, meter.Enter())
+	meter.Leave()
+	assert.NoError(t, meter.Enter())
+
+	assert.NoError(t, meter.Allocate(&String{Value: "spike"}))
+	assert.NoError(t, meter.Allocate(&True))
+	assert.Equal(t, &MemoryLimitError{Limit: 40}, meter.Allocate(&Array{Elements: []Object{&NullObject}}))
+}
+
+func Test_Meter_defaultCallDepth(t *testing.T) {
+	assert.Equal(t, DefaultMaxCallDepth, NewMeter(context.Background(), Limits{}).MaxCallDepth())
+}
+
+func Test_Meter_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	meter := NewMeter(ctx, Limits{})
+	assert.NoError(t, meter.Interrupted())
+
+	cancel()
+	assert.Equal(t, &InterruptedError{Err: context.Canceled}, meter.Interrupted())
+	assert.EqualError(t, meter.Step(), "interrupted: context canceled")
+}
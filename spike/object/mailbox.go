@@ -0,0 +1,115 @@
+package object
+
+import (
+	"sync"
+	"time"
+)
+
+// noDeadline makes Mailbox operations wait forever.
+var noDeadline = time.Time{}
+
+// Mailbox is the message queue of a process. Any process can send to it, only
+// the owning process receives. Messages can be received out of order.
+type Mailbox struct {
+	mutex    sync.Mutex
+	messages []Object
+	closed   bool
+	arrived  chan struct{}
+}
+
+func NewMailbox() *Mailbox {
+	return &Mailbox{arrived: make(chan struct{}, 1)}
+}
+
+func (mailbox *Mailbox) Send(message Object) {
+	mailbox.mutex.Lock()
+	mailbox.messages = append(mailbox.messages, message)
+	mailbox.mutex.Unlock()
+
+	select {
+	case mailbox.arrived <- struct{}{}:
+	default:
+	}
+}
+
+// Close wakes up and fails pending and future receives, messages can still be
+// sent.
+func (mailbox *Mailbox) Close() {
+	mailbox.mutex.Lock()
+	mailbox.closed = true
+	mailbox.mutex.Unlock()
+
+	select {
+	case mailbox.arrived <- struct{}{}:
+	default:
+	}
+}
+
+func (mailbox *Mailbox) Len() int {
+	mailbox.mutex.Lock()
+	defer mailbox.mutex.Unlock()
+
+	return len(mailbox.messages)
+}
+
+// Peek returns the message at index, waiting for it to arrive until deadline.
+// A zero deadline waits forever, a past one doesn't wait at all.
+func (mailbox *Mailbox) Peek(index int, deadline time.Time) (Object, bool) {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		mailbox.mutex.Lock()
+		if mailbox.closed {
+			mailbox.mutex.Unlock()
+			return nil, false
+		}
+		if index < len(mailbox.messages) {
+			message := mailbox.messages[index]
+			mailbox.mutex.Unlock()
+			return message, true
+		}
+		mailbox.mutex.Unlock()
+
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return nil, false
+		}
+
+		select {
+		case <-mailbox.arrived:
+		case <-timeout:
+			return nil, false
+		}
+	}
+}
+
+// Remove takes the message at index out of the mailbox.
+func (mailbox *Mailbox) Remove(index int) Object {
+	mailbox.mutex.Lock()
+	defer mailbox.mutex.Unlock()
+
+	message := mailbox.messages[index]
+	mailbox.messages = append(mailbox.messages[:index], mailbox.messages[index+1:]...)
+
+	return message
+}
+
+// Receive removes and returns the oldest message accepted by match, waiting
+// until deadline for one to arrive.
+func (mailbox *Mailbox) Receive(match func(Object) bool, deadline time.Time) (Object, bool) {
+	for index := 0; ; index++ {
+		message, ok := mailbox.Peek(index, deadline)
+		if !ok {
+			return nil, false
+		}
+
+		if match(message) {
+			mailbox.Remove(index)
+			return message, true
+		}
+	}
+}
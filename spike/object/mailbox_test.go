@@ -0,0 +1,52 @@
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Mailbox_Receive(t *testing.T) {
+	mailbox := NewMailbox()
+	mailbox.Send(&Integer{Value: 1})
+	mailbox.Send(&String{Value: "a"})
+	mailbox.Send(&Integer{Value: 2})
+
+	isString := func(message Object) bool {
+		_, ok := message.(*String)
+		return ok
+	}
+
+	message, ok := mailbox.Receive(isString, noDeadline)
+	assert.True(t, ok)
+	assert.Equal(t, &String{Value: "a"}, message)
+	assert.Equal(t, 2, mailbox.Len())
+
+	message, ok = mailbox.Receive(func(Object) bool { return true }, noDeadline)
+	assert.True(t, ok)
+	assert.Equal(t, &Integer{Value: 1}, message)
+}
+
+func Test_Mailbox_Receive_timeout(t *testing.T) {
+	mailbox := NewMailbox()
+	mailbox.Send(&Integer{Value: 1})
+
+	message, ok := mailbox.Receive(func(Object) bool { return false }, time.Now().Add(10*time.Millisecond))
+	assert.False(t, ok)
+	assert.Nil(t, message)
+	assert.Equal(t, 1, mailbox.Len())
+}
+
+func Test_Mailbox_Peek_waitsForMessage(t *testing.T) {
+	mailbox := NewMailbox()
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		mailbox.Send(&Integer{Value: 1})
+	}()
+
+	message, ok := mailbox.Peek(0, time.Now().Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, &Integer{Value: 1}, message)
+}
@@ -3,17 +3,25 @@ package object
 type ObjectType string
 
 const (
-	IntegerType          ObjectType = "integer"
-	StringType           ObjectType = "string"
-	BooleanType          ObjectType = "boolean"
-	NullType             ObjectType = "null"
-	ReturnType           ObjectType = "return"
-	FunctionType         ObjectType = "function"
-	BuiltinFunctionType  ObjectType = "builtinFunction"
-	ArrayType            ObjectType = "array"
-	HashType             ObjectType = "hash"
-	CompiledFunctionType ObjectType = "compiledFunction"
-	ClosureType          ObjectType = "closure"
+	IntegerType           ObjectType = "integer"
+	FloatType             ObjectType = "float"
+	StringType            ObjectType = "string"
+	BooleanType           ObjectType = "boolean"
+	NullType              ObjectType = "null"
+	FunctionType          ObjectType = "function"
+	BuiltinFunctionType   ObjectType = "builtinFunction"
+	ArrayType             ObjectType = "array"
+	HashType              ObjectType = "hash"
+	CompiledFunctionType  ObjectType = "compiledFunction"
+	ClosureType           ObjectType = "closure"
+	TupleType             ObjectType = "tuple"
+	AtomType              ObjectType = "atom"
+	PidType               ObjectType = "pid"
+	PidHandlerType        ObjectType = "pidHandler"
+	ProcessDefinitionType ObjectType = "processDefinition"
+	SupervisorType        ObjectType = "supervisor"
+	GeneratorType         ObjectType = "generator"
+	requestType           ObjectType = "request"
 )
 
 type Ordering int8
@@ -38,6 +46,23 @@ type Hashable interface {
 	GetHashKey() HashKey
 }
 
+// IsHashable reports whether obj can be used as a hash key. Tuples are
+// hashable only when all of their elements are.
+func IsHashable(obj Object) bool {
+	if tuple, ok := obj.(*Tuple); ok {
+		for _, element := range tuple.Elements {
+			if !IsHashable(element) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	_, ok := obj.(Hashable)
+	return ok
+}
+
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
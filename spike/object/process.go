@@ -0,0 +1,421 @@
+package object
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// Host is the engine executing a builtin. Builtins that run Spike functions in
+// new processes use it to create engines for them.
+type Host interface {
+	// Fork returns a new engine running in process that shares the program of
+	// this one.
+	Fork(process *Process) Host
+	// Call runs function with args to completion.
+	Call(function Object, args ...Object) (Object, error)
+	// Process returns the process the engine runs in.
+	Process() *Process
+}
+
+var lastPid uint64
+
+// ErrKilled is the error of processes stopped by Kill.
+var ErrKilled = errors.New("killed")
+
+// Process is a lightweight process: a mailbox plus the engine running on its
+// own goroutine. Processes started from a ProcessDefinition serve cast and
+// call requests sent to their handlers.
+//
+// When a process exits, linked processes receive an (:EXIT, pid, reason)
+// message and monitoring processes a (:DOWN, pid, reason) one. The reason is
+// :normal, :killed or the error message.
+type Process struct {
+	Pid     *Pid
+	Mailbox *Mailbox
+
+	definition *ProcessDefinition
+	done       chan struct{}
+	err        error
+	killed     int32
+
+	mutex    sync.Mutex
+	exited   bool
+	links    map[*Process]bool
+	monitors []*Process
+}
+
+func NewProcess() *Process {
+	process := &Process{
+		Mailbox: NewMailbox(),
+		done:    make(chan struct{}),
+	}
+	process.Pid = &Pid{ID: atomic.AddUint64(&lastPid, 1), process: process}
+
+	return process
+}
+
+// Done is closed when the process exits.
+func (process *Process) Done() <-chan struct{} {
+	return process.done
+}
+
+// Err returns the error the process exited with. It is only valid after Done
+// is closed.
+func (process *Process) Err() error {
+	return process.err
+}
+
+// Kill asks the process to stop. Engines check it between instructions, a
+// process waiting for messages is woken up.
+func (process *Process) Kill() {
+	atomic.StoreInt32(&process.killed, 1)
+	process.Mailbox.Close()
+}
+
+func (process *Process) Killed() bool {
+	return atomic.LoadInt32(&process.killed) == 1
+}
+
+// Link makes process and other notify each other when one of them exits.
+// Linking to a process that has already exited notifies immediately.
+func (process *Process) Link(other *Process) {
+	if !other.addLink(process) {
+		process.Mailbox.Send(other.notification(exitNotification))
+		return
+	}
+
+	if !process.addLink(other) {
+		other.removeLink(process)
+		other.Mailbox.Send(process.notification(exitNotification))
+	}
+}
+
+// Unlink removes the link between process and other.
+func (process *Process) Unlink(other *Process) {
+	process.removeLink(other)
+	other.removeLink(process)
+}
+
+// Monitor makes process notify watcher when it exits, immediately when it
+// has already exited.
+func (process *Process) Monitor(watcher *Process) {
+	process.mutex.Lock()
+	if process.exited {
+		process.mutex.Unlock()
+		watcher.Mailbox.Send(process.notification(downNotification))
+		return
+	}
+
+	process.monitors = append(process.monitors, watcher)
+	process.mutex.Unlock()
+}
+
+func (process *Process) addLink(other *Process) bool {
+	process.mutex.Lock()
+	defer process.mutex.Unlock()
+
+	if process.exited {
+		return false
+	}
+
+	if process.links == nil {
+		process.links = make(map[*Process]bool)
+	}
+	process.links[other] = true
+
+	return true
+}
+
+func (process *Process) removeLink(other *Process) {
+	process.mutex.Lock()
+	defer process.mutex.Unlock()
+
+	delete(process.links, other)
+}
+
+func (process *Process) exit(err error) {
+	process.mutex.Lock()
+	process.err = err
+	process.exited = true
+	links := process.links
+	monitors := process.monitors
+	process.links = nil
+	process.monitors = nil
+	process.mutex.Unlock()
+
+	for linked := range links {
+		linked.removeLink(process)
+		linked.Mailbox.Send(process.notification(exitNotification))
+	}
+	for _, watcher := range monitors {
+		watcher.Mailbox.Send(process.notification(downNotification))
+	}
+
+	close(process.done)
+}
+
+const (
+	exitNotification = "EXIT"
+	downNotification = "DOWN"
+)
+
+// notification is the message sent to linked and monitoring processes of an
+// exited process.
+func (process *Process) notification(kind string) Object {
+	var reason Object = &String{Value: fmt.Sprint(process.err)}
+	if process.err == nil {
+		reason = NewAtom("normal")
+	} else if errors.Cause(process.err) == ErrKilled {
+		reason = NewAtom("killed")
+	}
+
+	return &Tuple{Elements: []Object{NewAtom(kind), process.Pid, reason}}
+}
+
+// Start runs target in a new process with an engine forked from host. Targets
+// are process definitions, closures and supervisors. A linked process is
+// linked to the process of host before it starts. Args are copied, as they
+// may alias the stack of host, which keeps running.
+func Start(host Host, target Object, args []Object, link bool) (*Pid, error) {
+	process := NewProcess()
+	args = append([]Object(nil), args...)
+
+	var run func(engine Host) error
+	switch target := target.(type) {
+	case *ProcessDefinition:
+		process.definition = target
+		run = func(engine Host) error {
+			return process.serve(engine, args)
+		}
+	case *Closure:
+		run = func(engine Host) error {
+			_, err := engine.Call(target, args...)
+			return err
+		}
+	case *Supervisor:
+		if len(args) != 0 {
+			return nil, errors.New("supervisors take no arguments")
+		}
+		run = func(engine Host) error {
+			return target.supervise(engine)
+		}
+	default:
+		return nil, errors.Errorf("unable to spawn %s", target.Type())
+	}
+
+	if link {
+		host.Process().Link(process)
+	}
+
+	return process.start(host, run), nil
+}
+
+// start runs the process on its own goroutine. A panic in the engine only
+// ends the process, with an error.
+func (process *Process) start(host Host, run func(engine Host) error) *Pid {
+	engine := host.Fork(process)
+
+	go func() {
+		var err error
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = errors.Errorf("process crashed: %v", recovered)
+			}
+			process.exit(err)
+		}()
+
+		err = run(engine)
+	}()
+
+	return process.Pid
+}
+
+// serve runs handlers for incoming requests one at a time. Cast handlers
+// returning a hash replace the state, call handlers reply with their result.
+// Messages other than requests stay in the mailbox.
+func (process *Process) serve(engine Host, args []Object) error {
+	state, err := engine.Call(process.definition.Init, args...)
+	if err != nil {
+		return err
+	}
+
+	for {
+		message, ok := process.Mailbox.Receive(isRequest, noDeadline)
+		if !ok {
+			return ErrKilled
+		}
+		request := message.(*request)
+		handler := process.definition.Handlers[request.handler]
+
+		result, err := engine.Call(handler.Function, append([]Object{state}, request.args...)...)
+		if err != nil {
+			return err
+		}
+
+		if request.reply != nil {
+			request.reply <- result
+		} else if _, ok := result.(*Hash); ok {
+			state = result
+		}
+	}
+}
+
+type Pid struct {
+	ID      uint64
+	process *Process
+}
+
+func (pid *Pid) Type() ObjectType {
+	return PidType
+}
+
+func (pid *Pid) Inspect() string {
+	return fmt.Sprintf("<pid.%d>", pid.ID)
+}
+
+func (pid *Pid) Equal(other Object) bool {
+	otherPid, ok := other.(*Pid)
+	return ok && otherPid.ID == pid.ID
+}
+
+func (pid *Pid) Compare(other Comparable) (Ordering, error) {
+	otherPid, ok := other.(*Pid)
+	if !ok {
+		return EQ, errors.Errorf("unable to compare %s with %s", PidType, other.(Object).Type())
+	}
+
+	switch {
+	case pid.ID < otherPid.ID:
+		return LT, nil
+	case pid.ID > otherPid.ID:
+		return GT, nil
+	}
+	return EQ, nil
+}
+
+func (pid *Pid) GetHashKey() HashKey {
+	return HashKey{Type: PidType, Value: pid.ID}
+}
+
+// Process returns the process identified by pid.
+func (pid *Pid) Process() *Process {
+	return pid.process
+}
+
+type HandlerKind string
+
+const (
+	CastHandler HandlerKind = "cast"
+	CallHandler HandlerKind = "call"
+)
+
+type ProcessHandler struct {
+	Kind     HandlerKind
+	Function Object
+}
+
+// ProcessDefinition is the result of a process statement: a function building
+// the initial state and handlers receiving the state as their first argument.
+type ProcessDefinition struct {
+	Name     string
+	Init     Object
+	Handlers map[string]ProcessHandler
+}
+
+func (definition *ProcessDefinition) Type() ObjectType {
+	return ProcessDefinitionType
+}
+
+func (definition *ProcessDefinition) Inspect() string {
+	return fmt.Sprintf("process %s", definition.Name)
+}
+
+func (definition *ProcessDefinition) Equal(other Object) bool {
+	return definition == other
+}
+
+// PidHandler is a handler of a running process, e.g. u.changeName. Calling it
+// sends a request to the process.
+type PidHandler struct {
+	Pid  *Pid
+	Name string
+}
+
+func (handler *PidHandler) Type() ObjectType {
+	return PidHandlerType
+}
+
+func (handler *PidHandler) Inspect() string {
+	return fmt.Sprintf("%s.%s", handler.Pid.Inspect(), handler.Name)
+}
+
+func (handler *PidHandler) Equal(other Object) bool {
+	otherHandler, ok := other.(*PidHandler)
+	return ok && otherHandler.Pid.Equal(handler.Pid) && otherHandler.Name == handler.Name
+}
+
+// Invoke sends a request for the handler. Casts return immediately, calls wait
+// for the reply of the process or for it to exit.
+func (handler *PidHandler) Invoke(caller *Process, args []Object) (Object, error) {
+	process := handler.Pid.process
+	if process.definition == nil {
+		return nil, errors.Errorf("%s has no handlers", handler.Pid.Inspect())
+	}
+
+	definition, ok := process.definition.Handlers[handler.Name]
+	if !ok {
+		return nil, errors.Errorf("process %s has no handler %s", process.definition.Name, handler.Name)
+	}
+
+	if definition.Kind == CastHandler {
+		process.Mailbox.Send(&request{handler: handler.Name, args: args})
+		return nil, nil
+	}
+
+	if caller == process {
+		return nil, errors.Errorf("process %s can not call itself", process.definition.Name)
+	}
+
+	reply := make(chan Object, 1)
+	process.Mailbox.Send(&request{handler: handler.Name, args: args, reply: reply})
+
+	select {
+	case result := <-reply:
+		return result, nil
+	case <-process.done:
+		select {
+		case result := <-reply:
+			return result, nil
+		default:
+			return nil, errors.Errorf("process %s exited: %v", process.definition.Name, process.err)
+		}
+	}
+}
+
+// request is a message sent to a handler of a process. Calls carry a channel
+// for the reply.
+type request struct {
+	handler string
+	args    []Object
+	reply   chan Object
+}
+
+func (request *request) Type() ObjectType {
+	return requestType
+}
+
+func (request *request) Inspect() string {
+	return fmt.Sprintf("request(%s)", request.handler)
+}
+
+func (request *request) Equal(other Object) bool {
+	return request == other
+}
+
+func isRequest(message Object) bool {
+	_, ok := message.(*request)
+	return ok
+}
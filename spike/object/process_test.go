@@ -0,0 +1,83 @@
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Start_linkedProcessCrash(t *testing.T) {
+	parent := &fakeHost{process: NewProcess(), call: func(*Process) (Object, error) {
+		panic("boom")
+	}}
+
+	pid, err := Start(parent, &Closure{}, nil, true)
+	assert.NoError(t, err)
+
+	message, ok := parent.process.Mailbox.Receive(isExitNotification, time.Now().Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, &Tuple{Elements: []Object{
+		NewAtom("EXIT"),
+		pid,
+		&String{Value: "process crashed: boom"},
+	}}, message)
+}
+
+func Test_Process_Kill(t *testing.T) {
+	parent := &fakeHost{process: NewProcess(), call: func(process *Process) (Object, error) {
+		_, ok := process.Mailbox.Receive(func(Object) bool { return true }, noDeadline)
+		if !ok {
+			return nil, ErrKilled
+		}
+		return nil, nil
+	}}
+
+	pid, err := Start(parent, &Closure{}, nil, false)
+	assert.NoError(t, err)
+	pid.Process().Monitor(parent.process)
+	pid.Process().Kill()
+
+	message, ok := parent.process.Mailbox.Receive(isDownNotification, time.Now().Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, &Tuple{Elements: []Object{NewAtom("DOWN"), pid, NewAtom("killed")}}, message)
+}
+
+func Test_Process_Monitor_exitedProcess(t *testing.T) {
+	parent := &fakeHost{process: NewProcess(), call: func(*Process) (Object, error) {
+		return nil, nil
+	}}
+
+	pid, err := Start(parent, &Closure{}, nil, false)
+	assert.NoError(t, err)
+	<-pid.Process().Done()
+
+	pid.Process().Monitor(parent.process)
+	pid.Process().Link(parent.process)
+
+	assert.Equal(t, 2, parent.process.Mailbox.Len())
+	message, _ := parent.process.Mailbox.Receive(isExitNotification, noDeadline)
+	assert.Equal(t, &Tuple{Elements: []Object{NewAtom("EXIT"), pid, NewAtom("normal")}}, message)
+}
+
+func isDownNotification(message Object) bool {
+	tuple, ok := message.(*Tuple)
+	return ok && tuple.Elements[0].Equal(NewAtom("DOWN"))
+}
+
+type fakeHost struct {
+	process *Process
+	call    func(process *Process) (Object, error)
+}
+
+func (host *fakeHost) Fork(process *Process) Host {
+	return &fakeHost{process: process, call: host.call}
+}
+
+func (host *fakeHost) Call(function Object, args ...Object) (Object, error) {
+	return host.call(host.process)
+}
+
+func (host *fakeHost) Process() *Process {
+	return host.process
+}
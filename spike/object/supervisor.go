@@ -0,0 +1,229 @@
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type RestartStrategy string
+
+const (
+	// OneForOne restarts only the child that failed.
+	OneForOne RestartStrategy = "one_for_one"
+	// OneForAll kills the remaining children and restarts all of them.
+	OneForAll RestartStrategy = "one_for_all"
+)
+
+const (
+	DefaultMaxRestarts = 3
+	DefaultPeriod      = 5 * time.Second
+)
+
+// Supervisor describes a supervisor process: children are closures or other
+// supervisors, started linked to it when it is spawned. Children failing are
+// restarted according to Strategy, children exiting normally are not. After
+// more than MaxRestarts restarts within Period the supervisor kills its
+// children and fails, so that its own supervisor can act.
+type Supervisor struct {
+	Strategy    RestartStrategy
+	Children    []Object
+	MaxRestarts int
+	Period      time.Duration
+}
+
+func (supervisor *Supervisor) Type() ObjectType {
+	return SupervisorType
+}
+
+func (supervisor *Supervisor) Inspect() string {
+	return fmt.Sprintf("supervisor(%s, %d children)", supervisor.Strategy, len(supervisor.Children))
+}
+
+func (supervisor *Supervisor) Equal(other Object) bool {
+	return supervisor == other
+}
+
+// newSupervisor builds a supervisor out of the strategy atom, the array of
+// children and an optional hash with "max_restarts" and "period" in
+// milliseconds.
+func newSupervisor(args ...Object) (Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, errors.New("2 or 3 function arguments expected")
+	}
+
+	strategy, ok := args[0].(*Atom)
+	if !ok || (strategy.Name != string(OneForOne) && strategy.Name != string(OneForAll)) {
+		return nil, errors.Errorf("unknown restart strategy %s", args[0].Inspect())
+	}
+
+	children, ok := args[1].(*Array)
+	if !ok {
+		return nil, errors.Errorf("children must be an array, got %s", args[1].Type())
+	}
+	for _, child := range children.Elements {
+		switch child.(type) {
+		case *Closure, *Supervisor:
+		default:
+			return nil, errors.Errorf("unable to supervise %s", child.Type())
+		}
+	}
+
+	supervisor := &Supervisor{
+		Strategy:    RestartStrategy(strategy.Name),
+		Children:    children.Elements,
+		MaxRestarts: DefaultMaxRestarts,
+		Period:      DefaultPeriod,
+	}
+
+	if len(args) == 3 {
+		options, ok := args[2].(*Hash)
+		if !ok {
+			return nil, errors.Errorf("supervisor options must be a hash, got %s", args[2].Type())
+		}
+
+		maxRestarts, err := integerOption(options, "max_restarts", int64(supervisor.MaxRestarts))
+		if err != nil {
+			return nil, err
+		}
+		period, err := integerOption(options, "period", int64(supervisor.Period/time.Millisecond))
+		if err != nil {
+			return nil, err
+		}
+
+		supervisor.MaxRestarts = int(maxRestarts)
+		supervisor.Period = time.Duration(period) * time.Millisecond
+	}
+
+	return supervisor, nil
+}
+
+func integerOption(options *Hash, name string, defaultValue int64) (int64, error) {
+	pair, ok := options.Pairs[(&String{Value: name}).GetHashKey()]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	value, ok := pair.Value.(*Integer)
+	if !ok || value.Value < 0 {
+		return 0, errors.Errorf("%s must be a non-negative integer, got %s", name, pair.Value.Inspect())
+	}
+
+	return value.Value, nil
+}
+
+// supervise runs in the supervisor process: it starts the children and
+// handles the exit notifications of their links.
+func (supervisor *Supervisor) supervise(engine Host) error {
+	self := engine.Process()
+	children := make([]*Process, len(supervisor.Children))
+	running := 0
+
+	start := func(i int) error {
+		pid, err := Start(engine, supervisor.Children[i], nil, true)
+		if err != nil {
+			return err
+		}
+		children[i] = pid.process
+		running++
+		return nil
+	}
+	stop := func(i int) {
+		if children[i] == nil {
+			return
+		}
+		self.Unlink(children[i])
+		children[i].Kill()
+		children[i] = nil
+		running--
+	}
+	stopAll := func() {
+		for i := range children {
+			stop(i)
+		}
+	}
+
+	for i := range children {
+		err := start(i)
+		if err != nil {
+			stopAll()
+			return err
+		}
+	}
+
+	var restarts []time.Time
+	for running > 0 {
+		message, ok := self.Mailbox.Receive(isExitNotification, noDeadline)
+		if !ok {
+			stopAll()
+			return ErrKilled
+		}
+
+		notification := message.(*Tuple)
+		i := childIndex(children, notification.Elements[1])
+		if i < 0 {
+			continue
+		}
+		children[i] = nil
+		running--
+
+		reason := notification.Elements[2]
+		if reason.Equal(NewAtom("normal")) {
+			continue
+		}
+
+		now := time.Now()
+		restarts = append(restarts, now)
+		for len(restarts) > 0 && now.Sub(restarts[0]) > supervisor.Period {
+			restarts = restarts[1:]
+		}
+		if len(restarts) > supervisor.MaxRestarts {
+			stopAll()
+			return errors.Errorf("reached maximum restart intensity: %s", reasonText(reason))
+		}
+
+		restarted := []int{i}
+		if supervisor.Strategy == OneForAll {
+			stopAll()
+			restarted = restarted[:0]
+			for j := range children {
+				restarted = append(restarted, j)
+			}
+		}
+
+		for _, j := range restarted {
+			err := start(j)
+			if err != nil {
+				stopAll()
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func childIndex(children []*Process, pid Object) int {
+	for i, child := range children {
+		if child != nil && child.Pid.Equal(pid) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// reasonText returns error messages of exit reasons without quotes.
+func reasonText(reason Object) string {
+	if message, ok := reason.(*String); ok {
+		return message.Value
+	}
+
+	return reason.Inspect()
+}
+
+func isExitNotification(message Object) bool {
+	tuple, ok := message.(*Tuple)
+	return ok && len(tuple.Elements) == 3 && tuple.Elements[0].Equal(NewAtom(exitNotification))
+}
@@ -0,0 +1,115 @@
+package object
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Tuple is an immutable, fixed-size sequence of objects. Tuples are hashable
+// when all of their elements are, see IsHashable.
+type Tuple struct {
+	Elements []Object
+}
+
+func (tuple *Tuple) Type() ObjectType {
+	return TupleType
+}
+
+func (tuple *Tuple) Inspect() string {
+	out := strings.Builder{}
+
+	out.WriteString("(")
+	for i, element := range tuple.Elements {
+		out.WriteString(element.Inspect())
+		if i < len(tuple.Elements)-1 {
+			out.WriteString(", ")
+		}
+	}
+	if len(tuple.Elements) == 1 {
+		out.WriteString(",")
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
+
+func (tuple *Tuple) Equal(other Object) bool {
+	otherTuple, ok := other.(*Tuple)
+	if !ok {
+		return false
+	}
+
+	if len(tuple.Elements) != len(otherTuple.Elements) {
+		return false
+	}
+
+	for i := range tuple.Elements {
+		if !tuple.Elements[i].Equal(otherTuple.Elements[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Compare orders tuples lexicographically: the first pair of elements that
+// differ decides, and a tuple that is a prefix of the other is smaller.
+func (tuple *Tuple) Compare(other Comparable) (Ordering, error) {
+	otherTuple, ok := other.(*Tuple)
+	if !ok {
+		return EQ, errors.Errorf("unable to compare %s with %s", TupleType, other.(Object).Type())
+	}
+
+	for i := 0; i < len(tuple.Elements) && i < len(otherTuple.Elements); i++ {
+		left := tuple.Elements[i]
+		right := otherTuple.Elements[i]
+		if left.Equal(right) {
+			continue
+		}
+
+		leftComparable, leftOk := left.(Comparable)
+		rightComparable, rightOk := right.(Comparable)
+		if !leftOk || !rightOk {
+			return EQ, errors.Errorf("unable to compare %s with %s", left.Type(), right.Type())
+		}
+
+		return leftComparable.Compare(rightComparable)
+	}
+
+	switch {
+	case len(tuple.Elements) < len(otherTuple.Elements):
+		return LT, nil
+	case len(tuple.Elements) > len(otherTuple.Elements):
+		return GT, nil
+	}
+
+	return EQ, nil
+}
+
+// GetHashKey combines hash keys of the elements. Callers must check
+// IsHashable first, elements that are not hashable only contribute their type.
+func (tuple *Tuple) GetHashKey() HashKey {
+	h := fnv.New64a()
+	buffer := make([]byte, 8)
+
+	for _, element := range tuple.Elements {
+		hashable, ok := element.(Hashable)
+		if !ok {
+			_, _ = h.Write([]byte(element.Type()))
+			continue
+		}
+
+		key := hashable.GetHashKey()
+		binary.BigEndian.PutUint64(buffer, key.Value)
+		_, _ = h.Write([]byte(key.Type))
+		_, _ = h.Write(buffer)
+	}
+
+	return HashKey{
+		Type:  TupleType,
+		Value: h.Sum64(),
+	}
+}
@@ -0,0 +1,77 @@
+package object
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Tuple_Inspect(t *testing.T) {
+	assert.Equal(t, "()", (&Tuple{}).Inspect())
+	assert.Equal(t, "(1,)", (&Tuple{Elements: []Object{&Integer{Value: 1}}}).Inspect())
+	assert.Equal(t, `(1, "a")`, (&Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}).Inspect())
+}
+
+func Test_Tuple_Compare(t *testing.T) {
+	testCases := []struct {
+		left             *Tuple
+		right            *Tuple
+		expectedOrdering Ordering
+	}{
+		{
+			left:             &Tuple{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}},
+			right:            &Tuple{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}},
+			expectedOrdering: EQ,
+		},
+		{
+			left:             &Tuple{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}},
+			right:            &Tuple{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 3}}},
+			expectedOrdering: LT,
+		},
+		{
+			left:             &Tuple{Elements: []Object{&Integer{Value: 2}}},
+			right:            &Tuple{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 3}}},
+			expectedOrdering: GT,
+		},
+		{
+			left:             &Tuple{Elements: []Object{&String{Value: "a"}}},
+			right:            &Tuple{Elements: []Object{&String{Value: "a"}, &Integer{Value: 3}}},
+			expectedOrdering: LT,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCaseName := fmt.Sprintf("%s <=> %s", testCase.left.Inspect(), testCase.right.Inspect())
+		t.Run(testCaseName, func(t *testing.T) {
+			ordering, err := testCase.left.Compare(testCase.right)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedOrdering, ordering)
+		})
+	}
+}
+
+func Test_Tuple_Compare_incomparableElements(t *testing.T) {
+	left := &Tuple{Elements: []Object{&String{Value: "a"}}}
+	right := &Tuple{Elements: []Object{&String{Value: "b"}}}
+
+	_, err := left.Compare(right)
+
+	assert.EqualError(t, err, "unable to compare string with string")
+}
+
+func Test_Tuple_GetHashKey(t *testing.T) {
+	first := &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	same := &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	swapped := &Tuple{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}}
+
+	assert.Equal(t, first.GetHashKey(), same.GetHashKey())
+	assert.NotEqual(t, first.GetHashKey(), swapped.GetHashKey())
+}
+
+func Test_IsHashable(t *testing.T) {
+	assert.True(t, IsHashable(&Tuple{Elements: []Object{&Integer{Value: 1}, &Tuple{}}}))
+	assert.False(t, IsHashable(&Tuple{Elements: []Object{&Array{}}}))
+	assert.False(t, IsHashable(&Array{}))
+}
@@ -8,6 +8,11 @@ import (
 type Array struct {
 	Token    lexer.Token
 	Elements []Expression
+	End      lexer.Position
+}
+
+func (array *Array) Span() lexer.Span {
+	return lexer.Span{Start: array.Token.Span.Start, End: array.End}
 }
 
 func (array *Array) TokenLiteral() string {
@@ -1,8 +1,11 @@
 package ast
 
+import "spike-interpreter-go/spike/lexer"
+
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Span() lexer.Span
 }
 
 type Statement interface {
@@ -26,6 +29,10 @@ func (statement *ExpressionStatement) TokenLiteral() string {
 func (statement *ExpressionStatement) statement() {
 }
 
+func (statement *ExpressionStatement) Span() lexer.Span {
+	return statement.Expression.Span()
+}
+
 func (statement *ExpressionStatement) String() string {
 	return statement.Expression.String()
 }
@@ -0,0 +1,27 @@
+package ast
+
+import (
+	"spike-interpreter-go/spike/lexer"
+)
+
+// Atom is a symbolic constant written as a colon directly followed by a name,
+// e.g. :person.
+type Atom struct {
+	Token lexer.Token
+	Name  string
+	End   lexer.Position
+}
+
+func (atom *Atom) Span() lexer.Span {
+	return lexer.Span{Start: atom.Token.Span.Start, End: atom.End}
+}
+
+func (atom *Atom) TokenLiteral() string {
+	return atom.Token.Literal
+}
+
+func (atom *Atom) String() string {
+	return ":" + atom.Name
+}
+
+func (atom *Atom) expression() {}
@@ -0,0 +1,26 @@
+package ast
+
+import (
+	"math/big"
+	"spike-interpreter-go/spike/lexer"
+)
+
+// BigInteger is an integer literal too large for an int64.
+type BigInteger struct {
+	Token lexer.Token
+	Value *big.Int
+}
+
+func (integer *BigInteger) Span() lexer.Span {
+	return integer.Token.Span
+}
+
+func (integer *BigInteger) TokenLiteral() string {
+	return integer.Token.Literal
+}
+
+func (integer *BigInteger) expression() {}
+
+func (integer *BigInteger) String() string {
+	return integer.Value.String()
+}
@@ -9,10 +9,15 @@ import (
 type BlockStatement struct {
 	Token      lexer.Token
 	Statements []Statement
+	End        lexer.Position
 }
 
 func (block *BlockStatement) statement() {}
 
+func (block *BlockStatement) Span() lexer.Span {
+	return lexer.Span{Start: block.Token.Span.Start, End: block.End}
+}
+
 func (block *BlockStatement) TokenLiteral() string {
 	return block.Token.Literal
 }
@@ -9,6 +9,10 @@ type Boolean struct {
 
 func (boolean *Boolean) expression() {}
 
+func (boolean *Boolean) Span() lexer.Span {
+	return boolean.Token.Span
+}
+
 func (boolean *Boolean) TokenLiteral() string {
 	return boolean.Token.Literal
 }
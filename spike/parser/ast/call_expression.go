@@ -9,6 +9,11 @@ type CallExpression struct {
 	Token     lexer.Token
 	Function  Expression
 	Arguments []Expression
+	End       lexer.Position
+}
+
+func (call *CallExpression) Span() lexer.Span {
+	return lexer.Span{Start: call.Function.Span().Start, End: call.End}
 }
 
 func (call *CallExpression) TokenLiteral() string {
@@ -0,0 +1,58 @@
+package ast
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"strings"
+)
+
+// CaseExpression matches Subject against the patterns of its clauses in
+// order and evaluates the body of the first clause that matches.
+type CaseExpression struct {
+	Token   lexer.Token
+	Subject Expression
+	Clauses []*CaseClause
+	End     lexer.Position
+}
+
+type CaseClause struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+func (expression *CaseExpression) expression() {}
+
+func (expression *CaseExpression) Span() lexer.Span {
+	return lexer.Span{Start: expression.Token.Span.Start, End: expression.End}
+}
+
+func (expression *CaseExpression) TokenLiteral() string {
+	return expression.Token.Literal
+}
+
+func (expression *CaseExpression) String() string {
+	out := strings.Builder{}
+	out.WriteString("case ")
+	out.WriteString(expression.Subject.String())
+	out.WriteString(" of")
+	for _, clause := range expression.Clauses {
+		out.WriteString(" ")
+		out.WriteString(clause.String())
+	}
+	out.WriteString(" end")
+
+	return out.String()
+}
+
+func (clause *CaseClause) String() string {
+	out := strings.Builder{}
+	out.WriteString(clause.Pattern.String())
+	if clause.Guard != nil {
+		out.WriteString(" when ")
+		out.WriteString(clause.Guard.String())
+	}
+	out.WriteString(" -> ")
+	out.WriteString(clause.Body.String())
+
+	return out.String()
+}
@@ -0,0 +1,25 @@
+package ast
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"strconv"
+)
+
+type Float struct {
+	Token lexer.Token
+	Value float64
+}
+
+func (float *Float) Span() lexer.Span {
+	return float.Token.Span
+}
+
+func (float *Float) TokenLiteral() string {
+	return float.Token.Literal
+}
+
+func (float *Float) expression() {}
+
+func (float *Float) String() string {
+	return strconv.FormatFloat(float.Value, 'g', -1, 64)
+}
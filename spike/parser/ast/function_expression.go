@@ -7,12 +7,20 @@ import (
 
 type FunctionExpression struct {
 	Token      lexer.Token
+	Name       string
 	Parameters []*Identifier
 	Body       Statement
+	// Generator is set for functions containing yield: calling them returns
+	// a generator instead of running the body.
+	Generator bool
 }
 
 func (function *FunctionExpression) expression() {}
 
+func (function *FunctionExpression) Span() lexer.Span {
+	return lexer.Span{Start: function.Token.Span.Start, End: function.Body.Span().End}
+}
+
 func (function *FunctionExpression) TokenLiteral() string {
 	return function.Token.Literal
 }
@@ -10,6 +10,11 @@ import (
 type Hash struct {
 	Token lexer.Token
 	Pairs map[Expression]Expression
+	End   lexer.Position
+}
+
+func (hash *Hash) Span() lexer.Span {
+	return lexer.Span{Start: hash.Token.Span.Start, End: hash.End}
 }
 
 func (hash *Hash) TokenLiteral() string {
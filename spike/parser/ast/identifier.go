@@ -7,6 +7,10 @@ type Identifier struct {
 	Value string
 }
 
+func (identifier *Identifier) Span() lexer.Span {
+	return identifier.Token.Span
+}
+
 func (identifier *Identifier) TokenLiteral() string {
 	return identifier.Token.Literal
 }
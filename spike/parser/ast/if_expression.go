@@ -14,6 +14,15 @@ type IfExpression struct {
 
 func (expression *IfExpression) expression() {}
 
+func (expression *IfExpression) Span() lexer.Span {
+	end := expression.Then.Span().End
+	if expression.Else != nil {
+		end = expression.Else.Span().End
+	}
+
+	return lexer.Span{Start: expression.Token.Span.Start, End: end}
+}
+
 func (expression *IfExpression) TokenLiteral() string {
 	return expression.Token.Literal
 }
@@ -10,6 +10,11 @@ type IndexExpression struct {
 	Token lexer.Token
 	Array Expression
 	Index Expression
+	End   lexer.Position
+}
+
+func (index *IndexExpression) Span() lexer.Span {
+	return lexer.Span{Start: index.Array.Span().Start, End: index.End}
 }
 
 func (index *IndexExpression) TokenLiteral() string {
@@ -14,6 +14,10 @@ type InfixExpression struct {
 
 func (expression *InfixExpression) expression() {}
 
+func (expression *InfixExpression) Span() lexer.Span {
+	return lexer.Span{Start: expression.Left.Span().Start, End: expression.Right.Span().End}
+}
+
 func (expression *InfixExpression) TokenLiteral() string {
 	return expression.Token.Literal
 }
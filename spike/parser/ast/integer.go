@@ -10,6 +10,10 @@ type Integer struct {
 	Value int64
 }
 
+func (integer *Integer) Span() lexer.Span {
+	return integer.Token.Span
+}
+
 func (integer *Integer) TokenLiteral() string {
 	return integer.Token.Literal
 }
@@ -9,6 +9,15 @@ type LetStatement struct {
 	Token lexer.Token
 	Name  *Identifier
 	Value Expression
+	Doc   string
+}
+
+func (let *LetStatement) Span() lexer.Span {
+	if let.Value == nil {
+		return let.Token.Span
+	}
+
+	return lexer.Span{Start: let.Token.Span.Start, End: let.Value.Span().End}
 }
 
 func (let *LetStatement) TokenLiteral() string {
@@ -0,0 +1,172 @@
+package ast
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"strings"
+)
+
+// Pattern is the left-hand side of a case clause.
+type Pattern interface {
+	Node
+	pattern()
+}
+
+// LiteralPattern matches values equal to a literal: a number, string,
+// boolean or atom.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (pattern *LiteralPattern) pattern() {}
+
+func (pattern *LiteralPattern) Span() lexer.Span {
+	return pattern.Value.Span()
+}
+
+func (pattern *LiteralPattern) TokenLiteral() string {
+	return pattern.Value.TokenLiteral()
+}
+
+func (pattern *LiteralPattern) String() string {
+	return pattern.Value.String()
+}
+
+// BindingPattern matches any value and binds it to Name.
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (pattern *BindingPattern) pattern() {}
+
+func (pattern *BindingPattern) Span() lexer.Span {
+	return pattern.Name.Span()
+}
+
+func (pattern *BindingPattern) TokenLiteral() string {
+	return pattern.Name.TokenLiteral()
+}
+
+func (pattern *BindingPattern) String() string {
+	return pattern.Name.String()
+}
+
+// WildcardPattern, written as _, matches any value without binding it.
+type WildcardPattern struct {
+	Token lexer.Token
+}
+
+func (pattern *WildcardPattern) pattern() {}
+
+func (pattern *WildcardPattern) Span() lexer.Span {
+	return pattern.Token.Span
+}
+
+func (pattern *WildcardPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+
+func (pattern *WildcardPattern) String() string {
+	return "_"
+}
+
+// TuplePattern matches tuples of the same size whose elements match.
+type TuplePattern struct {
+	Token    lexer.Token
+	Elements []Pattern
+	End      lexer.Position
+}
+
+func (pattern *TuplePattern) pattern() {}
+
+func (pattern *TuplePattern) Span() lexer.Span {
+	return lexer.Span{Start: pattern.Token.Span.Start, End: pattern.End}
+}
+
+func (pattern *TuplePattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+
+func (pattern *TuplePattern) String() string {
+	out := strings.Builder{}
+	out.WriteString("(")
+	out.WriteString(joinPatterns(pattern.Elements))
+	if len(pattern.Elements) == 1 {
+		out.WriteString(",")
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// ArrayPattern matches arrays whose leading elements match Elements. Without
+// Rest the array must have exactly len(Elements) elements, with Rest the
+// remaining elements are matched against Rest as a new array.
+type ArrayPattern struct {
+	Token    lexer.Token
+	Elements []Pattern
+	Rest     Pattern
+	End      lexer.Position
+}
+
+func (pattern *ArrayPattern) pattern() {}
+
+func (pattern *ArrayPattern) Span() lexer.Span {
+	return lexer.Span{Start: pattern.Token.Span.Start, End: pattern.End}
+}
+
+func (pattern *ArrayPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+
+func (pattern *ArrayPattern) String() string {
+	out := strings.Builder{}
+	out.WriteString("[")
+	out.WriteString(joinPatterns(pattern.Elements))
+	if pattern.Rest != nil {
+		if len(pattern.Elements) > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString("...")
+		out.WriteString(pattern.Rest.String())
+	}
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// HashPattern matches hashes containing all of Keys, with the value under each
+// key matching the corresponding pattern in Values. Other keys are ignored.
+type HashPattern struct {
+	Token  lexer.Token
+	Keys   []Expression
+	Values []Pattern
+	End    lexer.Position
+}
+
+func (pattern *HashPattern) pattern() {}
+
+func (pattern *HashPattern) Span() lexer.Span {
+	return lexer.Span{Start: pattern.Token.Span.Start, End: pattern.End}
+}
+
+func (pattern *HashPattern) TokenLiteral() string {
+	return pattern.Token.Literal
+}
+
+func (pattern *HashPattern) String() string {
+	pairs := make([]string, 0, len(pattern.Keys))
+	for i, key := range pattern.Keys {
+		pairs = append(pairs, key.String()+": "+pattern.Values[i].String())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func joinPatterns(patterns []Pattern) string {
+	elements := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		elements = append(elements, pattern.String())
+	}
+
+	return strings.Join(elements, ", ")
+}
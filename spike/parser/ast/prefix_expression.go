@@ -13,6 +13,10 @@ type PrefixExpression struct {
 
 func (expression *PrefixExpression) expression() {}
 
+func (expression *PrefixExpression) Span() lexer.Span {
+	return lexer.Span{Start: expression.Token.Span.Start, End: expression.Right.Span().End}
+}
+
 func (expression *PrefixExpression) TokenLiteral() string {
 	return expression.Token.Literal
 }
@@ -1,15 +1,30 @@
 package ast
 
-import "strings"
+import (
+	"spike-interpreter-go/spike/lexer"
+	"strings"
+)
 
 type Program struct {
 	Statements []Statement
+	Comments   []lexer.Token
 }
 
 func (program *Program) TokenLiteral() string {
 	return "program"
 }
 
+func (program *Program) Span() lexer.Span {
+	if len(program.Statements) == 0 {
+		return lexer.Span{}
+	}
+
+	return lexer.Span{
+		Start: program.Statements[0].Span().Start,
+		End:   program.Statements[len(program.Statements)-1].Span().End,
+	}
+}
+
 func (program *Program) AddStatement(statement Statement) {
 	program.Statements = append(program.Statements, statement)
 }
@@ -0,0 +1,45 @@
+package ast
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"strings"
+)
+
+// ReceiveExpression takes the oldest message of the process mailbox matching
+// one of its clauses and evaluates the body of that clause. Without a match,
+// it waits for new messages, at most Timeout milliseconds when After is set.
+type ReceiveExpression struct {
+	Token   lexer.Token
+	Clauses []*CaseClause
+	Timeout Expression
+	After   *BlockStatement
+	End     lexer.Position
+}
+
+func (expression *ReceiveExpression) expression() {}
+
+func (expression *ReceiveExpression) Span() lexer.Span {
+	return lexer.Span{Start: expression.Token.Span.Start, End: expression.End}
+}
+
+func (expression *ReceiveExpression) TokenLiteral() string {
+	return expression.Token.Literal
+}
+
+func (expression *ReceiveExpression) String() string {
+	out := strings.Builder{}
+	out.WriteString("receive")
+	for _, clause := range expression.Clauses {
+		out.WriteString(" ")
+		out.WriteString(clause.String())
+	}
+	if expression.After != nil {
+		out.WriteString(" after ")
+		out.WriteString(expression.Timeout.String())
+		out.WriteString(" -> ")
+		out.WriteString(expression.After.String())
+	}
+	out.WriteString(" end")
+
+	return out.String()
+}
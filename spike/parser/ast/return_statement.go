@@ -10,6 +10,14 @@ type ReturnStatement struct {
 	Result Expression
 }
 
+func (returnStatement *ReturnStatement) Span() lexer.Span {
+	if returnStatement.Result == nil {
+		return returnStatement.Token.Span
+	}
+
+	return lexer.Span{Start: returnStatement.Token.Span.Start, End: returnStatement.Result.Span().End}
+}
+
 func (returnStatement *ReturnStatement) TokenLiteral() string {
 	return returnStatement.Token.Literal
 }
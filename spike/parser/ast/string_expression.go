@@ -10,6 +10,10 @@ type String struct {
 	Value string
 }
 
+func (str *String) Span() lexer.Span {
+	return str.Token.Span
+}
+
 func (str *String) TokenLiteral() string {
 	return str.Token.Literal
 }
@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"strings"
+)
+
+type Tuple struct {
+	Token    lexer.Token
+	Elements []Expression
+	End      lexer.Position
+}
+
+func (tuple *Tuple) Span() lexer.Span {
+	return lexer.Span{Start: tuple.Token.Span.Start, End: tuple.End}
+}
+
+func (tuple *Tuple) TokenLiteral() string {
+	return tuple.Token.Literal
+}
+
+func (tuple *Tuple) String() string {
+	out := strings.Builder{}
+
+	out.WriteString("(")
+
+	for i, element := range tuple.Elements {
+		out.WriteString(element.String())
+		if i < len(tuple.Elements)-1 {
+			out.WriteString(", ")
+		}
+	}
+
+	if len(tuple.Elements) == 1 {
+		out.WriteString(",")
+	}
+
+	out.WriteString(")")
+
+	return out.String()
+}
+
+func (tuple *Tuple) expression() {
+}
@@ -0,0 +1,35 @@
+package ast
+
+import (
+	"spike-interpreter-go/spike/lexer"
+)
+
+// YieldExpression suspends the generator it runs in, handing Value (null when
+// missing) to the caller of next. It evaluates to the value passed to the
+// next call resuming the generator.
+type YieldExpression struct {
+	Token lexer.Token
+	Value Expression
+}
+
+func (expression *YieldExpression) expression() {}
+
+func (expression *YieldExpression) Span() lexer.Span {
+	if expression.Value == nil {
+		return expression.Token.Span
+	}
+
+	return lexer.Span{Start: expression.Token.Span.Start, End: expression.Value.Span().End}
+}
+
+func (expression *YieldExpression) TokenLiteral() string {
+	return expression.Token.Literal
+}
+
+func (expression *YieldExpression) String() string {
+	if expression.Value == nil {
+		return "yield"
+	}
+
+	return "yield " + expression.Value.String()
+}
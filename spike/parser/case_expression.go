@@ -0,0 +1,361 @@
+package parser
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/parser/ast"
+)
+
+const wildcard = "_"
+
+func (parser *Parser) parseCaseExpression() (ast.Expression, error) {
+	expression := &ast.CaseExpression{
+		Token:   parser.currentToken,
+		Clauses: make([]*ast.CaseClause, 0),
+	}
+
+	parser.advanceToken()
+	subject, err := parser.parseExpression(lowest)
+	if err != nil {
+		return expression, err
+	}
+	expression.Subject = subject
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.Of {
+		return expression, parser.errorf("expected of, got %s", parser.currentToken.Type)
+	}
+
+	for parser.advanceToken(); parser.currentToken.Type != lexer.End; parser.advanceToken() {
+		if parser.currentToken.Type == lexer.Eof {
+			return expression, parser.errorWithHint(
+				"a case expression must be closed with end",
+				"expected end, got %s",
+				parser.currentToken.Type,
+			)
+		}
+
+		clause, err := parser.parseCaseClause()
+		if err != nil {
+			return expression, err
+		}
+		expression.Clauses = append(expression.Clauses, clause)
+	}
+
+	if len(expression.Clauses) == 0 {
+		return expression, parser.errorf("case expression must have at least one clause")
+	}
+	expression.End = parser.currentToken.Span.End
+
+	return expression, nil
+}
+
+func (parser *Parser) parseCaseClause() (*ast.CaseClause, error) {
+	parser.patternNames = map[string]bool{}
+	pattern, err := parser.parsePattern()
+	if err != nil {
+		return nil, err
+	}
+	clause := &ast.CaseClause{Pattern: pattern}
+
+	parser.advanceToken()
+	if parser.currentToken.Type == lexer.When {
+		parser.advanceToken()
+		guard, err := parser.parseExpression(lowest)
+		if err != nil {
+			return clause, err
+		}
+		clause.Guard = guard
+
+		parser.advanceToken()
+	}
+
+	if parser.currentToken.Type != lexer.Arrow {
+		return clause, parser.errorf("expected arrow, got %s", parser.currentToken.Type)
+	}
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.LeftBrace {
+		return clause, parser.errorf("expected left brace, got: %s", parser.currentToken.Type)
+	}
+
+	body, err := parser.parseBlockStatement()
+	if err != nil {
+		return clause, err
+	}
+	clause.Body = body.(*ast.BlockStatement)
+
+	return clause, nil
+}
+
+// parsePattern parses a pattern of the clause started by parseCaseClause,
+// which binds each name once.
+func (parser *Parser) parsePattern() (ast.Pattern, error) {
+	switch parser.currentToken.Type {
+	case lexer.Identifier:
+		if parser.currentToken.Literal == wildcard {
+			return &ast.WildcardPattern{Token: parser.currentToken}, nil
+		}
+
+		if parser.patternNames[parser.currentToken.Literal] {
+			return nil, parser.errorWithHint(
+				"compare values with a guard, e.g. (x, y) when x == y",
+				"%s is bound more than once in this pattern",
+				parser.currentToken.Literal,
+			)
+		}
+		parser.patternNames[parser.currentToken.Literal] = true
+
+		return &ast.BindingPattern{
+			Name: &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal},
+		}, nil
+	case lexer.LeftParenthesis:
+		return parser.parseTuplePattern()
+	case lexer.LeftBracket:
+		return parser.parseArrayPattern()
+	case lexer.LeftBrace:
+		return parser.parseHashPattern()
+	}
+
+	value, err := parser.parsePatternLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.LiteralPattern{Value: value}, nil
+}
+
+// parsePatternLiteral parses literals allowed in patterns and as keys of hash
+// patterns: numbers (optionally negated), strings, booleans and atoms.
+func (parser *Parser) parsePatternLiteral() (ast.Expression, error) {
+	switch parser.currentToken.Type {
+	case lexer.Integer, lexer.Float, lexer.String, lexer.True, lexer.False, lexer.Colon:
+		return parser.prefixParsers[parser.currentToken.Type]()
+	case lexer.Minus:
+		if parser.peekToken.Type == lexer.Integer || parser.peekToken.Type == lexer.Float {
+			return parser.parsePrefixExpression()
+		}
+	}
+
+	return nil, parser.errorWithHint(
+		"patterns can be literals, names, _, tuples, arrays or hashes",
+		"expected pattern, got %s",
+		parser.currentToken.Type,
+	)
+}
+
+// parseTuplePattern parses (p1, p2, ...). Like in expressions, a single
+// pattern in parentheses is only a tuple when followed by a comma.
+func (parser *Parser) parseTuplePattern() (ast.Pattern, error) {
+	token := parser.currentToken
+
+	elements, rest, trailingComma, err := parser.parsePatternList(lexer.RightParenthesis, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(elements) == 1 && !trailingComma && rest == nil {
+		return elements[0], nil
+	}
+
+	return &ast.TuplePattern{
+		Token:    token,
+		Elements: elements,
+		End:      parser.currentToken.Span.End,
+	}, nil
+}
+
+func (parser *Parser) parseArrayPattern() (ast.Pattern, error) {
+	token := parser.currentToken
+
+	elements, rest, _, err := parser.parsePatternList(lexer.RightBracket, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ArrayPattern{
+		Token:    token,
+		Elements: elements,
+		Rest:     rest,
+		End:      parser.currentToken.Span.End,
+	}, nil
+}
+
+// parsePatternList parses comma separated patterns up to the closing token.
+// When allowRest is set the list may end with ...name or ..._ which is
+// returned as rest.
+func (parser *Parser) parsePatternList(closing lexer.TokenType, allowRest bool) ([]ast.Pattern, ast.Pattern, bool, error) {
+	elements := make([]ast.Pattern, 0)
+	trailingComma := false
+
+	for {
+		parser.advanceToken()
+		if parser.currentToken.Type == closing {
+			return elements, nil, trailingComma, nil
+		}
+
+		if allowRest && parser.currentToken.Type == lexer.Ellipsis {
+			rest, err := parser.parseRestPattern(closing)
+			return elements, rest, false, err
+		}
+
+		element, err := parser.parsePattern()
+		if err != nil {
+			return elements, nil, false, err
+		}
+		elements = append(elements, element)
+
+		parser.advanceToken()
+		if parser.currentToken.Type == closing {
+			return elements, nil, false, nil
+		}
+
+		if parser.currentToken.Type != lexer.Comma {
+			return elements, nil, false, parser.errorf("expected comma, got %s", parser.currentToken.Type)
+		}
+		trailingComma = true
+	}
+}
+
+func (parser *Parser) parseRestPattern(closing lexer.TokenType) (ast.Pattern, error) {
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.Identifier {
+		return nil, parser.errorf("expected identifier after ..., got %s", parser.currentToken.Type)
+	}
+
+	rest, err := parser.parsePattern()
+	if err != nil {
+		return nil, err
+	}
+
+	parser.advanceToken()
+	if parser.currentToken.Type != closing {
+		return nil, parser.errorWithHint(
+			"the rest pattern must be the last element",
+			"expected %s, got %s",
+			closing,
+			parser.currentToken.Type,
+		)
+	}
+
+	return rest, nil
+}
+
+func (parser *Parser) parseHashPattern() (ast.Pattern, error) {
+	pattern := &ast.HashPattern{
+		Token:  parser.currentToken,
+		Keys:   make([]ast.Expression, 0),
+		Values: make([]ast.Pattern, 0),
+	}
+
+	for {
+		parser.advanceToken()
+		if parser.currentToken.Type == lexer.RightBrace {
+			break
+		}
+
+		key, err := parser.parsePatternLiteral()
+		if err != nil {
+			return nil, err
+		}
+
+		parser.advanceToken()
+		if parser.currentToken.Type != lexer.Colon {
+			return nil, parser.errorf("expected colon, got: %s", parser.currentToken.Literal)
+		}
+
+		parser.advanceToken()
+		value, err := parser.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+
+		pattern.Keys = append(pattern.Keys, key)
+		pattern.Values = append(pattern.Values, value)
+
+		parser.advanceToken()
+		if parser.currentToken.Type == lexer.RightBrace {
+			break
+		}
+
+		if parser.currentToken.Type != lexer.Comma {
+			return nil, parser.errorf("expected comma, got %s", parser.currentToken.Type)
+		}
+	}
+	pattern.End = parser.currentToken.Span.End
+
+	return pattern, nil
+}
+
+func (parser *Parser) parseReceiveExpression() (ast.Expression, error) {
+	expression := &ast.ReceiveExpression{
+		Token:   parser.currentToken,
+		Clauses: make([]*ast.CaseClause, 0),
+	}
+
+	for parser.advanceToken(); parser.currentToken.Type != lexer.End; parser.advanceToken() {
+		switch parser.currentToken.Type {
+		case lexer.Eof:
+			return expression, parser.errorWithHint(
+				"a receive expression must be closed with end",
+				"expected end, got %s",
+				parser.currentToken.Type,
+			)
+		case lexer.After:
+			err := parser.parseReceiveTimeout(expression)
+			if err != nil {
+				return expression, err
+			}
+			continue
+		}
+
+		if expression.After != nil {
+			return expression, parser.errorf("expected end, got %s", parser.currentToken.Type)
+		}
+
+		clause, err := parser.parseCaseClause()
+		if err != nil {
+			return expression, err
+		}
+		expression.Clauses = append(expression.Clauses, clause)
+	}
+
+	if len(expression.Clauses) == 0 && expression.After == nil {
+		return expression, parser.errorf("receive expression must have at least one clause")
+	}
+	expression.End = parser.currentToken.Span.End
+
+	return expression, nil
+}
+
+// parseReceiveTimeout parses the after <milliseconds> -> { ... } clause, which
+// must be the last one.
+func (parser *Parser) parseReceiveTimeout(expression *ast.ReceiveExpression) error {
+	if expression.After != nil {
+		return parser.errorf("expected end, got %s", parser.currentToken.Type)
+	}
+
+	parser.advanceToken()
+	timeout, err := parser.parseExpression(lowest)
+	if err != nil {
+		return err
+	}
+	expression.Timeout = timeout
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.Arrow {
+		return parser.errorf("expected arrow, got %s", parser.currentToken.Type)
+	}
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.LeftBrace {
+		return parser.errorf("expected left brace, got: %s", parser.currentToken.Type)
+	}
+
+	body, err := parser.parseBlockStatement()
+	if err != nil {
+		return err
+	}
+	expression.After = body.(*ast.BlockStatement)
+
+	return nil
+}
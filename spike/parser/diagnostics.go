@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"fmt"
+	"spike-interpreter-go/spike/lexer"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+type Diagnostic struct {
+	Severity Severity
+	Span     lexer.Span
+	Message  string
+	Hint     string
+}
+
+func (diagnostic *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s", diagnostic.Span.Start, diagnostic.Message)
+}
+
+// Diagnostics is returned by ParseProgram when at least one error was
+// reported. It lists every problem found, in source order.
+type Diagnostics []*Diagnostic
+
+func (diagnostics Diagnostics) Error() string {
+	messages := make([]string, len(diagnostics))
+	for i, diagnostic := range diagnostics {
+		messages[i] = diagnostic.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
@@ -141,11 +141,11 @@ func Test_invalid_expressions(t *testing.T) {
 	}{
 		"let after minus operator": {
 			code:          `-let;`,
-			expectedError: `"let" is not a valid prefix expression`,
+			expectedError: `1:2: "let" is not a valid prefix expression`,
 		},
 		"return after minus operator": {
 			code:          `-return;`,
-			expectedError: `"return" is not a valid prefix expression`,
+			expectedError: `1:2: "return" is not a valid prefix expression`,
 		},
 	}
 
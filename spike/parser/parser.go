@@ -1,13 +1,18 @@
 package parser
 
 import (
+	"fmt"
+	"math/big"
 	"spike-interpreter-go/spike/lexer"
 	"spike-interpreter-go/spike/parser/ast"
 	"strconv"
-
-	"github.com/pkg/errors"
+	"strings"
 )
 
+// toStringBuiltin is the builtin called on interpolated expressions. It is
+// not an identifier, so that bindings named str do not replace it.
+const toStringBuiltin = "$str"
+
 type prefixParseFunc func() (ast.Expression, error)
 type infixParseFunc func(expression ast.Expression) (ast.Expression, error)
 
@@ -39,6 +44,7 @@ var precedences = map[lexer.TokenType]int{
 	lexer.Or:              alternative,
 	lexer.LeftParenthesis: call,
 	lexer.LeftBracket:     index,
+	lexer.Dot:             index,
 }
 
 type Parser struct {
@@ -47,6 +53,19 @@ type Parser struct {
 	peekToken     lexer.Token
 	prefixParsers map[lexer.TokenType]prefixParseFunc
 	infixParsers  map[lexer.TokenType]infixParseFunc
+	diagnostics   Diagnostics
+	currentError  error
+	peekError     error
+
+	comments        []lexer.Token
+	currentComments []lexer.Token
+	peekComments    []lexer.Token
+
+	// functions holds the function expressions being parsed, innermost last,
+	// and nil for process bodies.
+	functions []*ast.FunctionExpression
+	// patternNames holds the names bound by the pattern being parsed.
+	patternNames map[string]bool
 }
 
 func New(lexerInstance *lexer.Lexer) *Parser {
@@ -56,6 +75,7 @@ func New(lexerInstance *lexer.Lexer) *Parser {
 
 	parser.addPrefixParser(lexer.Identifier, parser.parseIdentifier)
 	parser.addPrefixParser(lexer.Integer, parser.parseInteger)
+	parser.addPrefixParser(lexer.Float, parser.parseFloat)
 	parser.addPrefixParser(lexer.True, parser.parseBoolean)
 	parser.addPrefixParser(lexer.False, parser.parseBoolean)
 	parser.addPrefixParser(lexer.Bang, parser.parsePrefixExpression)
@@ -64,8 +84,14 @@ func New(lexerInstance *lexer.Lexer) *Parser {
 	parser.addPrefixParser(lexer.If, parser.parseIfExpression)
 	parser.addPrefixParser(lexer.Fn, parser.parseFunctionExpression)
 	parser.addPrefixParser(lexer.String, parser.parseString)
+	parser.addPrefixParser(lexer.Template, parser.parseTemplate)
+	parser.addPrefixParser(lexer.Invalid, parser.parseInvalid)
 	parser.addPrefixParser(lexer.LeftBracket, parser.parseArray)
 	parser.addPrefixParser(lexer.LeftBrace, parser.parseHash)
+	parser.addPrefixParser(lexer.Colon, parser.parseAtom)
+	parser.addPrefixParser(lexer.Case, parser.parseCaseExpression)
+	parser.addPrefixParser(lexer.Receive, parser.parseReceiveExpression)
+	parser.addPrefixParser(lexer.Yield, parser.parseYieldExpression)
 
 	parser.addInfixParser(lexer.Plus, parser.parseInfixExpression)
 	parser.addInfixParser(lexer.Asterisk, parser.parseInfixExpression)
@@ -81,10 +107,14 @@ func New(lexerInstance *lexer.Lexer) *Parser {
 	parser.addInfixParser(lexer.And, parser.parseInfixExpression)
 	parser.addInfixParser(lexer.LeftParenthesis, parser.parseCallExpression)
 	parser.addInfixParser(lexer.LeftBracket, parser.parseIndexExpression)
+	parser.addInfixParser(lexer.Dot, parser.parseMemberExpression)
 
 	return parser
 }
 
+// ParseProgram parses the whole input. Statements that fail to parse are
+// reported and skipped, so the returned program holds every statement that
+// could be parsed even when the error (of type Diagnostics) is not nil.
 func (parser *Parser) ParseProgram() (*ast.Program, error) {
 	program := &ast.Program{}
 
@@ -93,7 +123,8 @@ func (parser *Parser) ParseProgram() (*ast.Program, error) {
 	for parser.advanceToken(); parser.currentToken.Type != lexer.Eof; parser.advanceToken() {
 		statement, err := parser.parseStatement()
 		if err != nil {
-			return program, err
+			parser.recover(err)
+			continue
 		}
 
 		program.AddStatement(statement)
@@ -103,6 +134,12 @@ func (parser *Parser) ParseProgram() (*ast.Program, error) {
 		}
 	}
 
+	program.Comments = parser.comments
+
+	if len(parser.diagnostics) > 0 {
+		return program, parser.diagnostics
+	}
+
 	return program, nil
 }
 
@@ -114,9 +151,98 @@ func (parser *Parser) addInfixParser(tokenType lexer.TokenType, infixParser infi
 	parser.infixParsers[tokenType] = infixParser
 }
 
+// advanceToken moves to the next token. Comment tokens, emitted only when the
+// lexer was asked to, are set aside so they can be attached as documentation;
+// comments trailing code on the same line are never documentation.
 func (parser *Parser) advanceToken() {
 	parser.currentToken = parser.peekToken
-	parser.peekToken, _ = parser.lexerInstance.NextToken()
+	parser.currentError = parser.peekError
+	parser.currentComments = parser.peekComments
+	parser.peekComments = nil
+
+	for {
+		parser.peekToken, parser.peekError = parser.lexerInstance.NextToken()
+		if parser.peekToken.Type != lexer.Comment {
+			return
+		}
+
+		parser.comments = append(parser.comments, parser.peekToken)
+
+		trailing := parser.peekToken.Span.Start.Line == parser.currentToken.Span.End.Line
+		if !trailing {
+			parser.peekComments = append(parser.peekComments, parser.peekToken)
+		}
+	}
+}
+
+func (parser *Parser) errorf(format string, args ...interface{}) error {
+	return parser.errorWithHint("", format, args...)
+}
+
+func (parser *Parser) errorWithHint(hint string, format string, args ...interface{}) error {
+	return &Diagnostic{
+		Severity: SeverityError,
+		Span:     parser.currentToken.Span,
+		Message:  fmt.Sprintf(format, args...),
+		Hint:     hint,
+	}
+}
+
+func (parser *Parser) lexerError(err error) error {
+	lexerError, ok := err.(*lexer.Error)
+	if !ok {
+		return parser.errorf("%s", err)
+	}
+
+	end := lexerError.End
+	if end == (lexer.Position{}) {
+		end = lexerError.Position
+	}
+
+	return &Diagnostic{
+		Severity: SeverityError,
+		Span:     lexer.Span{Start: lexerError.Position, End: end},
+		Message:  lexerError.Message,
+	}
+}
+
+// recover records the error and skips tokens until the start of the next
+// statement: just past a semicolon, or right before let, return or a closing
+// brace. Blocks opened while skipping are skipped as a whole. When the error
+// is at a closing brace, recover stops on it, so that the block it closes
+// ends there.
+func (parser *Parser) recover(err error) {
+	diagnostic, ok := err.(*Diagnostic)
+	if !ok {
+		diagnostic = &Diagnostic{Severity: SeverityError, Span: parser.currentToken.Span, Message: err.Error()}
+	}
+	parser.diagnostics = append(parser.diagnostics, diagnostic)
+
+	depth := 0
+	for parser.currentToken.Type != lexer.Eof {
+		switch parser.currentToken.Type {
+		case lexer.LeftBrace:
+			depth++
+		case lexer.RightBrace:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case lexer.Semicolon:
+			if depth == 0 {
+				return
+			}
+		}
+
+		if depth == 0 {
+			switch parser.peekToken.Type {
+			case lexer.Let, lexer.Return, lexer.RightBrace, lexer.Eof:
+				return
+			}
+		}
+
+		parser.advanceToken()
+	}
 }
 
 func (parser *Parser) parseStatement() (ast.Statement, error) {
@@ -125,18 +251,46 @@ func (parser *Parser) parseStatement() (ast.Statement, error) {
 		return parser.parseLetStatement()
 	case lexer.Return:
 		return parser.parseReturnStatement()
+	case lexer.Identifier:
+		if parser.currentToken.Literal == processKeyword && parser.peekToken.Type == lexer.Identifier {
+			return parser.parseProcessStatement()
+		}
+		if parser.peekToken.Type == lexer.Assign {
+			return parser.parseAssignment()
+		}
+		return parser.parseExpressionStatement()
 	default:
 		return parser.parseExpressionStatement()
 	}
 }
 
+// parseAssignment parses name = value only to report it: variables are
+// immutable, so there is no assignment statement.
+func (parser *Parser) parseAssignment() (ast.Statement, error) {
+	name := parser.currentToken.Literal
+	err := parser.errorWithHint(
+		fmt.Sprintf("bind a new name with let, or shadow %s with let in a nested function", name),
+		"variables are immutable, %s can not be reassigned",
+		name,
+	)
+
+	parser.advanceToken()
+	parser.advanceToken()
+	_, _ = parser.parseExpression(lowest)
+
+	return nil, err
+}
+
 func (parser *Parser) parseLetStatement() (ast.Statement, error) {
-	letStatement := &ast.LetStatement{Token: parser.currentToken}
+	letStatement := &ast.LetStatement{
+		Token: parser.currentToken,
+		Doc:   docComment(parser.currentComments, parser.currentToken),
+	}
 
 	parser.advanceToken()
 
 	if parser.currentToken.Type != lexer.Identifier {
-		return letStatement, errors.Errorf("expected identifier, got %s", parser.currentToken.Type)
+		return letStatement, parser.errorf("expected identifier, got %s", parser.currentToken.Type)
 	}
 
 	letStatement.Name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
@@ -144,7 +298,11 @@ func (parser *Parser) parseLetStatement() (ast.Statement, error) {
 	parser.advanceToken()
 
 	if parser.currentToken.Type != lexer.Assign {
-		return letStatement, errors.Errorf("expected assign operator, got %s", parser.currentToken.Type)
+		return letStatement, parser.errorWithHint(
+			"let statements have the form: let <name> = <expression>",
+			"expected assign operator, got %s",
+			parser.currentToken.Type,
+		)
 	}
 
 	parser.advanceToken()
@@ -152,6 +310,10 @@ func (parser *Parser) parseLetStatement() (ast.Statement, error) {
 	expression, err := parser.parseExpression(lowest)
 	letStatement.Value = expression
 
+	if function, ok := expression.(*ast.FunctionExpression); ok {
+		function.Name = letStatement.Name.Value
+	}
+
 	return letStatement, err
 }
 
@@ -160,7 +322,7 @@ func (parser *Parser) parseIfExpression() (ast.Expression, error) {
 
 	parser.advanceToken()
 	if parser.currentToken.Type != lexer.LeftParenthesis {
-		return ifExpression, errors.Errorf("expected left parenthesis, got %s", parser.currentToken.Type)
+		return ifExpression, parser.errorf("expected left parenthesis, got %s", parser.currentToken.Type)
 	}
 
 	parser.advanceToken()
@@ -172,12 +334,16 @@ func (parser *Parser) parseIfExpression() (ast.Expression, error) {
 
 	parser.advanceToken()
 	if parser.currentToken.Type != lexer.RightParenthesis {
-		return ifExpression, errors.Errorf("expected right parenthesis, got %s", parser.currentToken.Type)
+		return ifExpression, parser.errorWithHint(
+			"the condition of an if expression must be wrapped in parentheses",
+			"expected right parenthesis, got %s",
+			parser.currentToken.Type,
+		)
 	}
 
 	parser.advanceToken()
 	if parser.currentToken.Type != lexer.LeftBrace {
-		return ifExpression, errors.Errorf("expected left brace, got: %s", parser.currentToken.Type)
+		return ifExpression, parser.errorf("expected left brace, got: %s", parser.currentToken.Type)
 	}
 
 	block, err := parser.parseBlockStatement()
@@ -193,7 +359,7 @@ func (parser *Parser) parseIfExpression() (ast.Expression, error) {
 	parser.advanceToken()
 	parser.advanceToken()
 	if parser.currentToken.Type != lexer.LeftBrace {
-		return ifExpression, errors.Errorf("expected left brace, got: %s", parser.currentToken.Type)
+		return ifExpression, parser.errorf("expected left brace, got: %s", parser.currentToken.Type)
 	}
 
 	block, err = parser.parseBlockStatement()
@@ -210,48 +376,58 @@ func (parser *Parser) parseFunctionExpression() (ast.Expression, error) {
 
 	parser.advanceToken()
 	if parser.currentToken.Type != lexer.LeftParenthesis {
-		return functionExpression, errors.Errorf("expected left parenthesis, got %s", parser.currentToken.Type)
+		return functionExpression, parser.errorf("expected left parenthesis, got %s", parser.currentToken.Type)
 	}
 
+	parameters, err := parser.parseFunctionParameters()
+	functionExpression.Parameters = parameters
+	if err != nil {
+		return functionExpression, err
+	}
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.LeftBrace {
+		return functionExpression, parser.errorf("expected left brace, got: %s", parser.currentToken.Type)
+	}
+
+	parser.functions = append(parser.functions, functionExpression)
+	block, err := parser.parseBlockStatement()
+	parser.functions = parser.functions[:len(parser.functions)-1]
+	if err != nil {
+		return functionExpression, err
+	}
+
+	functionExpression.Body = block
+
+	return functionExpression, nil
+}
+
+// parseFunctionParameters parses identifiers from the current left
+// parenthesis up to the matching right one.
+func (parser *Parser) parseFunctionParameters() ([]*ast.Identifier, error) {
+	var parameters []*ast.Identifier
+
 	for {
 		parser.advanceToken()
 		if parser.currentToken.Type == lexer.RightParenthesis {
-			break
+			return parameters, nil
 		}
 
 		if parser.currentToken.Type != lexer.Identifier {
-			return functionExpression, errors.Errorf("expected identifier, got %s", parser.currentToken.Type)
+			return parameters, parser.errorf("expected identifier, got %s", parser.currentToken.Type)
 		}
 
-		identifier, err := parser.parseIdentifier()
-		if err != nil {
-			return functionExpression, err
-		}
-		functionExpression.Parameters = append(functionExpression.Parameters, identifier.(*ast.Identifier))
+		parameters = append(parameters, &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal})
 
 		parser.advanceToken()
 		if parser.currentToken.Type == lexer.RightParenthesis {
-			break
+			return parameters, nil
 		}
 
 		if parser.currentToken.Type != lexer.Comma {
-			return functionExpression, errors.Errorf("expected comma, got %s", parser.currentToken.Type)
+			return parameters, parser.errorf("expected comma, got %s", parser.currentToken.Type)
 		}
 	}
-
-	parser.advanceToken()
-	if parser.currentToken.Type != lexer.LeftBrace {
-		return functionExpression, errors.Errorf("expected left brace, got: %s", parser.currentToken.Type)
-	}
-
-	block, err := parser.parseBlockStatement()
-	if err != nil {
-		return functionExpression, err
-	}
-
-	functionExpression.Body = block
-
-	return functionExpression, nil
 }
 
 func (parser *Parser) parseReturnStatement() (ast.Statement, error) {
@@ -259,7 +435,10 @@ func (parser *Parser) parseReturnStatement() (ast.Statement, error) {
 
 	parser.advanceToken()
 
-	expression, _ := parser.parseExpression(lowest)
+	expression, err := parser.parseExpression(lowest)
+	if err != nil {
+		return returnStatement, err
+	}
 	returnStatement.Result = expression
 
 	return returnStatement, nil
@@ -285,8 +464,15 @@ func (parser *Parser) parseExpression(precedence int) (ast.Expression, error) {
 	var expression ast.Expression
 	var err error
 	parsePrefixExpression, ok := parser.prefixParsers[parser.currentToken.Type]
+	if !ok && parser.currentToken.Type == lexer.Eof {
+		return expression, parser.errorf("unexpected end of input")
+	}
 	if !ok {
-		return expression, errors.Errorf("%q is not a valid prefix expression", parser.currentToken.Literal)
+		return expression, parser.errorWithHint(
+			"an expression was expected here",
+			"%q is not a valid prefix expression",
+			parser.currentToken.Literal,
+		)
 	}
 
 	expression, err = parsePrefixExpression()
@@ -303,12 +489,19 @@ func (parser *Parser) parseExpression(precedence int) (ast.Expression, error) {
 		parser.advanceToken()
 
 		expression, err = parseInfixExpression(expression)
+		if err != nil {
+			return expression, err
+		}
 	}
 
-	return expression, err
+	return expression, nil
 }
 
 func (parser *Parser) parseIdentifier() (ast.Expression, error) {
+	if parser.currentToken.Literal == spawnKeyword && parser.peekToken.Type == lexer.Identifier {
+		return parser.parseSpawnExpression()
+	}
+
 	expression := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
 
 	return expression, nil
@@ -320,10 +513,117 @@ func (parser *Parser) parseString() (ast.Expression, error) {
 	return expression, nil
 }
 
+// parseAtom parses :name. The name has to follow the colon directly, which
+// keeps atoms apart from the colon separating hash keys and values.
+func (parser *Parser) parseAtom() (ast.Expression, error) {
+	token := parser.currentToken
+	if parser.peekToken.Type != lexer.Identifier || parser.peekToken.Span.Start.Offset != token.Span.End.Offset {
+		return nil, parser.errorWithHint(
+			"atoms are written as a colon directly followed by a name, e.g. :ok",
+			"expected atom name, got %s",
+			parser.peekToken.Type,
+		)
+	}
+
+	parser.advanceToken()
+
+	return &ast.Atom{
+		Token: token,
+		Name:  parser.currentToken.Literal,
+		End:   parser.currentToken.Span.End,
+	}, nil
+}
+
+func (parser *Parser) parseInvalid() (ast.Expression, error) {
+	if _, ok := parser.currentError.(*lexer.Error); ok {
+		return nil, parser.lexerError(parser.currentError)
+	}
+	if parser.currentError != nil {
+		return nil, parser.errorf("%s", parser.currentError)
+	}
+
+	return nil, parser.errorf("unexpected character %q", parser.currentToken.Literal)
+}
+
+// parseTemplate desugars an interpolated string like "a ${b} c" into
+// "a " + str(b) + " c".
+func (parser *Parser) parseTemplate() (ast.Expression, error) {
+	template := parser.currentToken
+
+	parts, err := lexer.SplitTemplate(template)
+	if err != nil {
+		return nil, parser.lexerError(err)
+	}
+
+	var result ast.Expression
+	for _, part := range parts {
+		var expression ast.Expression = &ast.String{
+			Token: lexer.Token{Type: lexer.String, Literal: part.Text, Span: template.Span},
+			Value: part.Text,
+		}
+
+		if part.IsExpression {
+			expression, err = parser.parseInterpolation(part)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if result == nil {
+			result = expression
+			continue
+		}
+
+		result = &ast.InfixExpression{
+			Token:    lexer.Token{Type: lexer.Plus, Literal: "+", Span: template.Span},
+			Left:     result,
+			Operator: "+",
+			Right:    expression,
+		}
+	}
+
+	if result == nil {
+		return &ast.String{Token: lexer.Token{Type: lexer.String, Span: template.Span}}, nil
+	}
+
+	return result, nil
+}
+
+func (parser *Parser) parseInterpolation(part lexer.TemplatePart) (ast.Expression, error) {
+	interpolation := New(lexer.NewAt(strings.NewReader(part.Text), part.Position))
+	interpolation.advanceToken()
+	interpolation.advanceToken()
+
+	expression, err := interpolation.parseExpression(lowest)
+	if err != nil {
+		return nil, err
+	}
+
+	interpolation.advanceToken()
+	if interpolation.currentToken.Type != lexer.Eof {
+		return nil, interpolation.errorf("unexpected %s in interpolation", interpolation.currentToken.Type)
+	}
+
+	return &ast.CallExpression{
+		Token:     lexer.Token{Type: lexer.LeftParenthesis, Literal: "(", Span: expression.Span()},
+		Function:  &ast.Identifier{Token: lexer.Token{Type: lexer.Identifier, Literal: toStringBuiltin, Span: expression.Span()}, Value: toStringBuiltin},
+		Arguments: []ast.Expression{expression},
+		End:       expression.Span().End,
+	}, nil
+}
+
+// parseInteger parses integer literals, which are BigInteger nodes when they
+// do not fit in an int64.
 func (parser *Parser) parseInteger() (ast.Expression, error) {
 	value, err := strconv.ParseInt(parser.currentToken.Literal, 10, 64)
+	if numberError, ok := err.(*strconv.NumError); ok && numberError.Err == strconv.ErrRange {
+		bigValue, ok := new(big.Int).SetString(parser.currentToken.Literal, 10)
+		if ok {
+			return &ast.BigInteger{Token: parser.currentToken, Value: bigValue}, nil
+		}
+	}
 	if err != nil {
-		return nil, err
+		return nil, parser.errorf("invalid integer literal %q", parser.currentToken.Literal)
 	}
 
 	expression := &ast.Integer{
@@ -334,8 +634,22 @@ func (parser *Parser) parseInteger() (ast.Expression, error) {
 	return expression, nil
 }
 
+func (parser *Parser) parseFloat() (ast.Expression, error) {
+	value, err := strconv.ParseFloat(parser.currentToken.Literal, 64)
+	if err != nil {
+		return nil, parser.errorf("invalid float literal %q", parser.currentToken.Literal)
+	}
+
+	expression := &ast.Float{
+		Token: parser.currentToken,
+		Value: value,
+	}
+
+	return expression, nil
+}
+
 func (parser *Parser) parseBoolean() (ast.Expression, error) {
-	if parser.currentToken == lexer.TrueToken {
+	if parser.currentToken.Type == lexer.True {
 		return &ast.Boolean{Token: parser.currentToken, Value: true}, nil
 	}
 
@@ -365,21 +679,77 @@ func (parser *Parser) parseInfixExpression(left ast.Expression) (ast.Expression,
 	precedence, _ := precedences[parser.currentToken.Type]
 
 	parser.advanceToken()
-	expression.Right, _ = parser.parseExpression(precedence)
+	right, err := parser.parseExpression(precedence)
+	if err != nil {
+		return expression, err
+	}
+	expression.Right = right
 
 	return expression, nil
 }
 
+// parseGroupedExpression parses a parenthesized expression or a tuple
+// literal. A trailing comma makes a one-element tuple, so (1) is a grouped
+// integer and (1,) is a tuple.
 func (parser *Parser) parseGroupedExpression() (ast.Expression, error) {
+	token := parser.currentToken
 	parser.advanceToken()
 
-	expression, _ := parser.parseExpression(lowest)
+	if parser.currentToken.Type == lexer.RightParenthesis {
+		return &ast.Tuple{
+			Token:    token,
+			Elements: []ast.Expression{},
+			End:      parser.currentToken.Span.End,
+		}, nil
+	}
+
+	expression, err := parser.parseExpression(lowest)
+	if err != nil {
+		return expression, err
+	}
+
+	if parser.peekToken.Type == lexer.Comma {
+		return parser.parseTuple(token, expression)
+	}
 
 	parser.advanceToken()
+	if parser.currentToken.Type != lexer.RightParenthesis {
+		return expression, parser.errorf("expected right parenthesis, got %s", parser.currentToken.Type)
+	}
 
 	return expression, nil
 }
 
+func (parser *Parser) parseTuple(token lexer.Token, first ast.Expression) (ast.Expression, error) {
+	tuple := &ast.Tuple{
+		Token:    token,
+		Elements: []ast.Expression{first},
+	}
+
+	for parser.peekToken.Type == lexer.Comma {
+		parser.advanceToken()
+		parser.advanceToken()
+		if parser.currentToken.Type == lexer.RightParenthesis {
+			tuple.End = parser.currentToken.Span.End
+			return tuple, nil
+		}
+
+		element, err := parser.parseExpression(lowest)
+		if err != nil {
+			return tuple, err
+		}
+		tuple.Elements = append(tuple.Elements, element)
+	}
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.RightParenthesis {
+		return tuple, parser.errorf("expected right parenthesis, got %s", parser.currentToken.Type)
+	}
+	tuple.End = parser.currentToken.Span.End
+
+	return tuple, nil
+}
+
 func (parser *Parser) parseBlockStatement() (ast.Statement, error) {
 	blockStatement := &ast.BlockStatement{
 		Token:      parser.currentToken,
@@ -387,9 +757,21 @@ func (parser *Parser) parseBlockStatement() (ast.Statement, error) {
 	}
 
 	for parser.advanceToken(); parser.currentToken.Type != lexer.RightBrace; parser.advanceToken() {
+		if parser.currentToken.Type == lexer.Eof {
+			return blockStatement, parser.errorWithHint(
+				"a block opened with { was never closed",
+				"expected right brace, got %s",
+				parser.currentToken.Type,
+			)
+		}
+
 		statement, err := parser.parseStatement()
 		if err != nil {
-			return blockStatement, err
+			parser.recover(err)
+			if parser.currentToken.Type == lexer.RightBrace {
+				break
+			}
+			continue
 		}
 		blockStatement.Statements = append(blockStatement.Statements, statement)
 
@@ -397,6 +779,7 @@ func (parser *Parser) parseBlockStatement() (ast.Statement, error) {
 			parser.advanceToken()
 		}
 	}
+	blockStatement.End = parser.currentToken.Span.End
 
 	return blockStatement, nil
 }
@@ -413,6 +796,7 @@ func (parser *Parser) parseCallExpression(function ast.Expression) (ast.Expressi
 	}
 
 	callExpression.Arguments = callArguments
+	callExpression.End = parser.currentToken.Span.End
 
 	return callExpression, nil
 }
@@ -439,7 +823,11 @@ func (parser *Parser) parseCallArguments() ([]ast.Expression, error) {
 		}
 
 		if parser.currentToken.Type != lexer.Comma {
-			return arguments, errors.Errorf("expected comma, got %s", parser.currentToken.Type)
+			return arguments, parser.errorWithHint(
+				"call arguments must be separated by commas",
+				"expected comma, got %s",
+				parser.currentToken.Type,
+			)
 		}
 	}
 
@@ -465,7 +853,7 @@ func (parser *Parser) parseHash() (ast.Expression, error) {
 
 		parser.advanceToken()
 		if parser.currentToken.Type != lexer.Colon {
-			return nil, errors.Errorf("expected colon, got: %s", parser.currentToken.Literal)
+			return nil, parser.errorf("expected colon, got: %s", parser.currentToken.Literal)
 		}
 
 		parser.advanceToken()
@@ -482,10 +870,12 @@ func (parser *Parser) parseHash() (ast.Expression, error) {
 		}
 
 		if parser.currentToken.Type != lexer.Comma {
-			return nil, errors.Errorf("expected comma, got %s", parser.currentToken.Type)
+			return nil, parser.errorf("expected comma, got %s", parser.currentToken.Type)
 		}
 	}
 
+	hash.End = parser.currentToken.Span.End
+
 	return hash, nil
 }
 
@@ -513,10 +903,12 @@ func (parser *Parser) parseArray() (ast.Expression, error) {
 		}
 
 		if parser.currentToken.Type != lexer.Comma {
-			return nil, errors.Errorf("expected comma, got %s", parser.currentToken.Type)
+			return nil, parser.errorf("expected comma, got %s", parser.currentToken.Type)
 		}
 	}
 
+	array.End = parser.currentToken.Span.End
+
 	return array, nil
 }
 
@@ -535,8 +927,38 @@ func (parser *Parser) parseIndexExpression(array ast.Expression) (ast.Expression
 
 	parser.advanceToken()
 	if parser.currentToken.Type != lexer.RightBracket {
-		return nil, errors.Errorf("expected closing bracket, got: %s", parser.currentToken.Type)
+		return nil, parser.errorf("expected closing bracket, got: %s", parser.currentToken.Type)
 	}
+	i.End = parser.currentToken.Span.End
 
 	return i, nil
 }
+
+// docComment returns the text of the comments placed directly above token,
+// without blank lines in between, stripped of comment markers.
+func docComment(comments []lexer.Token, token lexer.Token) string {
+	first := len(comments)
+	line := token.Span.Start.Line
+	for i := len(comments) - 1; i >= 0; i-- {
+		if comments[i].Span.End.Line < line-1 {
+			break
+		}
+
+		first = i
+		line = comments[i].Span.Start.Line
+	}
+
+	lines := make([]string, 0, len(comments)-first)
+	for _, comment := range comments[first:] {
+		text := comment.Literal
+		if strings.HasPrefix(text, "//") {
+			text = strings.TrimPrefix(text, "//")
+		} else {
+			text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		}
+
+		lines = append(lines, strings.TrimSpace(text))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"math/big"
 	"spike-interpreter-go/spike/lexer"
 	"spike-interpreter-go/spike/parser/ast"
 	"strings"
@@ -18,32 +19,50 @@ func Test_Parser_parseValidCode(t *testing.T) {
 			code: `let variable = 10;`,
 			expectedProgram: &ast.Program{Statements: []ast.Statement{
 				&ast.LetStatement{
-					Token: lexer.Token{Type: lexer.Let, Literal: "let"},
+					Token: lexer.Token{Type: lexer.Let, Literal: "let", Span: lineSpan(1, 4)},
 					Name: &ast.Identifier{
-						Token: lexer.Token{Type: lexer.Identifier, Literal: "variable"},
+						Token: lexer.Token{Type: lexer.Identifier, Literal: "variable", Span: lineSpan(5, 13)},
 						Value: "variable",
 					},
 					Value: &ast.Integer{
-						Token: lexer.Token{Type: lexer.Integer, Literal: "10"},
+						Token: lexer.Token{Type: lexer.Integer, Literal: "10", Span: lineSpan(16, 18)},
 						Value: 10,
 					},
 				},
 			}},
 		},
+		{
+			code: `let big = 9223372036854775808;`,
+			expectedProgram: &ast.Program{Statements: []ast.Statement{
+				&ast.LetStatement{
+					Token: lexer.Token{Type: lexer.Let, Literal: "let", Span: lineSpan(1, 4)},
+					Name: &ast.Identifier{
+						Token: lexer.Token{Type: lexer.Identifier, Literal: "big", Span: lineSpan(5, 8)},
+						Value: "big",
+					},
+					Value: &ast.BigInteger{
+						Token: lexer.Token{Type: lexer.Integer, Literal: "9223372036854775808", Span: lineSpan(11, 30)},
+						Value: new(big.Int).Lsh(big.NewInt(1), 63),
+					},
+				},
+			}},
+		},
 		{
 			code: `return 2 + 2;`,
 			expectedProgram: &ast.Program{Statements: []ast.Statement{
 				&ast.ReturnStatement{
-					Token: lexer.Token{Type: lexer.Return, Literal: "return"},
+					Token: lexer.Token{Type: lexer.Return, Literal: "return", Span: lineSpan(1, 7)},
 					Result: &ast.InfixExpression{
 						Token: lexer.Token{
 							Type:    lexer.Plus,
 							Literal: "+",
+							Span:    lineSpan(10, 11),
 						},
 						Left: &ast.Integer{
 							Token: lexer.Token{
 								Type:    lexer.Integer,
 								Literal: "2",
+								Span:    lineSpan(8, 9),
 							},
 							Value: 2,
 						},
@@ -52,6 +71,7 @@ func Test_Parser_parseValidCode(t *testing.T) {
 							Token: lexer.Token{
 								Type:    lexer.Integer,
 								Literal: "2",
+								Span:    lineSpan(12, 13),
 							},
 							Value: 2,
 						},
@@ -71,6 +91,52 @@ func Test_Parser_parseValidCode(t *testing.T) {
 	}
 }
 
+func Test_Parser_nodeSpans(t *testing.T) {
+	testCases := []struct {
+		code         string
+		expectedSpan lexer.Span
+	}{
+		{
+			code:         "let a = 1 + foo(2, 3);",
+			expectedSpan: lineSpan(1, 22),
+		},
+		{
+			code:         "  [1, 2][0] * 3",
+			expectedSpan: lineSpan(3, 16),
+		},
+		{
+			code:         "fn (x) { x }",
+			expectedSpan: lineSpan(1, 13),
+		},
+		{
+			code:         "if (a) { 1 } else { 2 }",
+			expectedSpan: lineSpan(1, 24),
+		},
+		{
+			code:         `{"a": -1}`,
+			expectedSpan: lineSpan(1, 10),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.code, func(t *testing.T) {
+			program, err := New(lexer.New(strings.NewReader(testCase.code))).ParseProgram()
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedSpan, program.Statements[0].Span())
+		})
+	}
+}
+
+func Test_Parser_errorWithFileName(t *testing.T) {
+	code := "let a = 1;\nif (a { 2 }"
+	parser := New(lexer.NewWithFileName(strings.NewReader(code), "file.spk"))
+
+	_, err := parser.ParseProgram()
+
+	assert.EqualError(t, err, "file.spk:2:7: expected right parenthesis, got leftBrace")
+}
+
 func Test_Parser_ParseProgram(t *testing.T) {
 	testCases := []struct {
 		code        string
@@ -148,6 +214,76 @@ func Test_Parser_ParseProgram(t *testing.T) {
 			code:        "{}",
 			expectedAst: "{}\n",
 		},
+		{
+			code:        `"Hello ${name}!"`,
+			expectedAst: "((\"Hello \" + $str(name);) + \"!\")\n",
+		},
+		{
+			code:        "1.5 * 2e3",
+			expectedAst: "(1.5 * 2000)\n",
+		},
+		{
+			code:        `let Person = (123, "Lukasz")`,
+			expectedAst: `let Person = (123, "Lukasz")` + "\n",
+		},
+		{
+			code:        `let Place = (:place, 222, "Krakow")`,
+			expectedAst: `let Place = (:place, 222, "Krakow")` + "\n",
+		},
+		{
+			code:        "{:ok: :error}",
+			expectedAst: "{:ok: :error}\n",
+		},
+		{
+			code: `case Person of (:person, Id, _) when Id > 1 -> { Id } [1, -2.5, ...Rest] -> { Rest } {"a": (x,), :b: true} -> { x } end`,
+			expectedAst: "case Person of (:person, Id, _) when (Id > 1) -> {\n  Id;\n} [1, (-2.5), ...Rest] -> {\n  Rest;\n}" +
+				" {\"a\": (x,), :b: true} -> {\n  x;\n} end\n",
+		},
+		{
+			code:        "case x of (y) -> { y } end",
+			expectedAst: "case x of y -> {\n  y;\n} end\n",
+		},
+		{
+			code:        "()",
+			expectedAst: "()\n",
+		},
+		{
+			code:        "(1 + 2,)",
+			expectedAst: "((1 + 2),)\n",
+		},
+		{
+			code:        "(1 + 2)",
+			expectedAst: "(1 + 2)\n",
+		},
+		{
+			code:        "(a, (b, c), [d],)[1]",
+			expectedAst: "((a, (b, c), [d])[1])\n",
+		},
+		{
+			code:        "receive (:ping, from) when ok -> { send(from, :pong) } after 100 -> { :timeout } end",
+			expectedAst: "receive (:ping, from) when ok -> {\n  send(from, :pong);;\n} after 100 -> {\n  :timeout;\n} end\n",
+		},
+		{
+			code:        "fn() { let x = yield 1 + 2; yield }",
+			expectedAst: "fn () {\n  let x = yield (1 + 2);\n  yield;\n}\n",
+		},
+		{
+			code:        "user.address.city",
+			expectedAst: "((user[\"address\"])[\"city\"])\n",
+		},
+		{
+			code:        "spawn User(1).rename(name)",
+			expectedAst: "(spawn(User, 1);[\"rename\"])(name);\n",
+		},
+		{
+			code:        "let spawn = 1; spawn(f)",
+			expectedAst: "let spawn = 1\nspawn(f);\n",
+		},
+		{
+			code: "process Counter(start) { let count = start; cast add(n) { {\"count\": this.count + n} } call get() { this.count } }",
+			expectedAst: "process Counter = process(\"Counter\", process (start) {\n  let count = start;\n  {\"count\": count};\n}, " +
+				"{\"add\": (:cast, cast (this, n) {\n  {\"count\": ((this[\"count\"]) + n)};\n}), \"get\": (:call, call (this) {\n  (this[\"count\"]);\n})});\n",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -167,11 +303,79 @@ func Test_Parser_parsingError(t *testing.T) {
 	}{
 		"missing assignment in let statement": {
 			code:          "let variable 10;",
-			expectedError: "expected assign operator, got integer",
+			expectedError: "1:14: expected assign operator, got integer",
 		},
 		"missing identifier in let statement": {
 			code:          "let = 10;",
-			expectedError: "expected identifier, got assign",
+			expectedError: "1:5: expected identifier, got assign",
+		},
+		"atom name separated from colon": {
+			code:          ": ok",
+			expectedError: "1:1: expected atom name, got identifier",
+		},
+		"case without clauses": {
+			code:          "case x of end",
+			expectedError: "1:11: case expression must have at least one clause",
+		},
+		"unclosed case": {
+			code:          "case x of _ -> { 1 }",
+			expectedError: "1:21: expected end, got eof",
+		},
+		"rest pattern not last": {
+			code:          "case x of [...t, y] -> { 1 } end",
+			expectedError: "1:16: expected rightBracket, got comma",
+		},
+		"expression as pattern": {
+			code:          "case x of y + 1 -> { 1 } end",
+			expectedError: "1:13: expected arrow, got plus",
+		},
+		"name bound twice in a pattern": {
+			code:          "case x of (y, [z, ...y]) -> { 1 } end",
+			expectedError: "1:22: y is bound more than once in this pattern",
+		},
+		"name bound twice in a receive pattern": {
+			code:          "receive (a, a) -> { a } end",
+			expectedError: "1:13: a is bound more than once in this pattern",
+		},
+		"receive without clauses": {
+			code:          "receive end",
+			expectedError: "1:9: receive expression must have at least one clause",
+		},
+		"receive clause after timeout": {
+			code:          "receive after 1 -> { 1 } x -> { x } end",
+			expectedError: "1:26: expected end, got identifier",
+		},
+		"process body with an expression": {
+			code:          "process User { 1 }",
+			expectedError: "1:16: expected let, cast or call, got integer",
+		},
+		"duplicate process handler": {
+			code:          "process User { cast a() { 1 } call a() { 2 } }",
+			expectedError: "1:36: duplicate handler a",
+		},
+		"spawn without arguments": {
+			code:          "spawn User",
+			expectedError: "1:7: expected left parenthesis, got eof",
+		},
+		"member access without a name": {
+			code:          "user.1",
+			expectedError: "1:6: expected identifier, got integer",
+		},
+		"yield outside of a function": {
+			code:          "yield 1",
+			expectedError: "1:1: yield outside of a function",
+		},
+		"yield in a process handler": {
+			code:          "fn() { process User { call get() { yield 1 } } }",
+			expectedError: "1:36: yield is not allowed in process bodies",
+		},
+		"assignment": {
+			code:          `let name = "kenny"; name = "def"`,
+			expectedError: "1:21: variables are immutable, name can not be reassigned",
+		},
+		"unclosed tuple": {
+			code:          "(1, 2 3)",
+			expectedError: "1:7: expected right parenthesis, got integer",
 		},
 	}
 
@@ -185,3 +389,144 @@ func Test_Parser_parsingError(t *testing.T) {
 		})
 	}
 }
+
+func Test_Parser_generatorFunctions(t *testing.T) {
+	code := `fn() { fn() { yield 1 } }`
+
+	program, err := New(lexer.New(strings.NewReader(code))).ParseProgram()
+	assert.NoError(t, err)
+
+	outer := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionExpression)
+	inner := outer.Body.(*ast.BlockStatement).Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionExpression)
+	assert.False(t, outer.Generator)
+	assert.True(t, inner.Generator)
+}
+
+func lineSpan(startColumn, endColumn int) lexer.Span {
+	return lexer.Span{
+		Start: lexer.Position{Line: 1, Column: startColumn, Offset: startColumn - 1},
+		End:   lexer.Position{Line: 1, Column: endColumn, Offset: endColumn - 1},
+	}
+}
+
+func Test_Parser_recoversFromErrors(t *testing.T) {
+	code := `let a = 1;
+let b 2;
+let c = fn(x) {
+  let y = ;
+  x + 1
+};
+let d = (1 + 2;
+return a;`
+
+	program, err := New(lexer.New(strings.NewReader(code))).ParseProgram()
+
+	diagnostics, ok := err.(Diagnostics)
+	assert.True(t, ok)
+	assert.Len(t, diagnostics, 3)
+
+	assert.Equal(t, "2:7: expected assign operator, got integer", diagnostics[0].Error())
+	assert.Equal(t, SeverityError, diagnostics[0].Severity)
+	assert.Equal(t, "let statements have the form: let <name> = <expression>", diagnostics[0].Hint)
+	assert.Equal(t, 2, diagnostics[0].Span.Start.Line)
+
+	assert.Equal(t, "4:11: \";\" is not a valid prefix expression", diagnostics[1].Error())
+	assert.Equal(t, "7:15: expected right parenthesis, got semicolon", diagnostics[2].Error())
+
+	assert.Equal(t, "let a = 1\nlet c = fn (x) {\n  (x + 1);\n}\nreturn a\n", program.String())
+}
+
+func Test_Parser_recoversAtClosingBrace(t *testing.T) {
+	program, err := New(lexer.New(strings.NewReader("let f = fn() { let x = }; let y = 2;"))).ParseProgram()
+
+	diagnostics, ok := err.(Diagnostics)
+	assert.True(t, ok)
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, "1:24: \"}\" is not a valid prefix expression", diagnostics[0].Error())
+
+	assert.Equal(t, "let f = fn () {\n}\nlet y = 2\n", program.String())
+}
+
+func Test_Parser_assignmentDiagnostic(t *testing.T) {
+	code := `let name = "kenny";
+name = "def";
+name`
+
+	program, err := New(lexer.New(strings.NewReader(code))).ParseProgram()
+
+	diagnostics, ok := err.(Diagnostics)
+	assert.True(t, ok)
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, "2:1: variables are immutable, name can not be reassigned", diagnostics[0].Error())
+	assert.Equal(t, "bind a new name with let, or shadow name with let in a nested function", diagnostics[0].Hint)
+
+	assert.Equal(t, "let name = \"kenny\"\nname\n", program.String())
+}
+
+func Test_Parser_unclosedBlock(t *testing.T) {
+	_, err := New(lexer.New(strings.NewReader("fn (x) { x + 1"))).ParseProgram()
+
+	assert.EqualError(t, err, "1:15: expected right brace, got eof")
+}
+
+func Test_Parser_unterminatedBlockComment(t *testing.T) {
+	_, err := New(lexer.New(strings.NewReader("let a = 1;\n1 /* never\nclosed"))).ParseProgram()
+
+	diagnostics, ok := err.(Diagnostics)
+	assert.True(t, ok)
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, "2:3: unterminated block comment", diagnostics[0].Error())
+	assert.Equal(t, lexer.Span{
+		Start: lexer.Position{Line: 2, Column: 3, Offset: 13},
+		End:   lexer.Position{Line: 2, Column: 5, Offset: 15},
+	}, diagnostics[0].Span)
+}
+
+func Test_Parser_docComments(t *testing.T) {
+	code := `// unrelated
+
+// Adds two numbers.
+// Returns their sum.
+let add = fn(a, b) { a + b }; // trailing
+/* Answer. */ let answer = 42;
+let undocumented = 1;`
+
+	l := lexer.New(strings.NewReader(code))
+	l.EmitComments()
+
+	program, err := New(l).ParseProgram()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Adds two numbers.\nReturns their sum.", program.Statements[0].(*ast.LetStatement).Doc)
+	assert.Equal(t, "Answer.", program.Statements[1].(*ast.LetStatement).Doc)
+	assert.Equal(t, "", program.Statements[2].(*ast.LetStatement).Doc)
+	assert.Len(t, program.Comments, 5)
+}
+
+func Test_Parser_templateErrors(t *testing.T) {
+	testCases := map[string]struct {
+		code          string
+		expectedError string
+	}{
+		"invalid interpolated expression": {
+			code:          `let a = "x ${1 +} y";`,
+			expectedError: "1:17: unexpected end of input",
+		},
+		"trailing tokens in interpolation": {
+			code:          `"${a b}"`,
+			expectedError: "1:6: unexpected identifier in interpolation",
+		},
+		"invalid escape sequence": {
+			code:          `let a = "\q";`,
+			expectedError: `1:9: invalid escape sequence \q`,
+		},
+	}
+
+	for testCaseName, testCase := range testCases {
+		t.Run(testCaseName, func(t *testing.T) {
+			_, err := New(lexer.New(strings.NewReader(testCase.code))).ParseProgram()
+
+			assert.EqualError(t, err, testCase.expectedError)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/parser/ast"
+)
+
+// processKeyword, spawnKeyword, castKeyword and callKeyword are contextual:
+// they are lexed as identifiers and only act as keywords when followed by
+// another identifier, so they remain usable as ordinary names.
+const (
+	processKeyword = "process"
+	spawnKeyword   = "spawn"
+	castKeyword    = "cast"
+	callKeyword    = "call"
+)
+
+// processBuiltin is the builtin building a process definition out of a
+// process statement.
+const processBuiltin = "process"
+
+// thisParameter is the name of the state parameter prepended to handlers.
+const thisParameter = "this"
+
+// parseProcessStatement parses
+//
+//	process Name(parameters) { let field = value; cast name(parameters) { ... } }
+//
+// and desugars it to let Name = process("Name", init, handlers). The init
+// function runs the let statements and returns them as the state hash; each
+// handler becomes a (kind, function) tuple whose function receives the state
+// as its first parameter, this.
+func (parser *Parser) parseProcessStatement() (ast.Statement, error) {
+	token := parser.currentToken
+	statement := &ast.LetStatement{
+		Token: token,
+		Doc:   docComment(parser.currentComments, token),
+	}
+
+	parser.advanceToken()
+	statement.Name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	parser.functions = append(parser.functions, nil)
+	defer func() { parser.functions = parser.functions[:len(parser.functions)-1] }()
+
+	var parameters []*ast.Identifier
+	if parser.peekToken.Type == lexer.LeftParenthesis {
+		parser.advanceToken()
+
+		var err error
+		parameters, err = parser.parseFunctionParameters()
+		if err != nil {
+			return statement, err
+		}
+	}
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.LeftBrace {
+		return statement, parser.errorf("expected left brace, got: %s", parser.currentToken.Type)
+	}
+
+	body := &ast.BlockStatement{Token: token}
+	state := &ast.Hash{Token: token, Pairs: make(map[ast.Expression]ast.Expression)}
+	handlers := &ast.Hash{Token: token, Pairs: make(map[ast.Expression]ast.Expression)}
+	handlerNames := make(map[string]bool)
+
+	for parser.advanceToken(); parser.currentToken.Type != lexer.RightBrace; parser.advanceToken() {
+		switch {
+		case parser.currentToken.Type == lexer.Eof:
+			return statement, parser.errorWithHint(
+				"a process body opened with { was never closed",
+				"expected right brace, got %s",
+				parser.currentToken.Type,
+			)
+		case parser.currentToken.Type == lexer.Let:
+			field, err := parser.parseLetStatement()
+			if err != nil {
+				parser.skipBlock()
+				return statement, err
+			}
+			name := field.(*ast.LetStatement).Name
+			body.Statements = append(body.Statements, field)
+			state.Pairs[&ast.String{Token: name.Token, Value: name.Value}] = name
+		case parser.currentToken.Type == lexer.Identifier &&
+			(parser.currentToken.Literal == castKeyword || parser.currentToken.Literal == callKeyword):
+			name, handler, err := parser.parseProcessHandler(statement.Name.Value, handlerNames)
+			if err != nil {
+				parser.skipBlock()
+				return statement, err
+			}
+			handlers.Pairs[name] = handler
+		default:
+			err := parser.errorWithHint(
+				"a process body contains let statements and cast or call handlers",
+				"expected let, cast or call, got %s",
+				parser.currentToken.Type,
+			)
+			parser.skipBlock()
+			return statement, err
+		}
+
+		if parser.peekToken.Type == lexer.Semicolon {
+			parser.advanceToken()
+		}
+	}
+
+	end := parser.currentToken.Span.End
+	body.End = end
+	state.End = end
+	handlers.End = end
+	body.Statements = append(body.Statements, &ast.ExpressionStatement{Expression: state})
+
+	builtin := lexer.Token{Type: lexer.Identifier, Literal: processBuiltin, Span: token.Span}
+	statement.Value = &ast.CallExpression{
+		Token:    token,
+		Function: &ast.Identifier{Token: builtin, Value: processBuiltin},
+		Arguments: []ast.Expression{
+			&ast.String{Token: statement.Name.Token, Value: statement.Name.Value},
+			&ast.FunctionExpression{Token: token, Name: statement.Name.Value, Parameters: parameters, Body: body},
+			handlers,
+		},
+		End: end,
+	}
+
+	return statement, nil
+}
+
+// parseProcessHandler parses cast name(parameters) { ... } or the call
+// equivalent, returning the handler name and its (kind, function) tuple.
+// Names are recorded in defined to reject duplicates.
+func (parser *Parser) parseProcessHandler(processName string, defined map[string]bool) (*ast.String, ast.Expression, error) {
+	kind := parser.currentToken
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.Identifier {
+		return nil, nil, parser.errorf("expected identifier, got %s", parser.currentToken.Type)
+	}
+	name := &ast.String{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	if defined[name.Value] {
+		return nil, nil, parser.errorf("duplicate handler %s", name.Value)
+	}
+	defined[name.Value] = true
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.LeftParenthesis {
+		return nil, nil, parser.errorf("expected left parenthesis, got %s", parser.currentToken.Type)
+	}
+
+	parameters, err := parser.parseFunctionParameters()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.LeftBrace {
+		return nil, nil, parser.errorf("expected left brace, got: %s", parser.currentToken.Type)
+	}
+
+	body, err := parser.parseBlockStatement()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	this := &ast.Identifier{Token: lexer.Token{Type: lexer.Identifier, Literal: thisParameter, Span: kind.Span}, Value: thisParameter}
+	function := &ast.FunctionExpression{
+		Token:      kind,
+		Name:       processName + "." + name.Value,
+		Parameters: append([]*ast.Identifier{this}, parameters...),
+		Body:       body,
+	}
+
+	return name, &ast.Tuple{
+		Token:    kind,
+		Elements: []ast.Expression{&ast.Atom{Token: kind, Name: kind.Literal, End: kind.Span.End}, function},
+		End:      body.Span().End,
+	}, nil
+}
+
+// skipBlock advances to the right brace closing the block the current token
+// is in, so that recovery resumes after it.
+func (parser *Parser) skipBlock() {
+	depth := 0
+	for parser.currentToken.Type != lexer.Eof {
+		switch parser.currentToken.Type {
+		case lexer.LeftBrace:
+			depth++
+		case lexer.RightBrace:
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+
+		parser.advanceToken()
+	}
+}
+
+// parseSpawnExpression parses spawn Name(arguments) as a shorthand for
+// spawn(Name, arguments).
+func (parser *Parser) parseSpawnExpression() (ast.Expression, error) {
+	spawn := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	parser.advanceToken()
+	definition, err := parser.parseExpression(call)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.peekToken.Type != lexer.LeftParenthesis {
+		return nil, parser.errorWithHint(
+			"processes are spawned with: spawn <name>(<arguments>)",
+			"expected left parenthesis, got %s",
+			parser.peekToken.Type,
+		)
+	}
+	parser.advanceToken()
+
+	expression, err := parser.parseCallExpression(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	callExpression := expression.(*ast.CallExpression)
+	callExpression.Token = spawn.Token
+	callExpression.Function = spawn
+	callExpression.Arguments = append([]ast.Expression{definition}, callExpression.Arguments...)
+
+	return callExpression, nil
+}
+
+// parseMemberExpression parses object.name as a shorthand for object["name"].
+func (parser *Parser) parseMemberExpression(left ast.Expression) (ast.Expression, error) {
+	token := parser.currentToken
+
+	parser.advanceToken()
+	if parser.currentToken.Type != lexer.Identifier {
+		return nil, parser.errorf("expected identifier, got %s", parser.currentToken.Type)
+	}
+
+	return &ast.IndexExpression{
+		Token: token,
+		Array: left,
+		Index: &ast.String{Token: parser.currentToken, Value: parser.currentToken.Literal},
+		End:   parser.currentToken.Span.End,
+	}, nil
+}
@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/parser/ast"
+)
+
+// parseYieldExpression parses yield with an optional value and marks the
+// enclosing function as a generator.
+func (parser *Parser) parseYieldExpression() (ast.Expression, error) {
+	expression := &ast.YieldExpression{Token: parser.currentToken}
+
+	if len(parser.functions) == 0 {
+		return expression, parser.errorf("yield outside of a function")
+	}
+	function := parser.functions[len(parser.functions)-1]
+	if function == nil {
+		return expression, parser.errorf("yield is not allowed in process bodies")
+	}
+	function.Generator = true
+
+	switch parser.peekToken.Type {
+	case lexer.Semicolon, lexer.RightBrace, lexer.RightParenthesis, lexer.Comma, lexer.Eof:
+		return expression, nil
+	}
+
+	parser.advanceToken()
+	value, err := parser.parseExpression(lowest)
+	expression.Value = value
+
+	return expression, err
+}
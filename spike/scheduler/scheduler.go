@@ -0,0 +1,208 @@
+package scheduler
+
+import (
+	"math/rand"
+	"runtime"
+	"spike-interpreter-go/spike/vm"
+	"sync"
+)
+
+// DefaultBudget is the number of instructions a task runs per slice when
+// Options.Budget is not set.
+const DefaultBudget = 1000
+
+// Task is a program executed in slices. *vm.VM implements it.
+type Task interface {
+	RunFor(budget int) (vm.State, error)
+}
+
+type Options struct {
+	// Workers is the number of goroutines running tasks, runtime.NumCPU()
+	// when not set.
+	Workers int
+	// Budget is the maximum number of instructions of a slice.
+	Budget int
+	// Deterministic runs every task on the goroutine calling Run, with slice
+	// lengths drawn from Seed. The same seed and tasks give the same
+	// interleaving, as long as tasks don't depend on other goroutines.
+	Deterministic bool
+	Seed          int64
+}
+
+// Stats describe the work done by a scheduler and its run queue.
+type Stats struct {
+	Spawned   int
+	Finished  int
+	Failed    int
+	Slices    int
+	Queued    int
+	MaxQueued int
+}
+
+// Handle tracks a task added to a scheduler.
+type Handle struct {
+	task Task
+	done chan struct{}
+	err  error
+}
+
+func (handle *Handle) Task() Task {
+	return handle.task
+}
+
+// Done is closed when the task finishes or fails.
+func (handle *Handle) Done() <-chan struct{} {
+	return handle.done
+}
+
+// Err returns the error the task failed with. It is only valid after Done is
+// closed.
+func (handle *Handle) Err() error {
+	return handle.err
+}
+
+// Scheduler multiplexes tasks over a pool of workers. Tasks wait in a single
+// run queue and are given fixed slices in round-robin order: a task that
+// runs out of budget goes back to the end of the queue.
+type Scheduler struct {
+	options Options
+	random  *rand.Rand
+
+	mutex   sync.Mutex
+	ready   *sync.Cond
+	queue   []*Handle
+	pending int
+	stats   Stats
+}
+
+func New(options Options) *Scheduler {
+	if options.Workers <= 0 {
+		options.Workers = runtime.NumCPU()
+	}
+	if options.Budget <= 0 {
+		options.Budget = DefaultBudget
+	}
+
+	scheduler := &Scheduler{options: options}
+	scheduler.ready = sync.NewCond(&scheduler.mutex)
+	if options.Deterministic {
+		scheduler.random = rand.New(rand.NewSource(options.Seed))
+	}
+
+	return scheduler
+}
+
+// Spawn adds task to the end of the run queue. Tasks can be spawned while Run
+// is in progress.
+func (scheduler *Scheduler) Spawn(task Task) *Handle {
+	handle := &Handle{task: task, done: make(chan struct{})}
+
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	scheduler.stats.Spawned++
+	scheduler.pending++
+	scheduler.enqueue(handle)
+
+	return handle
+}
+
+// Run executes tasks until every spawned task has finished or failed.
+func (scheduler *Scheduler) Run() {
+	if scheduler.options.Deterministic {
+		scheduler.work()
+		return
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < scheduler.options.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			scheduler.work()
+		}()
+	}
+	workers.Wait()
+}
+
+// Stats returns a snapshot of the scheduler statistics.
+func (scheduler *Scheduler) Stats() Stats {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	stats := scheduler.stats
+	stats.Queued = len(scheduler.queue)
+
+	return stats
+}
+
+func (scheduler *Scheduler) work() {
+	for {
+		handle, budget, ok := scheduler.next()
+		if !ok {
+			return
+		}
+
+		state, err := handle.task.RunFor(budget)
+		scheduler.complete(handle, state, err)
+	}
+}
+
+// next takes the task at the front of the run queue, waiting for one while
+// other workers still run tasks that may be requeued.
+func (scheduler *Scheduler) next() (*Handle, int, bool) {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	for len(scheduler.queue) == 0 {
+		if scheduler.pending == 0 {
+			return nil, 0, false
+		}
+		scheduler.ready.Wait()
+	}
+
+	handle := scheduler.queue[0]
+	scheduler.queue[0] = nil
+	scheduler.queue = scheduler.queue[1:]
+	scheduler.stats.Slices++
+
+	budget := scheduler.options.Budget
+	if scheduler.random != nil {
+		budget = 1 + scheduler.random.Intn(budget)
+	}
+
+	return handle, budget, true
+}
+
+func (scheduler *Scheduler) complete(handle *Handle, state vm.State, err error) {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	if err == nil && state == vm.Suspended {
+		scheduler.enqueue(handle)
+		return
+	}
+
+	if err != nil {
+		scheduler.stats.Failed++
+	} else {
+		scheduler.stats.Finished++
+	}
+
+	handle.err = err
+	close(handle.done)
+
+	scheduler.pending--
+	if scheduler.pending == 0 {
+		scheduler.ready.Broadcast()
+	}
+}
+
+func (scheduler *Scheduler) enqueue(handle *Handle) {
+	scheduler.queue = append(scheduler.queue, handle)
+	if len(scheduler.queue) > scheduler.stats.MaxQueued {
+		scheduler.stats.MaxQueued = len(scheduler.queue)
+	}
+
+	scheduler.ready.Signal()
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"fmt"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"spike-interpreter-go/spike/vm"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Scheduler_Run(t *testing.T) {
+	scheduler := New(Options{Workers: 4, Budget: 50})
+
+	handles := make([]*Handle, 0)
+	for i := 0; i < 200; i++ {
+		code := fmt.Sprintf(`let sum = fn(n) { if (n == 0) { return 0 } n + sum(n - 1) }; sum(%d)`, i)
+		handles = append(handles, scheduler.Spawn(newVM(t, code)))
+	}
+
+	scheduler.Run()
+
+	for i, handle := range handles {
+		assert.NoError(t, handle.Err())
+		assert.Equal(t, &object.Integer{Value: int64(i * (i + 1) / 2)}, handle.Task().(*vm.VM).LastPoppedStackElement())
+	}
+
+	stats := scheduler.Stats()
+	assert.Equal(t, 200, stats.Spawned)
+	assert.Equal(t, 200, stats.Finished)
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, 200, stats.MaxQueued)
+	assert.True(t, stats.Slices > 200)
+}
+
+func Test_Scheduler_Run_withFailingTask(t *testing.T) {
+	scheduler := New(Options{Workers: 2})
+
+	failing := scheduler.Spawn(newVM(t, `1 / 0`))
+	succeeding := scheduler.Spawn(newVM(t, `1 + 1`))
+
+	scheduler.Run()
+
+	assert.EqualError(t, failing.Err(), "1:1: division by zero")
+	assert.NoError(t, succeeding.Err())
+
+	stats := scheduler.Stats()
+	assert.Equal(t, 1, stats.Failed)
+	assert.Equal(t, 1, stats.Finished)
+}
+
+func Test_Scheduler_Run_roundRobin(t *testing.T) {
+	trace := &trace{}
+	scheduler := New(Options{Workers: 1, Budget: 10})
+
+	scheduler.Spawn(&fakeTask{name: "a", instructions: 30, trace: trace})
+	scheduler.Spawn(&fakeTask{name: "b", instructions: 10, trace: trace})
+	scheduler.Spawn(&fakeTask{name: "c", instructions: 20, trace: trace})
+
+	scheduler.Run()
+
+	assert.Equal(t, []string{"a", "b", "c", "a", "c", "a"}, trace.slices)
+}
+
+func Test_Scheduler_Run_deterministic(t *testing.T) {
+	run := func(seed int64) []string {
+		trace := &trace{}
+		scheduler := New(Options{Budget: 10, Deterministic: true, Seed: seed})
+
+		for _, name := range []string{"a", "b", "c"} {
+			scheduler.Spawn(&fakeTask{name: name, instructions: 40, trace: trace})
+		}
+		scheduler.Run()
+
+		return trace.slices
+	}
+
+	assert.Equal(t, run(1), run(1))
+	assert.NotEqual(t, run(1), run(2))
+}
+
+func Test_Scheduler_Spawn_whileRunning(t *testing.T) {
+	scheduler := New(Options{Workers: 2, Budget: 1})
+	spawned := make(chan *Handle, 1)
+
+	scheduler.Spawn(&fakeTask{instructions: 100, trace: &trace{}, onFinish: func() {
+		spawned <- scheduler.Spawn(newVM(t, `1 + 1`))
+	}})
+
+	scheduler.Run()
+
+	handle := <-spawned
+	<-handle.Done()
+	assert.NoError(t, handle.Err())
+	assert.Equal(t, 2, scheduler.Stats().Finished)
+}
+
+type trace struct {
+	mutex  sync.Mutex
+	slices []string
+}
+
+type fakeTask struct {
+	name         string
+	instructions int
+	trace        *trace
+	onFinish     func()
+}
+
+func (task *fakeTask) RunFor(budget int) (vm.State, error) {
+	if budget <= 0 {
+		return vm.Suspended, errors.New("budget must be positive")
+	}
+
+	task.trace.mutex.Lock()
+	task.trace.slices = append(task.trace.slices, task.name)
+	task.trace.mutex.Unlock()
+
+	task.instructions -= budget
+	if task.instructions > 0 {
+		return vm.Suspended, nil
+	}
+
+	if task.onFinish != nil {
+		task.onFinish()
+	}
+	return vm.Finished, nil
+}
+
+func newVM(t *testing.T, input string) *vm.VM {
+	program, err := parser.New(lexer.New(strings.NewReader(input))).ParseProgram()
+	assert.NoError(t, err)
+
+	c := compiler.New()
+	assert.NoError(t, c.Compile(program))
+
+	return vm.New(c.Bytecode())
+}
@@ -0,0 +1,146 @@
+// Package spike embeds Spike in Go programs. An Interpreter compiles and runs
+// programs on the bytecode VM, keeping the globals they define from one run to
+// the next, and lets Go read and set globals and call Spike functions.
+package spike
+
+import (
+	"context"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"spike-interpreter-go/spike/vm"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Interpreter holds the globals, symbols and constants programs share. It is
+// not safe for concurrent use.
+type Interpreter struct {
+	symbolTable *compiler.SymbolTable
+	constants   []object.Object
+	globals     []object.Object
+
+	// context and limits are given to every VM when limited is set.
+	context context.Context
+	limits  object.Limits
+	limited bool
+}
+
+// Program is source compiled by an Interpreter, which runs it.
+type Program struct {
+	bytecode *compiler.Bytecode
+}
+
+func New() *Interpreter {
+	return &Interpreter{
+		symbolTable: compiler.NewGlobalSymbolTable(),
+		constants:   []object.Object{},
+		globals:     make([]object.Object, vm.GlobalsSize),
+	}
+}
+
+// Limit applies ctx and limits to every later Run, Eval and Call, each of
+// them counting instructions and memory from zero. See vm.VM.Limit.
+func (interpreter *Interpreter) Limit(ctx context.Context, limits object.Limits) {
+	interpreter.context = ctx
+	interpreter.limits = limits
+	interpreter.limited = true
+}
+
+// Compile parses and compiles source, which may use the globals defined by
+// the programs compiled before it. The globals source defines can be used by
+// programs compiled after it, but only get their values once it runs. A
+// program that fails to compile defines nothing.
+func (interpreter *Interpreter) Compile(source string) (*Program, error) {
+	program, err := parser.New(lexer.New(strings.NewReader(source))).ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+
+	symbolTable := interpreter.symbolTable.Copy()
+	comp := compiler.NewWithState(symbolTable, interpreter.constants)
+	if err := comp.Compile(program); err != nil {
+		return nil, err
+	}
+
+	bytecode := comp.Bytecode()
+	interpreter.symbolTable = symbolTable
+	interpreter.constants = bytecode.Constants
+
+	return &Program{bytecode: bytecode}, nil
+}
+
+// Run runs program and returns the value of its last expression statement.
+func (interpreter *Interpreter) Run(program *Program) (object.Object, error) {
+	machine := interpreter.newVM(program.bytecode)
+	if err := machine.Run(); err != nil {
+		return nil, err
+	}
+
+	return machine.LastPoppedStackElement(), nil
+}
+
+// Eval compiles and runs source.
+func (interpreter *Interpreter) Eval(source string) (object.Object, error) {
+	program, err := interpreter.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return interpreter.Run(program)
+}
+
+// Get returns the value of the global name, which is only set once the
+// program defining it ran.
+func (interpreter *Interpreter) Get(name string) (object.Object, bool) {
+	symbol, ok := interpreter.symbolTable.Resolve(name)
+	if !ok || symbol.SymbolScope != compiler.GlobalScope {
+		return nil, false
+	}
+
+	value := interpreter.globals[symbol.Index]
+	return value, value != nil
+}
+
+// Set converts value with ToObject and makes it the value of the global name,
+// defining the global for programs compiled later when it does not exist.
+func (interpreter *Interpreter) Set(name string, value interface{}) error {
+	converted, err := ToObject(value)
+	if err != nil {
+		return errors.Wrapf(err, "setting %s", name)
+	}
+
+	symbol, ok := interpreter.symbolTable.Resolve(name)
+	if !ok || symbol.SymbolScope != compiler.GlobalScope {
+		symbol = interpreter.symbolTable.Define(name)
+	}
+	interpreter.globals[symbol.Index] = converted
+
+	return nil
+}
+
+// Call calls the Spike function with args converted with ToObject and
+// returns its result.
+func (interpreter *Interpreter) Call(function object.Object, args ...interface{}) (object.Object, error) {
+	converted := make([]object.Object, len(args))
+	for i, arg := range args {
+		var err error
+		if converted[i], err = ToObject(arg); err != nil {
+			return nil, errors.Wrapf(err, "argument %d", i+1)
+		}
+	}
+
+	machine := interpreter.newVM(&compiler.Bytecode{Constants: interpreter.constants})
+	return machine.Call(function, converted...)
+}
+
+func (interpreter *Interpreter) newVM(bytecode *compiler.Bytecode) *vm.VM {
+	machine := vm.NewWithGlobalStore(bytecode, interpreter.globals)
+	if interpreter.limited {
+		machine.Limit(interpreter.context, interpreter.limits)
+	}
+
+	return machine
+}
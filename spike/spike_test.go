@@ -0,0 +1,148 @@
+package spike
+
+import (
+	"context"
+	"math/big"
+	"spike-interpreter-go/spike/object"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Interpreter_Eval(t *testing.T) {
+	interpreter := New()
+
+	_, err := interpreter.Eval(`let greeting = "Hello"; let greet = fn(name) { greeting + ", " + name }`)
+	assert.NoError(t, err)
+
+	result, err := interpreter.Eval(`greet("Spike")`)
+	assert.NoError(t, err)
+	assert.Equal(t, &object.String{Value: "Hello, Spike"}, result)
+}
+
+func Test_Interpreter_CompileAndRun(t *testing.T) {
+	interpreter := New()
+
+	definition, err := interpreter.Compile("let counter = 41")
+	assert.NoError(t, err)
+	usage, err := interpreter.Compile("counter + 1")
+	assert.NoError(t, err)
+
+	_, ok := interpreter.Get("counter")
+	assert.False(t, ok)
+
+	_, err = interpreter.Run(definition)
+	assert.NoError(t, err)
+
+	result, err := interpreter.Run(usage)
+	assert.NoError(t, err)
+	assert.Equal(t, &object.Integer{Value: 42}, result)
+}
+
+func Test_Interpreter_Compile_errors(t *testing.T) {
+	interpreter := New()
+
+	_, err := interpreter.Compile("let x = 1; let y = missing")
+	assert.EqualError(t, err, "1:20: undefined identifier: missing")
+
+	_, err = interpreter.Eval("let x = 2")
+	assert.NoError(t, err)
+
+	_, err = interpreter.Compile("let x = ")
+	assert.Error(t, err)
+
+	x, ok := interpreter.Get("x")
+	assert.True(t, ok)
+	assert.Equal(t, &object.Integer{Value: 2}, x)
+}
+
+func Test_Interpreter_GetAndSet(t *testing.T) {
+	interpreter := New()
+
+	assert.NoError(t, interpreter.Set("limit", 10))
+	assert.NoError(t, interpreter.Set("names", []string{"a", "b"}))
+
+	_, err := interpreter.Eval("let doubled = limit * 2")
+	assert.NoError(t, err)
+
+	doubled, ok := interpreter.Get("doubled")
+	assert.True(t, ok)
+	assert.Equal(t, &object.Integer{Value: 20}, doubled)
+
+	assert.NoError(t, interpreter.Set("limit", 1))
+	result, err := interpreter.Eval("[limit, len(names)]")
+	assert.NoError(t, err)
+	assert.Equal(t, "[1, 2]", result.Inspect())
+
+	_, ok = interpreter.Get("missing")
+	assert.False(t, ok)
+	_, ok = interpreter.Get("len")
+	assert.False(t, ok)
+
+	assert.EqualError(t, interpreter.Set("channel", make(chan int)), "setting channel: unable to convert chan int to a Spike value")
+}
+
+func Test_Interpreter_Call(t *testing.T) {
+	interpreter := New()
+
+	_, err := interpreter.Eval(`let describe = fn(user) { user["name"] + " is " + str(user["age"]) }`)
+	assert.NoError(t, err)
+	describe, _ := interpreter.Get("describe")
+
+	result, err := interpreter.Call(describe, map[string]interface{}{"name": "Ada", "age": 36})
+	assert.NoError(t, err)
+	assert.Equal(t, &object.String{Value: "Ada is 36"}, result)
+
+	_, err = interpreter.Call(describe, 1, 2)
+	assert.EqualError(t, err, "mismatched number of function call arguments. Expected 1, got 2")
+
+	_, err = interpreter.Call(describe, struct{}{})
+	assert.EqualError(t, err, "argument 1: unable to convert struct {} to a Spike value")
+}
+
+func Test_Interpreter_Limit(t *testing.T) {
+	interpreter := New()
+	interpreter.Limit(context.Background(), object.Limits{MaxCallDepth: 10})
+
+	_, err := interpreter.Eval("let loop = fn(n) { loop(n + 1) }")
+	assert.NoError(t, err)
+
+	_, err = interpreter.Eval("loop(0)")
+	assert.Equal(t, &object.CallDepthError{Limit: 10}, errors.Cause(err))
+
+	loop, _ := interpreter.Get("loop")
+	_, err = interpreter.Call(loop, 0)
+	assert.Equal(t, &object.CallDepthError{Limit: 10}, errors.Cause(err))
+}
+
+func Test_ToObject(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	testCases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{value: nil, expected: "null"},
+		{value: true, expected: "true"},
+		{value: int64(-7), expected: "-7"},
+		{value: uint64(18446744073709551615), expected: "18446744073709551615"},
+		{value: huge, expected: "123456789012345678901234567890"},
+		{value: 1.5, expected: "1.5"},
+		{value: "spike", expected: `"spike"`},
+		{value: []interface{}{1, "a", []int{2}}, expected: `[1, "a", [2]]`},
+		{value: map[string]interface{}{"b": false, "a": nil}, expected: `{"a": null, "b": false}`},
+		{value: object.NewAtom("ok"), expected: ":ok"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expected, func(t *testing.T) {
+			converted, err := ToObject(testCase.value)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, converted.Inspect())
+		})
+	}
+
+	_, err := ToObject(map[string]interface{}{"a": 1, "b": []interface{}{func() {}}})
+	assert.EqualError(t, err, `key "b": element 0: unable to convert func() to a Spike value`)
+}
@@ -0,0 +1,86 @@
+package spike
+
+import (
+	"math/big"
+	"sort"
+	"spike-interpreter-go/spike/object"
+
+	"github.com/pkg/errors"
+)
+
+// ToObject converts a Go value to a Spike value. Objects are kept as they
+// are, nil becomes null, booleans, integers, floats, strings and *big.Int
+// their Spike counterparts, slices of them arrays and maps of them with
+// string keys hashes.
+func ToObject(value interface{}) (object.Object, error) {
+	switch value := value.(type) {
+	case object.Object:
+		return value, nil
+	case nil:
+		return &object.NullObject, nil
+	case bool:
+		if value {
+			return &object.True, nil
+		}
+		return &object.False, nil
+	case int:
+		return &object.Integer{Value: int64(value)}, nil
+	case int32:
+		return &object.Integer{Value: int64(value)}, nil
+	case int64:
+		return &object.Integer{Value: value}, nil
+	case uint64:
+		return object.NewInteger(new(big.Int).SetUint64(value)), nil
+	case *big.Int:
+		return object.NewInteger(new(big.Int).Set(value)), nil
+	case float32:
+		return &object.Float{Value: float64(value)}, nil
+	case float64:
+		return &object.Float{Value: value}, nil
+	case string:
+		return &object.String{Value: value}, nil
+	case []interface{}:
+		return toArray(len(value), func(i int) interface{} { return value[i] })
+	case []int:
+		return toArray(len(value), func(i int) interface{} { return value[i] })
+	case []string:
+		return toArray(len(value), func(i int) interface{} { return value[i] })
+	case map[string]interface{}:
+		return toHash(value)
+	}
+
+	return nil, errors.Errorf("unable to convert %T to a Spike value", value)
+}
+
+func toArray(length int, element func(i int) interface{}) (object.Object, error) {
+	elements := make([]object.Object, length)
+	for i := range elements {
+		var err error
+		if elements[i], err = ToObject(element(i)); err != nil {
+			return nil, errors.Wrapf(err, "element %d", i)
+		}
+	}
+
+	return &object.Array{Elements: elements}, nil
+}
+
+func toHash(values map[string]interface{}) (object.Object, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(values))}
+	for _, key := range keys {
+		value, err := ToObject(values[key])
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %q", key)
+		}
+
+		keyObject := &object.String{Value: key}
+		hash.Pairs[keyObject.GetHashKey()] = object.HashPair{Key: keyObject, Value: value}
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,51 @@
+package vm
+
+import (
+	"fmt"
+	"spike-interpreter-go/spike/lexer"
+	"strings"
+)
+
+type StackFrame struct {
+	Function string
+	Span     lexer.Span
+}
+
+func (frame StackFrame) String() string {
+	if frame.Span == (lexer.Span{}) {
+		return fmt.Sprintf("%s (unknown location)", frame.Function)
+	}
+
+	return fmt.Sprintf("%s (%s)", frame.Function, frame.Span.Start)
+}
+
+// RuntimeError wraps an error raised while executing bytecode together with
+// the call stack at the moment of failure, innermost frame first.
+type RuntimeError struct {
+	Err    error
+	Frames []StackFrame
+}
+
+func (err *RuntimeError) Error() string {
+	if len(err.Frames) == 0 || err.Frames[0].Span == (lexer.Span{}) {
+		return err.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", err.Frames[0].Span.Start, err.Err.Error())
+}
+
+func (err *RuntimeError) Cause() error {
+	return err.Err
+}
+
+func (err *RuntimeError) StackTrace() string {
+	out := strings.Builder{}
+	out.WriteString(err.Error())
+
+	for _, frame := range err.Frames {
+		out.WriteString("\n\tat ")
+		out.WriteString(frame.String())
+	}
+
+	return out.String()
+}
@@ -0,0 +1,131 @@
+package vm
+
+import (
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/object"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// runners holds VMs of finished generators. Generators continuing with
+// another generator finish at each step, reusing runners saves allocating a
+// stack every time.
+var runners = sync.Pool{
+	New: func() interface{} {
+		return NewWithGlobalStore(&compiler.Bytecode{}, nil)
+	},
+}
+
+// generator runs the frame of a generator function on a VM of its own,
+// taken on the first call to next. The frame stays there between calls,
+// suspended at its last yield.
+type generator struct {
+	constants []object.Object
+	globals   []object.Object
+	process   *object.Process
+	meter     *object.Meter
+	maxFrames int
+
+	closure *object.Closure
+	args    []object.Object
+	runner  *VM
+}
+
+func (vm *VM) newGenerator(closure *object.Closure, args []object.Object) *object.Generator {
+	generator := &generator{
+		constants: vm.constants,
+		globals:   vm.globals,
+		process:   vm.process,
+		meter:     vm.meter,
+		maxFrames: vm.maxFrames,
+		closure:   closure,
+		args:      append([]object.Object{}, args...),
+	}
+
+	return object.NewGenerator(closure.Function.Name, generator.resume)
+}
+
+func (generator *generator) resume(sent object.Object) (object.Object, bool, error) {
+	if generator.runner == nil {
+		if err := generator.start(); err != nil {
+			return nil, true, err
+		}
+	}
+
+	value, finished, err := generator.runner.resume(sent)
+	if finished {
+		generator.release()
+	}
+
+	return value, finished, err
+}
+
+// start prepares a runner with the constants, globals, process and limits of
+// the VM that called the generator function.
+func (generator *generator) start() error {
+	runner := runners.Get().(*VM)
+	runner.constants = generator.constants
+	runner.globals = generator.globals
+	runner.process = generator.process
+	runner.meter = generator.meter
+	runner.maxFrames = generator.maxFrames
+	generator.runner = runner
+
+	runner.stack[0] = generator.closure
+	copy(runner.stack[1:], generator.args)
+	generator.args = nil
+
+	frame := NewFrame(generator.closure, 1)
+	if err := runner.pushFrame(frame); err != nil {
+		generator.release()
+		return err
+	}
+	runner.sp = frame.basePointer + generator.closure.Function.LocalsCount
+
+	return nil
+}
+
+func (generator *generator) release() {
+	runner := generator.runner
+	for i := range runner.stack {
+		runner.stack[i] = nil
+	}
+	runner.sp = 0
+	runner.framesIndex = 1
+	runner.yielded = false
+	runner.constants = nil
+	runner.globals = nil
+	runner.process = nil
+	runner.meter = nil
+	runner.maxFrames = MaxFrames + 1
+
+	generator.runner = nil
+	generator.closure = nil
+	runners.Put(runner)
+}
+
+// resume runs the generator frame until it yields or returns. Sent becomes
+// the value of the yield the frame is suspended at, if any.
+func (vm *VM) resume(sent object.Object) (object.Object, bool, error) {
+	if vm.currentFrame().ip >= 0 {
+		err := vm.push(sent)
+		if err != nil {
+			return nil, true, err
+		}
+	}
+
+	err := vm.run()
+	if err != nil {
+		name := functionName(vm.frames[1].closure.Function)
+		return nil, true, errors.Wrapf(vm.newRuntimeError(err), "generator %s failed", name)
+	}
+
+	value := vm.pop()
+	if vm.yielded {
+		vm.yielded = false
+		return value, false, nil
+	}
+
+	return value, true, nil
+}
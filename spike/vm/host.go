@@ -0,0 +1,54 @@
+package vm
+
+import (
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/object"
+)
+
+// Fork returns a VM running in process with the constants, globals and limits
+// of vm.
+// Globals are shared: top-level bindings are only ever written once, before
+// code that could be forked can read them.
+func (vm *VM) Fork(process *object.Process) object.Host {
+	child := NewWithGlobalStore(&compiler.Bytecode{Constants: vm.constants}, vm.globals)
+	child.process = process
+	child.meter = vm.meter
+	child.maxFrames = vm.maxFrames
+
+	return child
+}
+
+// Call runs function with args to completion and returns its result. It must
+// not be used while Run is in progress.
+func (vm *VM) Call(function object.Object, args ...object.Object) (object.Object, error) {
+	sp := vm.sp
+	framesIndex := vm.framesIndex
+
+	err := vm.push(function)
+	for i := 0; err == nil && i < len(args); i++ {
+		err = vm.push(args[i])
+	}
+
+	if err == nil {
+		err = vm.callFunction(len(args))
+	}
+	if err == nil {
+		err = vm.run()
+	}
+	if err != nil {
+		runtimeError := vm.newRuntimeError(err)
+		vm.sp = sp
+		vm.framesIndex = framesIndex
+		return nil, runtimeError
+	}
+
+	result := vm.pop()
+	vm.sp = sp
+
+	return result, nil
+}
+
+// Process returns the process the VM runs in.
+func (vm *VM) Process() *object.Process {
+	return vm.process
+}
@@ -0,0 +1,122 @@
+package vm
+
+import (
+	"context"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/lexer"
+	"spike-interpreter-go/spike/object"
+	"spike-interpreter-go/spike/parser"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const countdown = `let countdown = fn(n) { if (n == 0) { :done } else { countdown(n - 1) } }; `
+
+func Test_Limit(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		code          string
+		context       context.Context
+		limits        object.Limits
+		expectedCause error
+		expectedError string
+	}{
+		{
+			code:          countdown + "countdown(10)",
+			limits:        object.Limits{MaxCallDepth: 10},
+			expectedCause: &object.CallDepthError{Limit: 10},
+			expectedError: "1:54: call depth limit of 10 exceeded",
+		},
+		{
+			code:   countdown + "countdown(9)",
+			limits: object.Limits{MaxCallDepth: 10},
+		},
+		{
+			code:   countdown + "countdown(5000)",
+			limits: object.Limits{MaxCallDepth: 5001},
+		},
+		{
+			code:          countdown + "countdown(100)",
+			limits:        object.Limits{MaxInstructions: 100},
+			expectedCause: &object.InstructionLimitError{Limit: 100},
+			expectedError: "1:54: instruction limit of 100 exceeded",
+		},
+		{
+			code:          `let double = fn(s) { double(s + s) }; double("spike")`,
+			limits:        object.Limits{MaxMemory: 1 << 20},
+			expectedCause: &object.MemoryLimitError{Limit: 1 << 20},
+			expectedError: "1:29: memory limit of 1048576 bytes exceeded",
+		},
+		{
+			code:          "1 + 2",
+			context:       cancelled,
+			expectedCause: context.Canceled,
+			expectedError: "interrupted: context canceled",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.code, func(t *testing.T) {
+			ctx := testCase.context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			vm := New(compileForLimits(t, testCase.code))
+			vm.Limit(ctx, testCase.limits)
+
+			err := vm.Run()
+			if testCase.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, object.NewAtom("done"), vm.LastPoppedStackElement())
+				return
+			}
+
+			assert.EqualError(t, err, testCase.expectedError)
+			assert.Equal(t, testCase.expectedCause, errors.Cause(err))
+		})
+	}
+}
+
+func Test_Run_callDepth(t *testing.T) {
+	err := New(compileForLimits(t, "let loop = fn() { loop() }; loop()")).Run()
+
+	assert.Equal(t, &object.CallDepthError{Limit: MaxFrames}, errors.Cause(err))
+}
+
+func Test_Limit_receive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	vm := New(compileForLimits(t, "receive message -> { message } end"))
+	vm.Limit(ctx, object.Limits{})
+
+	err := vm.Run()
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+}
+
+func Test_Limit_spawnedProcesses(t *testing.T) {
+	code := `let loop = fn(n) { loop(n + 1) }; process Loop { call run() { loop(0) } }; spawn Loop().run()`
+
+	vm := New(compileForLimits(t, code))
+	vm.Limit(context.Background(), object.Limits{MaxInstructions: 10000, MaxCallDepth: 1 << 20})
+
+	err := vm.Run()
+	assert.EqualError(t, err, "1:76: process Loop exited: 1:25: instruction limit of 10000 exceeded")
+}
+
+func compileForLimits(t *testing.T, code string) *compiler.Bytecode {
+	program, err := parser.New(lexer.New(strings.NewReader(code))).ParseProgram()
+	assert.NoError(t, err)
+
+	comp := compiler.New()
+	assert.NoError(t, comp.Compile(program))
+
+	return comp.Bytecode()
+}
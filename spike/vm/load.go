@@ -0,0 +1,37 @@
+package vm
+
+import (
+	"io/ioutil"
+	"spike-interpreter-go/spike/compiler"
+
+	"github.com/pkg/errors"
+)
+
+// LoadBytecode reads a bytecode file written by compiler.Marshal, rejecting
+// files of another bytecode version and bytecode that fails Verify.
+func LoadBytecode(path string) (*compiler.Bytecode, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bytecode, err := compiler.Unmarshal(data)
+	if err == nil {
+		err = Verify(bytecode)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading %s", path)
+	}
+
+	return bytecode, nil
+}
+
+// NewFromFile returns a VM running the bytecode file at path.
+func NewFromFile(path string) (*VM, error) {
+	bytecode, err := LoadBytecode(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(bytecode), nil
+}
@@ -0,0 +1,81 @@
+package vm
+
+import (
+	"fmt"
+	"spike-interpreter-go/spike/object"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const receiveCursorType object.ObjectType = "receiveCursor"
+
+// receivePollInterval is how long receive waits for messages at once when the
+// VM has limits, before checking whether its context is done.
+const receivePollInterval = 50 * time.Millisecond
+
+// receiveCursor is the position of a receive expression in the mailbox. It
+// lives on the stack between OpReceiveStart and the end of the expression.
+type receiveCursor struct {
+	index    int
+	deadline time.Time
+}
+
+// newReceiveCursor starts at the oldest message. The timeout is a number of
+// milliseconds, or null to wait forever.
+func newReceiveCursor(timeout object.Object) (*receiveCursor, error) {
+	switch timeout := timeout.(type) {
+	case *object.Null:
+		return &receiveCursor{}, nil
+	case *object.Integer:
+		milliseconds := time.Duration(timeout.Value) * time.Millisecond
+		return &receiveCursor{deadline: time.Now().Add(milliseconds)}, nil
+	}
+
+	return nil, errors.Errorf("receive timeout must be an integer, got %s", timeout.Type())
+}
+
+func (cursor *receiveCursor) Type() object.ObjectType {
+	return receiveCursorType
+}
+
+func (cursor *receiveCursor) Inspect() string {
+	return fmt.Sprintf("receive(%d)", cursor.index)
+}
+
+func (cursor *receiveCursor) Equal(other object.Object) bool {
+	return cursor == other
+}
+
+func (cursor *receiveCursor) expired() bool {
+	return !cursor.deadline.IsZero() && !time.Now().Before(cursor.deadline)
+}
+
+// cursorAt returns the cursor at index of the stack. Bytecode that passes
+// Verify can still hold another value there.
+func (vm *VM) cursorAt(index int) (*receiveCursor, error) {
+	cursor, ok := vm.stack[index].(*receiveCursor)
+	if !ok {
+		return nil, errors.Errorf("expected a receive cursor, got %s", vm.stack[index].Type())
+	}
+
+	return cursor, nil
+}
+
+// receive returns the message under cursor. VMs running with a budget don't
+// block: they give up the rest of their slice instead and retry when resumed.
+// VMs with limits wait receivePollInterval at most, so that they notice when
+// their context is done.
+func (vm *VM) receive(cursor *receiveCursor) (object.Object, bool) {
+	deadline := cursor.deadline
+	if vm.budget >= 0 {
+		deadline = time.Now()
+	} else if vm.meter != nil {
+		poll := time.Now().Add(receivePollInterval)
+		if deadline.IsZero() || poll.Before(deadline) {
+			deadline = poll
+		}
+	}
+
+	return vm.process.Mailbox.Peek(cursor.index, deadline)
+}
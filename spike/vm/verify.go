@@ -0,0 +1,358 @@
+package vm
+
+import (
+	"fmt"
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/object"
+
+	"github.com/pkg/errors"
+)
+
+// VerifyError reports bytecode the VM can not run safely.
+type VerifyError struct {
+	Function string
+	Offset   int
+	Message  string
+}
+
+func (err *VerifyError) Error() string {
+	return fmt.Sprintf("invalid bytecode in %s at %04d: %s", err.Function, err.Offset, err.Message)
+}
+
+// stackEffect tells how many values an instruction needs on the stack, how
+// many it pops and how many it pushes when execution continues with the next
+// instruction.
+type stackEffect struct {
+	needs, pops, pushes int
+}
+
+// Verify checks bytecode statically, so that running it can fail with
+// runtime errors but not crash the VM. Every function, the main program
+// included, must consist of defined opcodes with complete operands, refer to
+// existing constants, builtins, locals and free variables, jump to the start
+// of instructions, return rather than run past its end, and never pop more
+// than it pushed. Where control flow joins, the stack must have the same
+// depth on every path, and no function may need more than StackSize slots
+// for its locals and operands.
+//
+// Verify is meant for bytecode read from files, which may be corrupted or
+// crafted, so that it is checked once instead of on every instruction run.
+func Verify(bytecode *compiler.Bytecode) error {
+	main := &object.CompiledFunction{Name: mainFunctionName, Instructions: bytecode.Instructions}
+	freeVariables := closureFreeVariables(main, bytecode.Constants)
+
+	verifier := &verifier{constants: bytecode.Constants}
+	if err := verifier.verify(main, true, 0); err != nil {
+		return err
+	}
+
+	for i, constant := range bytecode.Constants {
+		function, ok := constant.(*object.CompiledFunction)
+		if !ok {
+			continue
+		}
+		if function.LocalsCount < function.ParametersCount {
+			return &VerifyError{
+				Function: functionName(function),
+				Message:  fmt.Sprintf("%d locals can not hold %d parameters", function.LocalsCount, function.ParametersCount),
+			}
+		}
+		if err := verifier.verify(function, false, freeVariables[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type verifier struct {
+	constants []object.Object
+}
+
+// closureFreeVariables returns the least number of free variables closures
+// of each function constant are created with, wherever they are created.
+func closureFreeVariables(main *object.CompiledFunction, constants []object.Object) map[int]int {
+	freeVariables := map[int]int{}
+
+	functions := []*object.CompiledFunction{main}
+	for _, constant := range constants {
+		if function, ok := constant.(*object.CompiledFunction); ok {
+			functions = append(functions, function)
+		}
+	}
+
+	for _, function := range functions {
+		instructions := function.Instructions
+		for offset := 0; offset < len(instructions); {
+			_, operands, length, err := instructions.ReadInstruction(offset)
+			if err != nil {
+				break
+			}
+
+			if code.Opcode(instructions[offset]) == code.OpClosure {
+				if known, ok := freeVariables[operands[0]]; !ok || operands[1] < known {
+					freeVariables[operands[0]] = operands[1]
+				}
+			}
+			offset += length
+		}
+	}
+
+	return freeVariables
+}
+
+// verify checks the instructions of function, which closures create with
+// at least freeVariables free variables.
+func (verifier *verifier) verify(function *object.CompiledFunction, main bool, freeVariables int) error {
+	name := functionName(function)
+	instructions := function.Instructions
+	fail := func(offset int, format string, args ...interface{}) error {
+		return &VerifyError{Function: name, Offset: offset, Message: fmt.Sprintf(format, args...)}
+	}
+
+	type decoded struct {
+		opcode   code.Opcode
+		operands []int
+		length   int
+	}
+	decodedAt := map[int]decoded{}
+	offsets := []int{}
+
+	for offset := 0; offset < len(instructions); {
+		definition, operands, length, err := instructions.ReadInstruction(offset)
+		if err != nil {
+			return fail(offset, "%s", err)
+		}
+
+		opcode := code.Opcode(instructions[offset])
+		if err := verifier.checkOperands(opcode, operands, function, main, freeVariables); err != nil {
+			return fail(offset, "%s %s", definition.Name, err)
+		}
+
+		decodedAt[offset] = decoded{opcode: opcode, operands: operands, length: length}
+		offsets = append(offsets, offset)
+		offset += length
+	}
+
+	for _, offset := range offsets {
+		instruction := decodedAt[offset]
+		if !code.IsJump(instruction.opcode) {
+			continue
+		}
+
+		target := instruction.operands[0]
+		if _, ok := decodedAt[target]; !ok && !(main && target == len(instructions)) {
+			return fail(offset, "jump target %d is not the start of an instruction", target)
+		}
+	}
+
+	// depths holds the stack depth before each instruction reached so far.
+	depths := map[int]int{}
+	pending := []int{}
+	reach := func(from, offset, depth int) error {
+		if offset == len(instructions) && main {
+			return nil
+		}
+		if offset >= len(instructions) {
+			return fail(from, "execution runs past the end of the function")
+		}
+
+		if known, ok := depths[offset]; ok {
+			if known != depth {
+				return fail(offset, "stack depth is %d on one path and %d on another", known, depth)
+			}
+			return nil
+		}
+
+		depths[offset] = depth
+		pending = append(pending, offset)
+		return nil
+	}
+
+	if len(instructions) == 0 && !main {
+		return fail(0, "execution runs past the end of the function")
+	}
+	if len(instructions) > 0 {
+		if err := reach(0, 0, 0); err != nil {
+			return err
+		}
+	}
+
+	maxDepth := 0
+	for len(pending) > 0 {
+		offset := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		instruction := decodedAt[offset]
+		depth := depths[offset]
+		effect := effectOf(instruction.opcode, instruction.operands)
+		if depth < effect.needs {
+			return fail(offset, "stack underflow, %s needs %d values but the stack holds %d",
+				opcodeName(instruction.opcode), effect.needs, depth)
+		}
+
+		next := depth - effect.pops + effect.pushes
+		if next > maxDepth {
+			maxDepth = next
+		}
+
+		var err error
+		switch instruction.opcode {
+		case code.OpReturnValue, code.OpReturn, code.OpNoMatch:
+		case code.OpJump:
+			err = reach(offset, instruction.operands[0], next)
+		case code.OpJumpNotTrue:
+			if err = reach(offset, instruction.operands[0], next); err == nil {
+				err = reach(offset, offset+instruction.length, next)
+			}
+		case code.OpReceive:
+			// The timeout jump pops the cursor instead of pushing a message.
+			if err = reach(offset, instruction.operands[0], depth-1); err == nil {
+				err = reach(offset, offset+instruction.length, next)
+			}
+		default:
+			err = reach(offset, offset+instruction.length, next)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if function.LocalsCount+maxDepth > StackSize {
+		return fail(0, "needs %d stack slots, more than the %d of the VM", function.LocalsCount+maxDepth, StackSize)
+	}
+
+	return nil
+}
+
+// checkOperands checks operands referring to constants, builtins, locals and
+// free variables.
+func (verifier *verifier) checkOperands(
+	opcode code.Opcode,
+	operands []int,
+	function *object.CompiledFunction,
+	main bool,
+	freeVariables int,
+) error {
+	switch opcode {
+	case code.OpConstant:
+		if operands[0] >= len(verifier.constants) {
+			return errors.Errorf("refers to constant %d of %d", operands[0], len(verifier.constants))
+		}
+
+	case code.OpClosure:
+		if operands[0] >= len(verifier.constants) {
+			return errors.Errorf("refers to constant %d of %d", operands[0], len(verifier.constants))
+		}
+		if _, ok := verifier.constants[operands[0]].(*object.CompiledFunction); !ok {
+			return errors.Errorf("refers to constant %d, which is not a function", operands[0])
+		}
+
+	case code.OpGetBuiltin:
+		if operands[0] >= len(object.Builtins) {
+			return errors.Errorf("refers to builtin %d of %d", operands[0], len(object.Builtins))
+		}
+
+	case code.OpGetLocal, code.OpSetLocal:
+		if main {
+			return errors.New("used outside of a function")
+		}
+		if operands[0] >= function.LocalsCount {
+			return errors.Errorf("refers to local %d of %d", operands[0], function.LocalsCount)
+		}
+
+	case code.OpGetFreeVar:
+		if main {
+			return errors.New("used outside of a function")
+		}
+		if operands[0] >= freeVariables {
+			return errors.Errorf("refers to free variable %d of %d", operands[0], freeVariables)
+		}
+
+	case code.OpHash:
+		if operands[0]%2 != 0 {
+			return errors.Errorf("needs an even number of keys and values, got %d", operands[0])
+		}
+
+	case code.OpMatchArray:
+		if operands[1] > 1 {
+			return errors.Errorf("rest flag must be 0 or 1, got %d", operands[1])
+		}
+
+	case code.OpReturnValue, code.OpReturn:
+		if main {
+			return errors.New("used outside of a function")
+		}
+
+	case code.OpYield:
+		if !function.Generator {
+			return errors.New("used outside of a generator")
+		}
+	}
+
+	return nil
+}
+
+// effectOf mirrors how run changes the stack for each opcode.
+func effectOf(opcode code.Opcode, operands []int) stackEffect {
+	switch opcode {
+	case code.OpConstant, code.OpTrue, code.OpFalse, code.OpNull,
+		code.OpGetGlobal, code.OpGetLocal, code.OpGetBuiltin, code.OpGetFreeVar:
+		return stackEffect{pushes: 1}
+
+	case code.OpPop, code.OpJumpNotTrue, code.OpSetGlobal, code.OpSetLocal, code.OpReceiveAccept:
+		return stackEffect{needs: 1, pops: 1}
+
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv,
+		code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpGreaterOrEqual, code.OpLessThan, code.OpLessOrEqual,
+		code.OpIndex, code.OpMatchEqual:
+		return stackEffect{needs: 2, pops: 2, pushes: 1}
+
+	case code.OpMinus, code.OpBang, code.OpMatchTuple, code.OpMatchArray, code.OpArrayRest,
+		code.OpReceiveStart, code.OpYield:
+		return stackEffect{needs: 1, pops: 1, pushes: 1}
+
+	case code.OpArray, code.OpTuple, code.OpHash:
+		return stackEffect{needs: operands[0], pops: operands[0], pushes: 1}
+
+	case code.OpClosure:
+		return stackEffect{needs: operands[1], pops: operands[1], pushes: 1}
+
+	case code.OpCall:
+		return stackEffect{needs: operands[0] + 1, pops: operands[0] + 1, pushes: 1}
+
+	case code.OpMatchHash:
+		return stackEffect{needs: operands[0] + 1, pops: operands[0] + 1, pushes: 1}
+
+	case code.OpReturnValue, code.OpNoMatch:
+		return stackEffect{needs: 1, pops: 1}
+
+	case code.OpReceive:
+		return stackEffect{needs: 1, pushes: 1}
+
+	case code.OpReceiveNext:
+		return stackEffect{needs: 1}
+	}
+
+	// OpJump and OpReturn leave the stack alone.
+	return stackEffect{}
+}
+
+func opcodeName(opcode code.Opcode) string {
+	definition, err := code.Lookup(opcode)
+	if err != nil {
+		return fmt.Sprintf("opcode %d", opcode)
+	}
+
+	return definition.Name
+}
+
+func functionName(function *object.CompiledFunction) string {
+	name := function.Name
+	if name == "" {
+		name = anonymousFunctionName
+	}
+
+	return name
+}
@@ -0,0 +1,246 @@
+package vm
+
+import (
+	"fmt"
+	"spike-interpreter-go/spike/code"
+	"spike-interpreter-go/spike/compiler"
+	"spike-interpreter-go/spike/object"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Verify(t *testing.T) {
+	function := func(instructions code.Instructions, locals, parameters int) *object.CompiledFunction {
+		return &object.CompiledFunction{
+			Name:            "f",
+			Instructions:    instructions,
+			LocalsCount:     locals,
+			ParametersCount: parameters,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		bytecode      *compiler.Bytecode
+		expectedError string
+	}{
+		{
+			name: "valid",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().
+					Make(code.OpTrue).
+					Make(code.OpJumpNotTrue, 11).
+					Make(code.OpClosure, 1, 0).
+					Make(code.OpJump, 12).
+					Make(code.OpNull).
+					Make(code.OpPop).
+					Build(),
+				Constants: []object.Object{
+					&object.Integer{Value: 1},
+					function(code.NewBuilder().Make(code.OpGetLocal, 0).Make(code.OpConstant, 0).Make(code.OpAdd).Make(code.OpReturnValue).Build(), 1, 1),
+				},
+			},
+		},
+		{
+			name: "undefined opcode",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.Instructions{byte(code.OpTrue), 255},
+			},
+			expectedError: "invalid bytecode in <main> at 0001: opcode 255 undefined",
+		},
+		{
+			name: "truncated operands",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpConstant, 0).Build()[:2],
+				Constants:    []object.Object{&object.Integer{Value: 1}},
+			},
+			expectedError: "invalid bytecode in <main> at 0000: OpConstant truncated, expected 2 operand bytes, got 1",
+		},
+		{
+			name: "constant out of range",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpConstant, 3).Make(code.OpPop).Build(),
+			},
+			expectedError: "invalid bytecode in <main> at 0000: OpConstant refers to constant 3 of 0",
+		},
+		{
+			name: "closure of a non-function",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpClosure, 0, 0).Make(code.OpPop).Build(),
+				Constants:    []object.Object{&object.Integer{Value: 1}},
+			},
+			expectedError: "invalid bytecode in <main> at 0000: OpClosure refers to constant 0, which is not a function",
+		},
+		{
+			name: "builtin out of range",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpGetBuiltin, 255).Make(code.OpPop).Build(),
+			},
+			expectedError: fmt.Sprintf("invalid bytecode in <main> at 0000: OpGetBuiltin refers to builtin 255 of %d", len(object.Builtins)),
+		},
+		{
+			name: "local out of range",
+			bytecode: &compiler.Bytecode{
+				Constants: []object.Object{function(code.NewBuilder().Make(code.OpGetLocal, 1).Make(code.OpReturnValue).Build(), 1, 1)},
+			},
+			expectedError: "invalid bytecode in f at 0000: OpGetLocal refers to local 1 of 1",
+		},
+		{
+			name: "local in the main program",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpGetLocal, 0).Make(code.OpPop).Build(),
+			},
+			expectedError: "invalid bytecode in <main> at 0000: OpGetLocal used outside of a function",
+		},
+		{
+			name: "free variable not captured",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpClosure, 0, 0).Make(code.OpPop).Build(),
+				Constants:    []object.Object{function(code.NewBuilder().Make(code.OpGetFreeVar, 0).Make(code.OpReturnValue).Build(), 0, 0)},
+			},
+			expectedError: "invalid bytecode in f at 0000: OpGetFreeVar refers to free variable 0 of 0",
+		},
+		{
+			name: "return from the main program",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpNull).Make(code.OpReturnValue).Build(),
+			},
+			expectedError: "invalid bytecode in <main> at 0001: OpReturnValue used outside of a function",
+		},
+		{
+			name: "yield outside of a generator",
+			bytecode: &compiler.Bytecode{
+				Constants: []object.Object{function(code.NewBuilder().Make(code.OpNull).Make(code.OpYield).Make(code.OpReturnValue).Build(), 0, 0)},
+			},
+			expectedError: "invalid bytecode in f at 0001: OpYield used outside of a generator",
+		},
+		{
+			name: "jump into an instruction",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpJump, 4).Make(code.OpConstant, 0).Make(code.OpPop).Build(),
+				Constants:    []object.Object{&object.Integer{Value: 1}},
+			},
+			expectedError: "invalid bytecode in <main> at 0000: jump target 4 is not the start of an instruction",
+		},
+		{
+			name: "stack underflow",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpTrue).Make(code.OpAdd).Build(),
+			},
+			expectedError: "invalid bytecode in <main> at 0001: stack underflow, OpAdd needs 2 values but the stack holds 1",
+		},
+		{
+			name: "call without callee",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().Make(code.OpTrue).Make(code.OpCall, 1).Build(),
+			},
+			expectedError: "invalid bytecode in <main> at 0001: stack underflow, OpCall needs 2 values but the stack holds 1",
+		},
+		{
+			name: "inconsistent depths",
+			bytecode: &compiler.Bytecode{
+				Instructions: code.NewBuilder().
+					Make(code.OpTrue).
+					Make(code.OpJumpNotTrue, 7).
+					Make(code.OpNull).
+					Make(code.OpNull).
+					Make(code.OpNull).
+					Make(code.OpPop).
+					Build(),
+			},
+			expectedError: "invalid bytecode in <main> at 0007: stack depth is 0 on one path and 3 on another",
+		},
+		{
+			name: "function without return",
+			bytecode: &compiler.Bytecode{
+				Constants: []object.Object{function(code.NewBuilder().Make(code.OpNull).Build(), 0, 0)},
+			},
+			expectedError: "invalid bytecode in f at 0000: execution runs past the end of the function",
+		},
+		{
+			name: "locals for parameters",
+			bytecode: &compiler.Bytecode{
+				Constants: []object.Object{function(code.NewBuilder().Make(code.OpReturn).Build(), 0, 2)},
+			},
+			expectedError: "invalid bytecode in f at 0000: 0 locals can not hold 2 parameters",
+		},
+		{
+			name: "stack too deep",
+			bytecode: &compiler.Bytecode{
+				Constants: []object.Object{function(code.NewBuilder().Make(code.OpArray, 0).Make(code.OpReturnValue).Build(), StackSize, 0)},
+			},
+			expectedError: "invalid bytecode in f at 0000: needs 2049 stack slots, more than the 2048 of the VM",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := Verify(testCase.bytecode)
+
+			if testCase.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, testCase.expectedError)
+			assert.IsType(t, &VerifyError{}, err)
+		})
+	}
+}
+
+func Test_Verify_runtimeErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		instructions  code.Instructions
+		constants     []object.Object
+		expectedError string
+	}{
+		{
+			name:          "rest of a non-array",
+			instructions:  code.NewBuilder().Make(code.OpNull).Make(code.OpArrayRest, 0).Make(code.OpPop).Build(),
+			expectedError: "unable to skip 0 elements of null",
+		},
+		{
+			name:          "rest past the end of an array",
+			instructions:  code.NewBuilder().Make(code.OpArray, 0).Make(code.OpArrayRest, 5).Make(code.OpPop).Build(),
+			expectedError: "unable to skip 5 elements of []",
+		},
+		{
+			name:          "unhashable key pattern",
+			instructions:  code.NewBuilder().Make(code.OpHash, 0).Make(code.OpArray, 0).Make(code.OpMatchHash, 1).Make(code.OpPop).Build(),
+			expectedError: "unusable as hash key: array",
+		},
+		{
+			name:          "global before it is set",
+			instructions:  code.NewBuilder().Make(code.OpGetGlobal, 0).Make(code.OpPop).Build(),
+			expectedError: "global 0 is used before it is set",
+		},
+		{
+			name:          "next without a cursor",
+			instructions:  code.NewBuilder().Make(code.OpNull).Make(code.OpReceiveNext).Make(code.OpPop).Build(),
+			expectedError: "expected a receive cursor, got null",
+		},
+		{
+			name:          "accept without a cursor",
+			instructions:  code.NewBuilder().Make(code.OpConstant, 0).Make(code.OpReceiveAccept).Build(),
+			constants:     []object.Object{&object.Integer{Value: 1}},
+			expectedError: "expected a receive cursor, got integer",
+		},
+		{
+			name:          "accept without a message",
+			instructions:  code.NewBuilder().Make(code.OpNull).Make(code.OpReceiveStart).Make(code.OpReceiveAccept).Build(),
+			expectedError: "no message to accept at receive(0)",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			bytecode := &compiler.Bytecode{Instructions: testCase.instructions, Constants: testCase.constants}
+			assert.NoError(t, Verify(bytecode))
+
+			err := New(bytecode).Run()
+			assert.EqualError(t, errors.Cause(err), testCase.expectedError)
+		})
+	}
+}
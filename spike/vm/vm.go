@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"context"
 	"encoding/binary"
 	"spike-interpreter-go/spike/code"
 	"spike-interpreter-go/spike/compiler"
@@ -10,15 +11,39 @@ import (
 )
 
 const (
-	StackSize   = 2048
-	MaxFrames   = 1024
+	// StackSize is the initial size of the stack, which grows as calls nest
+	// by up to StackSize slots for each frame.
+	StackSize = 2048
+	// MaxFrames is the call depth of VMs without limits.
+	MaxFrames   = object.DefaultMaxCallDepth
 	GlobalsSize = 65536
 )
 
+const (
+	mainFunctionName      = "<main>"
+	anonymousFunctionName = "<anonymous>"
+)
+
+var binaryOperators = map[code.Opcode]string{
+	code.OpAdd: "+",
+	code.OpSub: "-",
+	code.OpMul: "*",
+	code.OpDiv: "/",
+}
+
+var comparisonOperators = map[code.Opcode]string{
+	code.OpEqual:          "==",
+	code.OpNotEqual:       "!=",
+	code.OpGreaterThan:    ">",
+	code.OpGreaterOrEqual: ">=",
+	code.OpLessThan:       "<",
+	code.OpLessOrEqual:    "<=",
+}
+
 var (
-	True  = &object.Boolean{Value: true}
-	False = &object.Boolean{Value: false}
-	Null  = &object.Null{}
+	True  = &object.True
+	False = &object.False
+	Null  = &object.NullObject
 )
 
 type VM struct {
@@ -30,10 +55,49 @@ type VM struct {
 
 	frames      []*Frame
 	framesIndex int
+	// maxFrames is the number of frames the call depth limit allows, the
+	// main frame included.
+	maxFrames int
+
+	// budget is the number of instructions run may still execute, negative
+	// when unlimited.
+	budget int
+	// yielded is set when run stops at a yield of the generator the VM runs.
+	yielded bool
+
+	// meter enforces the limits set by Limit, nil when there are none.
+	meter *object.Meter
+
+	process *object.Process
+}
+
+// State is the state a VM is left in by RunFor.
+type State int
+
+const (
+	// Suspended VMs ran out of budget and resume on the next RunFor.
+	Suspended State = iota
+	// Finished VMs executed the whole program.
+	Finished
+)
+
+func (state State) String() string {
+	if state == Finished {
+		return "finished"
+	}
+	return "suspended"
 }
 
 func New(bytecode *compiler.Bytecode) *VM {
-	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions}
+	return NewWithGlobalStore(bytecode, make([]object.Object, GlobalsSize))
+}
+
+func NewWithGlobalStore(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	mainFn := &object.CompiledFunction{
+		Name:         mainFunctionName,
+		Instructions: bytecode.Instructions,
+		LineTable:    bytecode.LineTable,
+	}
 	mainClosure := &object.Closure{
 		Function:      mainFn,
 		FreeVariables: nil,
@@ -46,25 +110,93 @@ func New(bytecode *compiler.Bytecode) *VM {
 	return &VM{
 		constants:   bytecode.Constants,
 		stack:       make([]object.Object, StackSize),
-		globals:     make([]object.Object, GlobalsSize),
+		globals:     globals,
 		sp:          0,
 		frames:      frames,
 		framesIndex: 1,
+		maxFrames:   MaxFrames + 1,
+		budget:      -1,
+		process:     object.NewProcess(),
 	}
 }
 
-func NewWithGlobalStore(bytecode *compiler.Bytecode, globals []object.Object) *VM {
-	vm := New(bytecode)
-	vm.globals = globals
-	return vm
+// Limit makes Run, RunFor and Call stop with a runtime error caused by an
+// object.InterruptedError once ctx is done, or by an
+// object.InstructionLimitError, object.CallDepthError or
+// object.MemoryLimitError when the program exceeds limits. The instructions
+// and memory used by the program are counted from this call on, and
+// processes it spawns count towards the same limits.
+func (vm *VM) Limit(ctx context.Context, limits object.Limits) {
+	vm.meter = object.NewMeter(ctx, limits)
+	vm.maxFrames = vm.meter.MaxCallDepth() + 1
 }
 
 func (vm *VM) Run() error {
+	err := vm.run()
+	if err != nil {
+		return vm.newRuntimeError(err)
+	}
+
+	return nil
+}
+
+// RunFor executes at most budget instructions. A suspended VM keeps its
+// stack and frames, so calling RunFor or Run again continues where it
+// stopped.
+func (vm *VM) RunFor(budget int) (State, error) {
+	if budget <= 0 {
+		return Suspended, errors.Errorf("budget must be positive, got %d", budget)
+	}
+
+	vm.budget = budget
+	err := vm.run()
+	vm.budget = -1
+
+	if err != nil {
+		return Finished, vm.newRuntimeError(err)
+	}
+
+	if vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		return Suspended, nil
+	}
+	return Finished, nil
+}
+
+func (vm *VM) newRuntimeError(err error) *RuntimeError {
+	frames := make([]StackFrame, 0, vm.framesIndex)
+
+	for i := vm.framesIndex - 1; i >= 0; i-- {
+		frame := vm.frames[i]
+		function := frame.closure.Function
+
+		span, _ := function.LineTable.Lookup(frame.ip)
+		frames = append(frames, StackFrame{Function: functionName(function), Span: span})
+	}
+
+	return &RuntimeError{Err: err, Frames: frames}
+}
+
+func (vm *VM) run() error {
 	var ip int
 	var instructions code.Instructions
 	var op code.Opcode
 
 	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		if vm.budget == 0 {
+			return nil
+		}
+		if vm.budget > 0 {
+			vm.budget--
+		}
+		if vm.process.Killed() {
+			return object.ErrKilled
+		}
+		if vm.meter != nil {
+			if err := vm.meter.Step(); err != nil {
+				return err
+			}
+		}
+
 		vm.currentFrame().ip++
 
 		ip = vm.currentFrame().ip
@@ -82,19 +214,13 @@ func (vm *VM) Run() error {
 
 			}
 
-		case code.OpAdd:
-			err := vm.executePlusOperation()
-			if err != nil {
-				return err
-			}
-
-		case code.OpSub, code.OpMul, code.OpDiv:
-			err := vm.executeBinaryIntegerOperation(op)
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv:
+			err := vm.executeBinaryOperation(op)
 			if err != nil {
 				return err
 			}
 
-		case code.OpEqual, code.OpNotEqual, code.OpGreaterThan:
+		case code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpGreaterOrEqual, code.OpLessThan, code.OpLessOrEqual:
 			err := vm.executeComparison(op)
 			if err != nil {
 				return err
@@ -135,8 +261,11 @@ func (vm *VM) Run() error {
 			jumpIndex := binary.BigEndian.Uint16(instructions[ip+1:])
 			vm.currentFrame().ip += 2
 
-			condition := vm.pop().(*object.Boolean).Value
-			if !condition {
+			condition, ok := vm.pop().(*object.Boolean)
+			if !ok {
+				return errors.Errorf("expected boolean, got %s", vm.stack[vm.sp].Type())
+			}
+			if !condition.Value {
 				vm.currentFrame().ip = int(jumpIndex) - 1
 			}
 
@@ -156,7 +285,12 @@ func (vm *VM) Run() error {
 			globalIndex := binary.BigEndian.Uint16(instructions[ip+1:])
 			vm.currentFrame().ip += 2
 
-			err := vm.push(vm.globals[globalIndex])
+			global := vm.globals[globalIndex]
+			if global == nil {
+				return errors.Errorf("global %d is used before it is set", globalIndex)
+			}
+
+			err := vm.push(global)
 			if err != nil {
 				return err
 			}
@@ -173,108 +307,214 @@ func (vm *VM) Run() error {
 			vm.sp -= elementsCount
 
 			array := &object.Array{Elements: elements}
-			err := vm.push(array)
+			err := vm.pushNew(array)
 			if err != nil {
 				return err
 			}
 
-		case code.OpHash:
+		case code.OpTuple:
 			elementsCount := int(binary.BigEndian.Uint16(instructions[ip+1:]))
 			vm.currentFrame().ip += 2
 
-			pairs := make(map[object.HashKey]object.HashPair)
+			elements := make([]object.Object, elementsCount)
+			copy(elements, vm.stack[vm.sp-elementsCount:vm.sp])
+			vm.sp -= elementsCount
 
-			for i := 0; i < elementsCount; i += 2 {
-				key := vm.stack[vm.sp-elementsCount+i].(object.Hashable)
-				value := vm.stack[vm.sp-elementsCount+i+1]
+			err := vm.pushNew(&object.Tuple{Elements: elements})
+			if err != nil {
+				return err
+			}
 
-				pairs[key.GetHashKey()] = object.HashPair{
-					Key:   key.(object.Object),
-					Value: value,
-				}
+		case code.OpMatchEqual:
+			pattern := vm.pop()
+			value := vm.pop()
+
+			err := vm.push(nativeBoolToBoolean(pattern.Equal(value)))
+			if err != nil {
+				return err
 			}
 
-			hash := &object.Hash{Pairs: pairs}
-			err := vm.push(hash)
+		case code.OpMatchTuple:
+			elementsCount := int(binary.BigEndian.Uint16(instructions[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			tuple, ok := vm.pop().(*object.Tuple)
+			err := vm.push(nativeBoolToBoolean(ok && len(tuple.Elements) == elementsCount))
 			if err != nil {
 				return err
 			}
 
-		case code.OpIndex:
-			index := vm.pop()
-			array := vm.pop()
+		case code.OpMatchArray:
+			elementsCount := int(binary.BigEndian.Uint16(instructions[ip+1:]))
+			hasRest := instructions[ip+3] == 1
+			vm.currentFrame().ip += 3
 
-			switch array := array.(type) {
-			case *object.Array:
-				index, ok := index.(*object.Integer)
-				if !ok {
-					return errors.Errorf("Array index must be an integer, got: %s", index.Type())
-				}
+			array, ok := vm.pop().(*object.Array)
+			matched := ok && (len(array.Elements) == elementsCount || hasRest && len(array.Elements) >= elementsCount)
+			err := vm.push(nativeBoolToBoolean(matched))
+			if err != nil {
+				return err
+			}
 
-				if index.Value < 0 || index.Value >= int64(len(array.Elements)) {
-					err := vm.push(Null)
-					if err != nil {
-						return err
-					}
-				} else {
-					err := vm.push(array.Elements[index.Value])
-					if err != nil {
-						return err
-					}
-				}
-			case *object.Hash:
-				hashKey, ok := index.(object.Hashable)
+		case code.OpMatchHash:
+			keysCount := int(binary.BigEndian.Uint16(instructions[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			keys := vm.stack[vm.sp-keysCount : vm.sp]
+			hash, matched := vm.stack[vm.sp-keysCount-1].(*object.Hash)
+			for i := 0; matched && i < keysCount; i++ {
+				key, ok := keys[i].(object.Hashable)
 				if !ok {
-					return errors.Errorf("Object of type %s can not be used as a hash key", index.Type())
+					return errors.Errorf("unusable as hash key: %s", keys[i].Type())
 				}
+				_, matched = hash.Pairs[key.GetHashKey()]
+			}
+			vm.sp -= keysCount + 1
+
+			err := vm.push(nativeBoolToBoolean(matched))
+			if err != nil {
+				return err
+			}
+
+		case code.OpArrayRest:
+			skipped := int(binary.BigEndian.Uint16(instructions[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			value := vm.pop()
+			array, ok := value.(*object.Array)
+			if !ok || len(array.Elements) < skipped {
+				return errors.Errorf("unable to skip %d elements of %s", skipped, value.Inspect())
+			}
+			rest := make([]object.Object, len(array.Elements)-skipped)
+			copy(rest, array.Elements[skipped:])
 
-				value, err := array.Get(hashKey)
+			err := vm.pushNew(&object.Array{Elements: rest})
+			if err != nil {
+				return err
+			}
+
+		case code.OpNoMatch:
+			return errors.Errorf("no clause matched: %s", vm.pop().Inspect())
+
+		case code.OpReceiveStart:
+			cursor, err := newReceiveCursor(vm.pop())
+			if err != nil {
+				return err
+			}
+
+			err = vm.push(cursor)
+			if err != nil {
+				return err
+			}
+
+		case code.OpReceive:
+			timeoutIndex := binary.BigEndian.Uint16(instructions[ip+1:])
+			vm.currentFrame().ip += 2
+
+			cursor, err := vm.cursorAt(vm.sp - 1)
+			if err != nil {
+				return err
+			}
+			message, ok := vm.receive(cursor)
+			if ok {
+				err := vm.push(message)
 				if err != nil {
-					err = vm.push(Null)
-					if err != nil {
-						return err
-					}
-				} else {
-					err = vm.push(value)
-					if err != nil {
-						return err
-					}
+					return err
 				}
+				continue
 			}
 
-		case code.OpCall:
-			argumentsCount := int(instructions[ip+1])
-			vm.currentFrame().ip++
-			callee := vm.stack[vm.sp-1-argumentsCount]
-
-			switch callee := callee.(type) {
-			case *object.Closure:
-				if callee.Function.ParametersCount != argumentsCount {
-					return errors.Errorf(
-						"mismatched number of function call arguments. Expected %d, got %d",
-						callee.Function.ParametersCount,
-						argumentsCount,
-					)
+			if vm.budget >= 0 && !cursor.expired() {
+				vm.currentFrame().ip = ip - 1
+				vm.budget = 0
+				continue
+			}
+			if vm.meter != nil && !cursor.expired() && !vm.process.Killed() {
+				if err := vm.meter.Interrupted(); err != nil {
+					return err
 				}
+				vm.currentFrame().ip = ip - 1
+				continue
+			}
 
-				frame := NewFrame(callee, vm.sp-argumentsCount)
-				vm.pushFrame(frame)
-				vm.sp = frame.basePointer + callee.Function.LocalsCount
+			vm.pop()
+			vm.currentFrame().ip = int(timeoutIndex) - 1
 
-			case *object.BuiltinFunction:
-				args := vm.stack[vm.sp-argumentsCount : vm.sp]
+		case code.OpReceiveNext:
+			cursor, err := vm.cursorAt(vm.sp - 1)
+			if err != nil {
+				return err
+			}
+			cursor.index++
 
-				result, err := callee.Function(args...)
+		case code.OpReceiveAccept:
+			cursor, err := vm.cursorAt(vm.sp - 1)
+			if err != nil {
+				return err
+			}
+			if cursor.index >= vm.process.Mailbox.Len() {
+				return errors.Errorf("no message to accept at %s", cursor.Inspect())
+			}
+			vm.pop()
+			vm.process.Mailbox.Remove(cursor.index)
+
+		case code.OpHash:
+			elementsCount := int(binary.BigEndian.Uint16(instructions[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			pairs := make(map[object.HashKey]object.HashPair)
+
+			for i := 0; i < elementsCount; i += 2 {
+				key := vm.stack[vm.sp-elementsCount+i]
+				value := vm.stack[vm.sp-elementsCount+i+1]
+
+				hashKey, err := object.HashKeyOf(key)
 				if err != nil {
 					return err
 				}
-				err = vm.push(result)
+
+				pairs[hashKey] = object.HashPair{Key: key, Value: value}
+			}
+
+			vm.sp -= elementsCount
+
+			hash := &object.Hash{Pairs: pairs}
+			err := vm.pushNew(hash)
+			if err != nil {
+				return err
+			}
+
+		case code.OpIndex:
+			index := vm.pop()
+			container := vm.pop()
+
+			var result object.Object
+			var err error
+			if pid, ok := container.(*object.Pid); ok {
+				name, ok := index.(*object.String)
+				if !ok {
+					return errors.Errorf("Process handler name must be a string, got: %s", index.Type())
+				}
+				result = &object.PidHandler{Pid: pid, Name: name.Value}
+			} else {
+				result, err = object.Index(container, index)
 				if err != nil {
 					return err
 				}
+			}
 
-			default:
-				return errors.Errorf("Calling non-function %T", callee)
+			err = vm.push(result)
+			if err != nil {
+				return err
+			}
+
+		case code.OpCall:
+			argumentsCount := int(instructions[ip+1])
+			vm.currentFrame().ip++
+
+			err := vm.callFunction(argumentsCount)
+			if err != nil {
+				return err
 			}
 
 		case code.OpReturnValue:
@@ -288,6 +528,10 @@ func (vm *VM) Run() error {
 				return err
 			}
 
+		case code.OpYield:
+			vm.yielded = true
+			return nil
+
 		case code.OpReturn:
 			frame := vm.popFrame()
 			vm.sp = frame.basePointer - 1
@@ -344,7 +588,7 @@ func (vm *VM) Run() error {
 				Function:      function,
 				FreeVariables: freeVariables,
 			}
-			err := vm.push(closure)
+			err := vm.pushNew(closure)
 			if err != nil {
 				return err
 			}
@@ -364,35 +608,97 @@ func (vm *VM) Run() error {
 	return nil
 }
 
-func (vm *VM) executePlusOperation() error {
+// callFunction calls the callee placed on the stack below its arguments.
+// Closures get a new frame, other callees are run right away and replaced on
+// the stack by their result.
+func (vm *VM) callFunction(argumentsCount int) error {
+	callee := vm.stack[vm.sp-1-argumentsCount]
+	args := vm.stack[vm.sp-argumentsCount : vm.sp]
+
+	var result object.Object
+	var err error
+
+	switch callee := callee.(type) {
+	case *object.Closure:
+		if callee.Function.ParametersCount != argumentsCount {
+			return errors.Errorf(
+				"mismatched number of function call arguments. Expected %d, got %d",
+				callee.Function.ParametersCount,
+				argumentsCount,
+			)
+		}
+
+		if callee.Function.Generator {
+			result = vm.newGenerator(callee, args)
+			break
+		}
+
+		frame := NewFrame(callee, vm.sp-argumentsCount)
+		if err := vm.pushFrame(frame); err != nil {
+			return err
+		}
+
+		vm.sp = frame.basePointer + callee.Function.LocalsCount
+		if vm.sp >= len(vm.stack) {
+			return vm.growStack(vm.sp + 1)
+		}
+		return nil
+
+	case *object.BuiltinFunction:
+		result, err = callee.Call(vm, args...)
+
+	case *object.PidHandler:
+		result, err = callee.Invoke(vm.process, append([]object.Object{}, args...))
+
+	default:
+		return errors.Errorf("calling non-function %s", callee.Type())
+	}
+
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		result = Null
+	}
+
+	vm.sp = vm.sp - argumentsCount - 1
+	return vm.pushNew(result)
+}
+
+func (vm *VM) executeBinaryOperation(opcode code.Opcode) error {
 	right := vm.pop()
 	left := vm.pop()
 
-	if left.Type() == object.IntegerType && right.Type() == object.IntegerType {
-		leftValue := left.(*object.Integer).Value
-		rightValue := right.(*object.Integer).Value
+	if object.IsInteger(left) && object.IsInteger(right) {
+		result, err := object.IntegerOperation(binaryOperators[opcode], left, right)
+		if err != nil {
+			return err
+		}
+		return vm.pushNew(result)
+	}
 
-		result := &object.Integer{Value: leftValue + rightValue}
-		return vm.push(result)
-	} else if left.Type() == object.StringType && right.Type() == object.StringType {
+	leftFloat, leftIsNumber := object.ToFloat(left)
+	rightFloat, rightIsNumber := object.ToFloat(right)
+	if leftIsNumber && rightIsNumber {
+		return vm.executeBinaryFloatOperation(opcode, leftFloat, rightFloat)
+	}
+
+	if opcode == code.OpAdd && left.Type() == object.StringType && right.Type() == object.StringType {
 		leftValue := left.(*object.String).Value
 		rightValue := right.(*object.String).Value
 
 		result := &object.String{Value: leftValue + rightValue}
-		return vm.push(result)
+		return vm.pushNew(result)
 	}
 
-	return nil
+	return errors.Errorf("type mismatch: %s %s %s", left.Type(), binaryOperators[opcode], right.Type())
 }
 
-func (vm *VM) executeBinaryIntegerOperation(opcode code.Opcode) error {
-	right := vm.pop()
-	left := vm.pop()
-	leftValue := left.(*object.Integer).Value
-	rightValue := right.(*object.Integer).Value
-
-	var result int64
+func (vm *VM) executeBinaryFloatOperation(opcode code.Opcode, leftValue, rightValue float64) error {
+	var result float64
 	switch opcode {
+	case code.OpAdd:
+		result = leftValue + rightValue
 	case code.OpSub:
 		result = leftValue - rightValue
 	case code.OpMul:
@@ -400,56 +706,19 @@ func (vm *VM) executeBinaryIntegerOperation(opcode code.Opcode) error {
 	case code.OpDiv:
 		result = leftValue / rightValue
 	}
-	return vm.push(&object.Integer{Value: result})
+	return vm.pushNew(&object.Float{Value: result})
 }
 
 func (vm *VM) executeComparison(op code.Opcode) error {
 	right := vm.pop()
 	left := vm.pop()
 
-	if right.Type() != left.Type() {
-		return errors.Errorf("both operands must have same type, had: %s and %s", left.Type(), right.Type())
-	}
-
-	if right.Type() == object.IntegerType {
-		return vm.executeIntegerComparison(left, right, op)
-	}
-
-	if right.Type() == object.BooleanType {
-		return vm.executeBooleanComparison(left, right, op)
-	}
-
-	return errors.Errorf("unable to compare variables of type %s and %s", left.Type(), right.Type())
-}
-
-func (vm *VM) executeIntegerComparison(left object.Object, right object.Object, op code.Opcode) error {
-	leftInt := left.(*object.Integer).Value
-	rightInt := right.(*object.Integer).Value
-
-	switch op {
-	case code.OpEqual:
-		return vm.push(nativeBoolToBoolean(leftInt == rightInt))
-	case code.OpNotEqual:
-		return vm.push(nativeBoolToBoolean(leftInt != rightInt))
-	case code.OpGreaterThan:
-		return vm.push(nativeBoolToBoolean(leftInt > rightInt))
+	result, err := object.CompareObjects(comparisonOperators[op], left, right)
+	if err != nil {
+		return err
 	}
 
-	return errors.Errorf("unexpected operation: %d", op)
-}
-
-func (vm *VM) executeBooleanComparison(left object.Object, right object.Object, op code.Opcode) error {
-	leftBool := left.(*object.Boolean).Value
-	rightBool := right.(*object.Boolean).Value
-
-	switch op {
-	case code.OpEqual:
-		return vm.push(nativeBoolToBoolean(leftBool == rightBool))
-	case code.OpNotEqual:
-		return vm.push(nativeBoolToBoolean(leftBool != rightBool))
-	}
-
-	return errors.Errorf("unexpected operation: %d", op)
+	return vm.push(nativeBoolToBoolean(result))
 }
 
 func (vm *VM) executeBangOperator() error {
@@ -461,13 +730,21 @@ func (vm *VM) executeBangOperator() error {
 	case False:
 		return vm.push(True)
 	default:
-		return errors.Errorf("invalid operand for bang prefix operator: %#v", operand)
+		return errors.Errorf("type mismatch: !%s", operand.Type())
 	}
 }
 
 func (vm *VM) executeMinusOperator() error {
-	value := vm.pop().(*object.Integer).Value
-	return vm.push(&object.Integer{Value: -value})
+	operand := vm.pop()
+
+	switch operand := operand.(type) {
+	case *object.Integer, *object.BigInteger:
+		return vm.pushNew(object.NegateInteger(operand))
+	case *object.Float:
+		return vm.pushNew(&object.Float{Value: -operand.Value})
+	default:
+		return errors.Errorf("type mismatch: -%s", operand.Type())
+	}
 }
 
 func nativeBoolToBoolean(nativeBool bool) object.Object {
@@ -483,8 +760,10 @@ func (vm *VM) LastPoppedStackElement() object.Object {
 }
 
 func (vm *VM) push(o object.Object) error {
-	if vm.sp >= StackSize {
-		return errors.New("stack overflow")
+	if vm.sp >= len(vm.stack) {
+		if err := vm.growStack(vm.sp + 1); err != nil {
+			return err
+		}
 	}
 
 	vm.stack[vm.sp] = o
@@ -493,13 +772,57 @@ func (vm *VM) push(o object.Object) error {
 	return nil
 }
 
+// pushNew pushes a value created by the instruction being executed, which
+// counts towards the memory limit.
+func (vm *VM) pushNew(o object.Object) error {
+	if vm.meter != nil {
+		if err := vm.meter.Allocate(o); err != nil {
+			return err
+		}
+	}
+
+	return vm.push(o)
+}
+
+// growStack makes room for size slots, doubling the stack as needed, but
+// never beyond StackSize slots for each frame the call depth limit allows.
+func (vm *VM) growStack(size int) error {
+	if size > StackSize*vm.maxFrames {
+		return errors.New("stack overflow")
+	}
+
+	capacity := len(vm.stack)
+	for capacity < size {
+		capacity *= 2
+	}
+	if capacity > StackSize*vm.maxFrames {
+		capacity = StackSize * vm.maxFrames
+	}
+
+	stack := make([]object.Object, capacity)
+	copy(stack, vm.stack)
+	vm.stack = stack
+
+	return nil
+}
+
 func (vm *VM) currentFrame() *Frame {
 	return vm.frames[vm.framesIndex-1]
 }
 
-func (vm *VM) pushFrame(frame *Frame) {
-	vm.frames[vm.framesIndex] = frame
+func (vm *VM) pushFrame(frame *Frame) error {
+	if vm.framesIndex >= vm.maxFrames {
+		return &object.CallDepthError{Limit: vm.maxFrames - 1}
+	}
+
+	if vm.framesIndex == len(vm.frames) {
+		vm.frames = append(vm.frames, frame)
+	} else {
+		vm.frames[vm.framesIndex] = frame
+	}
 	vm.framesIndex++
+
+	return nil
 }
 
 func (vm *VM) popFrame() *Frame {
@@ -1,8 +1,10 @@
 package vm
 
 import (
-	"github.com/stretchr/testify/assert"
+	"spike-interpreter-go/spike/compiler"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_Run_withError(t *testing.T) {
@@ -12,15 +14,97 @@ func Test_Run_withError(t *testing.T) {
 	}{
 		{
 			code:          `let f = fn(a) { a }; f(1, 2)`,
-			expectedError: "mismatched number of function call arguments. Expected 1, got 2",
+			expectedError: "1:22: mismatched number of function call arguments. Expected 1, got 2",
+		},
+		{
+			code:          `1 / 0`,
+			expectedError: "1:1: division by zero",
+		},
+		{
+			code:          `1.5 - "a"`,
+			expectedError: "1:1: type mismatch: float - string",
+		},
+		{
+			code:          `let x = 1; case (x, 2) of (1, 3) -> { 1 } end`,
+			expectedError: "1:12: no clause matched: (1, 2)",
+		},
+		{
+			code:          `process Empty { call get() { 1 } }; spawn Empty().missing()`,
+			expectedError: "1:37: process Empty has no handler missing",
+		},
+		{
+			code:          `process Broken { call get() { 1 / 0 } }; spawn Broken().get()`,
+			expectedError: "1:42: process Broken exited: 1:31: division by zero",
+		},
+		{
+			code:          `receive x -> { x } after "soon" -> { 1 } end`,
+			expectedError: "1:1: receive timeout must be an integer, got string",
+		},
+		{
+			code:          `supervisor(:rest_for_one, [])`,
+			expectedError: "1:1: unknown restart strategy :rest_for_one",
+		},
+		{
+			code:          `monitor(1)`,
+			expectedError: "1:1: unable to monitor integer",
+		},
+		{
+			code:          `spawn(1)`,
+			expectedError: "1:1: unable to spawn integer",
+		},
+		{
+			code:          `send(1, 2)`,
+			expectedError: "1:1: unable to send to integer",
+		},
+		{
+			code:          `let x = 1; let x = 2`,
+			expectedError: "1:16: x is already defined in this scope",
+		},
+		{
+			code:          `1 && true`,
+			expectedError: "1:1: expected boolean, got integer",
+		},
+		{
+			code:          `false || "yes"`,
+			expectedError: "1:1: expected boolean, got string",
+		},
+		{
+			code:          `next(1)`,
+			expectedError: "1:1: unable to resume integer",
+		},
+		{
+			code:          `let broken = fn() { yield 1 / 0 }; next(broken())`,
+			expectedError: "1:36: generator broken failed: 1:27: division by zero",
 		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.code, func(t *testing.T) {
-			_, err := runInVM(testCase.code)
-			assert.Error(t, err)
-			assert.EqualError(t, err, testCase.expectedError)
+			for _, level := range []compiler.OptimizationLevel{compiler.O0, compiler.O2} {
+				_, err := runInVM(testCase.code, level)
+				assert.EqualError(t, err, testCase.expectedError, "optimization level %d", level)
+			}
 		})
 	}
 }
+
+func Test_Run_withError_stackTrace(t *testing.T) {
+	code := `let inner = fn(a) { a };
+let outer = fn() {
+  inner(1, 2)
+};
+fn() { outer() }()`
+
+	_, err := runInVM(code, compiler.O0)
+
+	runtimeError, ok := err.(*RuntimeError)
+	assert.True(t, ok)
+	assert.Equal(
+		t,
+		"3:3: mismatched number of function call arguments. Expected 1, got 2\n"+
+			"\tat outer (3:3)\n"+
+			"\tat <anonymous> (5:8)\n"+
+			"\tat <main> (5:1)",
+		runtimeError.StackTrace(),
+	)
+}
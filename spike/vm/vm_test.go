@@ -1,6 +1,8 @@
 package vm
 
 import (
+	"io/ioutil"
+	"os"
 	"spike-interpreter-go/spike/compiler"
 	"spike-interpreter-go/spike/lexer"
 	"spike-interpreter-go/spike/object"
@@ -48,6 +50,30 @@ func Test_Run(t *testing.T) {
 			code:             "1 > 2",
 			expectedStackTop: False,
 		},
+		{
+			code:             "[1 <= 2, 2 <= 2, 3 <= 2, 1 >= 2, 2 >= 2, 3 >= 2]",
+			expectedStackTop: &object.Array{Elements: []object.Object{True, True, False, False, True, True}},
+		},
+		{
+			code:             "[1.5 <= 1, 1 >= 1.0, (1, 2) <= (1, 3), (1, 2) >= (1, 3)]",
+			expectedStackTop: &object.Array{Elements: []object.Object{False, True, True, False}},
+		},
+		{
+			code:             "[true && true, true && false, false && true, false && false]",
+			expectedStackTop: &object.Array{Elements: []object.Object{True, False, False, False}},
+		},
+		{
+			code:             "[true || true, true || false, false || true, false || false]",
+			expectedStackTop: &object.Array{Elements: []object.Object{True, True, True, False}},
+		},
+		{
+			code:             "[false && 1 / 0 == 0, true || 1 / 0 == 0]",
+			expectedStackTop: &object.Array{Elements: []object.Object{False, True}},
+		},
+		{
+			code:             "let inRange = fn(x) { x >= 1 && x <= 10 || x == 42 }; [inRange(0), inRange(5), inRange(42)]",
+			expectedStackTop: &object.Array{Elements: []object.Object{False, True, True}},
+		},
 		{
 			code:             "1 == 2",
 			expectedStackTop: False,
@@ -276,18 +302,321 @@ func Test_Run(t *testing.T) {
 			`,
 			expectedStackTop: &object.Integer{Value: 3},
 		},
+		{
+			code:             `"a\tb\n" + "c"`,
+			expectedStackTop: &object.String{Value: "a\tb\nc"},
+		},
+		{
+			code:             `let name = "Spike"; let n = 2; "Hello ${name}, ${n + 1} times"`,
+			expectedStackTop: &object.String{Value: "Hello Spike, 3 times"},
+		},
+		{
+			code:             `let f = fn(x) { "<${x}>" }; f(true)`,
+			expectedStackTop: &object.String{Value: "<true>"},
+		},
+		{
+			code:             "1.5 + 2",
+			expectedStackTop: &object.Float{Value: 3.5},
+		},
+		{
+			code:             "(10 - 2.5) * 2 / 4",
+			expectedStackTop: &object.Float{Value: 3.75},
+		},
+		{
+			code:             "-1.5 < 1",
+			expectedStackTop: True,
+		},
+		{
+			code:             "2.0 == 2",
+			expectedStackTop: True,
+		},
+		{
+			code:             "let average = fn(a, b) { (a + b) / 2.0 }; average(1, 2)",
+			expectedStackTop: &object.Float{Value: 1.5},
+		},
+		{
+			code: `[int(2.9), float("1e3"), round(2.5)]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{
+				&object.Integer{Value: 2},
+				&object.Float{Value: 1000},
+				&object.Integer{Value: 3},
+			}},
+		},
+		{
+			code:             `let factorial = fn(n) { if (n == 1) { return 1; } n * factorial(n - 1) }; str(factorial(25))`,
+			expectedStackTop: &object.String{Value: "15511210043330985984000000"},
+		},
+		{
+			code:             "9223372036854775807 + 1 > 9223372036854775807",
+			expectedStackTop: True,
+		},
+		{
+			code: `let Person = (123, "Lukasz"); [Person, Person[1], len(Person), (1,)]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{
+				&object.Tuple{Elements: []object.Object{
+					&object.Integer{Value: 123},
+					&object.String{Value: "Lukasz"},
+				}},
+				&object.String{Value: "Lukasz"},
+				&object.Integer{Value: 2},
+				&object.Tuple{Elements: []object.Object{&object.Integer{Value: 1}}},
+			}},
+		},
+		{
+			code:             "()[0]",
+			expectedStackTop: Null,
+		},
+		{
+			code:             "(1, (2, 3)) == (1, (2, 3))",
+			expectedStackTop: True,
+		},
+		{
+			code:             `let Place = (:place, 222, "Krakow"); Place[0] == :place`,
+			expectedStackTop: True,
+		},
+		{
+			code:             `[:person != :place, :a < :b]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{True, True}},
+		},
+		{
+			code:             `{:name: "kenny"}[:name]`,
+			expectedStackTop: &object.String{Value: "kenny"},
+		},
+		{
+			code: `
+			let Person = (:person, 123, "Lukasz");
+			case Person of
+				(:person, Id, Name) -> { "Person: " + Name }
+				(:place, Id, Name) -> { "Place: " + Name }
+			end`,
+			expectedStackTop: &object.String{Value: "Person: Lukasz"},
+		},
+		{
+			code: `
+			let classify = fn(n) {
+				case n of
+					0 -> { "zero" }
+					x when x < 0 -> { "negative" }
+					_ -> { "positive" }
+				end
+			};
+			[classify(0), classify(-5), classify(7)]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{
+				&object.String{Value: "zero"},
+				&object.String{Value: "negative"},
+				&object.String{Value: "positive"},
+			}},
+		},
+		{
+			code:             `let sum = fn(xs) { case xs of [] -> { 0 } [head, ...tail] -> { head + sum(tail) } end }; sum([1, 2, 3, 4])`,
+			expectedStackTop: &object.Integer{Value: 10},
+		},
+		{
+			code:             `case {"name": "kenny", "age": 31} of {"name": N, "age": 30} -> { 1 } {"name": N} -> { N } end`,
+			expectedStackTop: &object.String{Value: "kenny"},
+		},
+		{
+			code:             `case 2.0 of "2" -> { :string } -2 -> { :negative } 2 -> { :number } end`,
+			expectedStackTop: object.NewAtom("number"),
+		},
+		{
+			code:             `case (1, [2, (3, 4)]) of (a, [b, (c, d)]) -> { a + b + c + d } end`,
+			expectedStackTop: &object.Integer{Value: 10},
+		},
+		{
+			code: `case [1, 2, 3] of [_, ...rest] when len(rest) > 5 -> { 0 } [_, ...rest] -> { rest } end`,
+			expectedStackTop: &object.Array{Elements: []object.Object{
+				&object.Integer{Value: 2},
+				&object.Integer{Value: 3},
+			}},
+		},
+		{
+			code:             "case 1 of 1 -> { } end",
+			expectedStackTop: Null,
+		},
+		{
+			code:             "let X = 10; let inner = case 3 of X -> { X } end; [inner, X]",
+			expectedStackTop: &object.Array{Elements: []object.Object{&object.Integer{Value: 3}, &object.Integer{Value: 10}}},
+		},
+		{
+			code:             "let f = fn(x) { case x of (x, y) -> { let z = x + y; z } end }; f((1, 2))",
+			expectedStackTop: &object.Integer{Value: 3},
+		},
+		{
+			code:             "(1, 2) < (1, 3)",
+			expectedStackTop: True,
+		},
+		{
+			code:             `{(1, "a"): "first"}[(1, "a")]`,
+			expectedStackTop: &object.String{Value: "first"},
+		},
+		{
+			code:             "(9223372036854775807 + 1) - 1",
+			expectedStackTop: &object.Integer{Value: 9223372036854775807},
+		},
+		{
+			code:             `let pick = fn(hash, key) { hash[key] }; pick({"a": 1, "b": 2}, "b")`,
+			expectedStackTop: &object.Integer{Value: 2},
+		},
+		{
+			code:             `let user = {"name": "kenny"}; user.name`,
+			expectedStackTop: &object.String{Value: "kenny"},
+		},
+		{
+			code: `
+			process Counter(start) {
+				let count = start;
+				cast add(n) { return {"count": this.count + n} }
+				call get() { this.count }
+			}
+			let counter = spawn Counter(10);
+			counter.add(1);
+			counter.add(2);
+			counter.get()`,
+			expectedStackTop: &object.Integer{Value: 13},
+		},
+		{
+			code: `
+			process User {
+				let name = "";
+				cast rename(newName) { return {"name": newName} }
+				call greet(greeting) { greeting + ", " + this.name }
+			}
+			let first = spawn User();
+			let second = spawn User();
+			first.rename("kenny");
+			second.rename("lukasz");
+			[first.greet("Hi"), second.greet("Hello"), first == second]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{
+				&object.String{Value: "Hi, kenny"},
+				&object.String{Value: "Hello, lukasz"},
+				False,
+			}},
+		},
+		{
+			code: `
+			let echo = fn() { receive (:ping, from) -> { send(from, :pong) } end };
+			let pid = spawn(echo);
+			send(pid, (:ping, self()));
+			receive :pong -> { "got pong" } after 1000 -> { "timeout" } end`,
+			expectedStackTop: &object.String{Value: "got pong"},
+		},
+		{
+			code: `
+			let me = self();
+			send(me, 1);
+			send(me, :b);
+			send(me, 2);
+			let first = receive :b -> { "b" } end;
+			let second = receive x -> { x } end;
+			[first, second, receive x -> { x } end]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{
+				&object.String{Value: "b"},
+				&object.Integer{Value: 1},
+				&object.Integer{Value: 2},
+			}},
+		},
+		{
+			code:             `send(self(), 5); send(self(), 12); receive x when x > 10 -> { x } end`,
+			expectedStackTop: &object.Integer{Value: 12},
+		},
+		{
+			code:             `send(self(), :late); receive :early -> { 1 } after 10 -> { :timeout } end`,
+			expectedStackTop: object.NewAtom("timeout"),
+		},
+		{
+			code:             `let parent = self(); spawn(fn(n) { send(parent, n * 2) }, 21); receive x -> { x } end`,
+			expectedStackTop: &object.Integer{Value: 42},
+		},
+		{
+			code:             `receive after 0 -> { } end`,
+			expectedStackTop: Null,
+		},
+		{
+			code:             `spawn_link(fn() { 1 / 0 }); receive (:EXIT, _, reason) -> { reason } end`,
+			expectedStackTop: &object.String{Value: "1:19: division by zero"},
+		},
+		{
+			code: `
+			let pid = spawn(fn() { receive :stop -> { } end });
+			monitor(pid);
+			send(pid, :stop);
+			receive (:DOWN, p, reason) when p == pid -> { reason } end`,
+			expectedStackTop: object.NewAtom("normal"),
+		},
+		{
+			code: `
+			let parent = self();
+			let worker = fn() { send(parent, (:started, self())); receive :crash -> { 1 / 0 } end };
+			spawn(supervisor(:one_for_one, [worker]));
+			let first = receive (:started, pid) -> { pid } end;
+			send(first, :crash);
+			let second = receive (:started, pid) -> { pid } end;
+			first != second`,
+			expectedStackTop: True,
+		},
+		{
+			code: `
+			let parent = self();
+			let worker = fn(name) { fn() { send(parent, (name, self())); receive :crash -> { 1 / 0 } end } };
+			spawn(supervisor(:one_for_all, [worker(:a), worker(:b)]));
+			let a = receive (:a, pid) -> { pid } end;
+			let b = receive (:b, pid) -> { pid } end;
+			send(a, :crash);
+			let restartedA = receive (:a, pid) -> { pid } end;
+			let restartedB = receive (:b, pid) -> { pid } end;
+			[a != restartedA, b != restartedB]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{True, True}},
+		},
+		{
+			code: `
+			let crasher = fn() { 1 / 0 };
+			let inner = supervisor(:one_for_one, [crasher], {"max_restarts": 0});
+			let outer = spawn(supervisor(:one_for_one, [inner], {"max_restarts": 1, "period": 1000}));
+			monitor(outer);
+			receive (:DOWN, _, reason) -> { reason } after 2000 -> { :timeout } end`,
+			expectedStackTop: &object.String{Value: "reached maximum restart intensity: reached maximum restart intensity: 2:25: division by zero"},
+		},
+		{
+			code:             `let numbers = fn() { yield 1; yield 2; 3 }; let g = numbers(); [next(g), next(g), done(g), next(g), done(g), next(g)]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}, False, &object.Integer{Value: 3}, True, Null}},
+		},
+		{
+			code: `
+			let naturals = fn(n) { yield n; naturals(n + 1) };
+			let g = naturals(5);
+			[next(g), next(g), next(g)]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{&object.Integer{Value: 5}, &object.Integer{Value: 6}, &object.Integer{Value: 7}}},
+		},
+		{
+			code:             `let echo = fn() { let a = yield :ready; let b = yield a * 2; a + b }; let g = echo(); [next(g), next(g, 10), next(g, 5)]`,
+			expectedStackTop: &object.Array{Elements: []object.Object{object.NewAtom("ready"), &object.Integer{Value: 20}, &object.Integer{Value: 15}}},
+		},
+		{
+			code: `
+			let count = fn(n, limit) { if (n < limit) { yield n; count(n + 1, limit) } };
+			let sum = fn(g, total) { let value = next(g); if (done(g)) { total } else { sum(g, total + value) } };
+			sum(count(0, 100), 0)`,
+			expectedStackTop: &object.Integer{Value: 4950},
+		},
+		{
+			code:             `let lazy = fn() { send(self(), :started); yield 1 }; let g = lazy(); receive :started -> { 1 } after 0 -> { :idle } end`,
+			expectedStackTop: object.NewAtom("idle"),
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.code, func(t *testing.T) {
-			stackTop, err := runInVM(testCase.code)
-			assert.NoError(t, err)
-			assert.Equal(t, testCase.expectedStackTop, stackTop)
+			for _, level := range []compiler.OptimizationLevel{compiler.O0, compiler.O2} {
+				stackTop, err := runInVM(testCase.code, level)
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedStackTop, stackTop, "optimization level %d", level)
+			}
 		})
 	}
 }
 
-func runInVM(input string) (object.Object, error) {
+func runInVM(input string, level compiler.OptimizationLevel) (object.Object, error) {
 	l := lexer.New(strings.NewReader(input))
 	p := parser.New(l)
 	c := compiler.New()
@@ -302,7 +631,14 @@ func runInVM(input string) (object.Object, error) {
 		return nil, err
 	}
 
-	vm := New(c.Bytecode())
+	// Bytecode of the compiler and the optimizer must pass the verifier.
+	bytecode := compiler.Optimize(c.Bytecode(), level)
+	err = Verify(bytecode)
+	if err != nil {
+		return nil, err
+	}
+
+	vm := New(bytecode)
 
 	err = vm.Run()
 	if err != nil {
@@ -311,3 +647,142 @@ func runInVM(input string) (object.Object, error) {
 
 	return vm.LastPoppedStackElement(), nil
 }
+
+func Test_RunFor(t *testing.T) {
+	code := `let countdown = fn(n) { if (n == 0) { return "done" } countdown(n - 1) }; countdown(50)`
+	vm := newVM(t, code)
+
+	slices := 0
+	for {
+		state, err := vm.RunFor(10)
+		assert.NoError(t, err)
+		slices++
+
+		if state == Finished {
+			break
+		}
+	}
+
+	assert.True(t, slices > 1)
+	assert.Equal(t, &object.String{Value: "done"}, vm.LastPoppedStackElement())
+
+	state, err := vm.RunFor(10)
+	assert.NoError(t, err)
+	assert.Equal(t, Finished, state)
+}
+
+func Test_RunFor_resumedByRun(t *testing.T) {
+	vm := newVM(t, `let a = 1; let b = a + 2; b * 3`)
+
+	state, err := vm.RunFor(2)
+	assert.NoError(t, err)
+	assert.Equal(t, Suspended, state)
+
+	assert.NoError(t, vm.Run())
+	assert.Equal(t, &object.Integer{Value: 9}, vm.LastPoppedStackElement())
+}
+
+func Test_RunFor_withError(t *testing.T) {
+	vm := newVM(t, `let a = 1; a / 0`)
+
+	_, err := vm.RunFor(1)
+	assert.NoError(t, err)
+
+	_, err = vm.RunFor(100)
+	assert.EqualError(t, err, "1:12: division by zero")
+
+	_, err = vm.RunFor(0)
+	assert.EqualError(t, err, "budget must be positive, got 0")
+}
+
+func Test_RunFor_yieldsInReceive(t *testing.T) {
+	vm := newVM(t, `receive (:add, a, b) -> { a + b } end`)
+
+	state, err := vm.RunFor(100)
+	assert.NoError(t, err)
+	assert.Equal(t, Suspended, state)
+
+	vm.Process().Mailbox.Send(&object.Tuple{Elements: []object.Object{
+		object.NewAtom("add"),
+		&object.Integer{Value: 1},
+		&object.Integer{Value: 2},
+	}})
+
+	state, err = vm.RunFor(100)
+	assert.NoError(t, err)
+	assert.Equal(t, Finished, state)
+	assert.Equal(t, &object.Integer{Value: 3}, vm.LastPoppedStackElement())
+}
+
+func Test_Generator_streamsLazily(t *testing.T) {
+	vm := newVM(t, `let naturals = fn(n) { yield n; naturals(n + 1) }; naturals(0)`)
+	assert.NoError(t, vm.Run())
+
+	generator, ok := vm.LastPoppedStackElement().(*object.Generator)
+	assert.True(t, ok)
+
+	for i := 0; i < 100000; i++ {
+		value, err := generator.Next(Null)
+		assert.NoError(t, err)
+		assert.Equal(t, &object.Integer{Value: int64(i)}, value)
+	}
+	assert.False(t, generator.Done())
+}
+
+func Test_Run_spawnArguments(t *testing.T) {
+	vm := newVM(t, `
+	process Counter(start) {
+		let count = start;
+		call get() { this.count }
+	}
+	let worker = fn(n) { receive (:get, from) -> { send(from, n) } end };
+	let sum = fn(n) {
+		if (n == 0) {
+			0
+		} else {
+			let counter = spawn Counter(n);
+			let pid = spawn(worker, n);
+			let noise = {"count": "get"};
+			send(pid, (:get, self()));
+			sum(n - 1) + counter.get() + receive x -> { x } end
+		}
+	};
+	sum(50)`)
+	assert.NoError(t, vm.Run())
+	assert.Equal(t, &object.Integer{Value: 2550}, vm.LastPoppedStackElement())
+}
+
+func newVM(t *testing.T, input string) *VM {
+	program, err := parser.New(lexer.New(strings.NewReader(input))).ParseProgram()
+	assert.NoError(t, err)
+
+	c := compiler.New()
+	assert.NoError(t, c.Compile(program))
+
+	return New(c.Bytecode())
+}
+
+func Test_NewFromFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "spike*.spkc")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	program, err := parser.New(lexer.New(strings.NewReader("let square = fn(x) { x * x }; square(12)"))).ParseProgram()
+	assert.NoError(t, err)
+	c := compiler.New()
+	assert.NoError(t, c.Compile(program))
+
+	data, err := compiler.Marshal(c.Bytecode())
+	assert.NoError(t, err)
+	_, err = file.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	machine, err := NewFromFile(file.Name())
+	assert.NoError(t, err)
+	assert.NoError(t, machine.Run())
+	assert.Equal(t, &object.Integer{Value: 144}, machine.LastPoppedStackElement())
+
+	_, err = NewFromFile(os.DevNull)
+	assert.EqualError(t, err, "loading "+os.DevNull+": not a Spike bytecode file")
+}